@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/brendenbissett/help-me-budget/api/internal/admin"
 	"github.com/brendenbissett/help-me-budget/api/internal/auth"
 	"github.com/brendenbissett/help-me-budget/api/internal/budget"
 	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/brendenbissett/help-me-budget/api/internal/jobs"
+	"github.com/brendenbissett/help-me-budget/api/internal/mailer"
 	"github.com/brendenbissett/help-me-budget/api/internal/middleware"
+	"github.com/brendenbissett/help-me-budget/api/internal/ocr"
+	"github.com/brendenbissett/help-me-budget/api/internal/storage"
+	"github.com/brendenbissett/help-me-budget/api/internal/webhooks"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/joho/godotenv"
@@ -45,9 +53,45 @@ func main() {
 		log.Fatal("API_SECRET_KEY environment variable is required")
 	}
 
+	// Email digests are only sent when SMTP is configured
+	if digestMailer, err := mailer.NewSMTPMailerFromEnv(); err != nil {
+		log.Printf("Email digests disabled: %v", err)
+	} else {
+		budget.ConfigureDigests(digestMailer)
+	}
+
+	// Transaction attachments are kept on local disk unless STORAGE_DRIVER=s3
+	attachmentStore, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Error initializing attachment storage:", err)
+	}
+	budget.ConfigureAttachments(attachmentStore, []byte(apiSecret))
+
+	// Receipt extraction needs tesseract installed (and poppler-utils for scanned PDFs)
+	if receiptEngine, err := ocr.NewTesseractEngineFromEnv(); err != nil {
+		log.Printf("Receipt extraction disabled: %v", err)
+	} else {
+		budget.ConfigureReceiptExtraction(receiptEngine)
+	}
+
+	// Start background job workers
+	budget.RegisterJobHandlers()
+	webhooks.RegisterJobHandlers()
+	budget.RegisterScheduledTasks()
+	jobWorkers, err := strconv.Atoi(os.Getenv("JOB_WORKERS"))
+	if err != nil || jobWorkers < 1 {
+		jobWorkers = 2
+	}
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	jobs.StartWorkers(workerCtx, jobWorkers)
+	jobs.StartScheduler(workerCtx)
+
 	app := fiber.New(fiber.Config{
 		// Increase header size limit to handle large browser cookies/headers
 		ReadBufferSize: 16384, // 16KB (default is 4KB)
+		// Allow larger bodies for data imports
+		BodyLimit: 32 * 1024 * 1024, // 32MB (default is 4MB)
 	})
 
 	// Set up middleware
@@ -79,5 +123,14 @@ func main() {
 	// Setup budget routes (accounts, categories, budgets, transactions)
 	budget.SetupBudgetRoutes(app)
 
+	// Setup background job status routes
+	jobs.SetupJobRoutes(app)
+
+	// Setup outgoing webhook routes
+	webhooks.SetupWebhookRoutes(app)
+
+	// Setup real-time event stream routes
+	events.SetupEventRoutes(app)
+
 	log.Fatal(app.Listen(":3000"))
 }
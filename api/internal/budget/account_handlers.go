@@ -3,6 +3,7 @@ package budget
 import (
 	"log"
 
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -134,6 +135,8 @@ func CreateAccountHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	events.Publish(c.Context(), userID, events.AccountCreated, account)
+
 	return c.Status(fiber.StatusCreated).JSON(account)
 }
 
@@ -189,6 +192,8 @@ func UpdateAccountHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	events.Publish(c.Context(), userID, events.AccountUpdated, account)
+
 	return c.JSON(account)
 }
 
@@ -221,6 +226,8 @@ func DeleteAccountHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	events.Publish(c.Context(), userID, events.AccountDeleted, fiber.Map{"id": accountID})
+
 	return c.JSON(fiber.Map{
 		"message": "Account deleted successfully",
 	})
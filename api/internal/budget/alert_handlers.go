@@ -0,0 +1,363 @@
+package budget
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// alertReferenceErrors maps missing references on an alert rule to client errors
+var alertReferenceErrors = map[string]string{
+	"category not found":     "Category not found",
+	"budget entry not found": "Budget entry not found",
+	"account not found":      "Account not found",
+}
+
+// GetAlertRulesHandler returns all of a user's alert rules
+func GetAlertRulesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	rules, err := GetAlertRulesByUserID(c.Context(), userID, false)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve alert rules",
+		})
+	}
+
+	if rules == nil {
+		rules = []AlertRule{}
+	}
+
+	return c.JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+// CreateAlertRuleHandler creates a new alert rule
+func CreateAlertRuleHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateAlertRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule := &AlertRule{
+		Name:          req.Name,
+		RuleType:      req.RuleType,
+		CategoryID:    req.CategoryID,
+		BudgetEntryID: req.BudgetEntryID,
+		AccountID:     req.AccountID,
+		Threshold:     req.Threshold,
+		DaysAhead:     req.DaysAhead,
+		IsActive:      true,
+	}
+	applyAlertRuleDefaults(rule)
+
+	if err := validateAlertRule(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkAlertRuleReferences(c.Context(), userID, rule); err != nil {
+		if message, ok := alertReferenceErrors[err.Error()]; ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create alert rule",
+		})
+	}
+
+	created, err := CreateAlertRule(c.Context(), userID, rule)
+	if err != nil {
+		log.Printf("Error creating alert rule for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create alert rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// UpdateAlertRuleHandler updates an existing alert rule
+func UpdateAlertRuleHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid alert rule ID",
+		})
+	}
+
+	var req UpdateAlertRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := GetAlertRuleByID(c.Context(), ruleID, userID)
+	if err != nil {
+		if err.Error() == "alert rule not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Alert rule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update alert rule",
+		})
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.CategoryID != nil {
+		rule.CategoryID = req.CategoryID
+	}
+	if req.BudgetEntryID != nil {
+		rule.BudgetEntryID = req.BudgetEntryID
+	}
+	if req.AccountID != nil {
+		rule.AccountID = req.AccountID
+	}
+	if req.Threshold != nil {
+		rule.Threshold = req.Threshold
+	}
+	if req.DaysAhead != nil {
+		rule.DaysAhead = req.DaysAhead
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if err := validateAlertRule(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := checkAlertRuleReferences(c.Context(), userID, rule); err != nil {
+		if message, ok := alertReferenceErrors[err.Error()]; ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update alert rule",
+		})
+	}
+
+	updated, err := UpdateAlertRule(c.Context(), userID, rule)
+	if err != nil {
+		if err.Error() == "alert rule not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Alert rule not found",
+			})
+		}
+		log.Printf("Error updating alert rule %s: %v", ruleID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update alert rule",
+		})
+	}
+
+	return c.JSON(updated)
+}
+
+// DeleteAlertRuleHandler deletes an alert rule
+func DeleteAlertRuleHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid alert rule ID",
+		})
+	}
+
+	if err := DeleteAlertRule(c.Context(), ruleID, userID); err != nil {
+		if err.Error() == "alert rule not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Alert rule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete alert rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Alert rule deleted successfully",
+	})
+}
+
+// EvaluateAlertsHandler evaluates the user's alert rules now instead of
+// waiting for the next transaction write or daily run
+func EvaluateAlertsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	created, err := EvaluateAlerts(c.Context(), userID, nil)
+	if err != nil {
+		log.Printf("Error evaluating alerts for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to evaluate alerts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"notifications": created,
+	})
+}
+
+// GetNotificationsHandler returns the user's notifications, newest first
+// (supports ?unread=true and ?limit=50)
+func GetNotificationsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	limit := 50
+	if limitParam := c.QueryInt("limit", 50); limitParam > 0 && limitParam <= 200 {
+		limit = limitParam
+	}
+
+	notifications, err := GetNotifications(c.Context(), userID, c.QueryBool("unread"), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve notifications",
+		})
+	}
+
+	unreadCount, err := GetUnreadNotificationCount(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve notifications",
+		})
+	}
+
+	if notifications == nil {
+		notifications = []Notification{}
+	}
+
+	return c.JSON(fiber.Map{
+		"notifications": notifications,
+		"unread_count":  unreadCount,
+	})
+}
+
+// GetUnreadNotificationCountHandler returns how many notifications are unread
+func GetUnreadNotificationCountHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	unreadCount, err := GetUnreadNotificationCount(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve notifications",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"unread_count": unreadCount,
+	})
+}
+
+// MarkNotificationReadHandler marks a notification read
+func MarkNotificationReadHandler(c *fiber.Ctx) error {
+	return setNotificationRead(c, true)
+}
+
+// MarkNotificationUnreadHandler marks a notification unread
+func MarkNotificationUnreadHandler(c *fiber.Ctx) error {
+	return setNotificationRead(c, false)
+}
+
+// setNotificationRead handles the read and unread endpoints
+func setNotificationRead(c *fiber.Ctx, read bool) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	notificationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid notification ID",
+		})
+	}
+
+	notification, err := SetNotificationRead(c.Context(), notificationID, userID, read)
+	if err != nil {
+		if err.Error() == "notification not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Notification not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update notification",
+		})
+	}
+
+	return c.JSON(notification)
+}
+
+// MarkAllNotificationsReadHandler marks all of the user's notifications read
+func MarkAllNotificationsReadHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	updated, err := MarkAllNotificationsRead(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update notifications",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"updated": updated,
+	})
+}
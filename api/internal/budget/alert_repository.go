@@ -0,0 +1,326 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const alertRuleColumns = `id, user_id, name, rule_type, category_id, budget_entry_id, account_id,
+	threshold, days_ahead, is_active, created_at, updated_at`
+
+// scanAlertRule scans a single alert rule row
+func scanAlertRule(row pgx.Row) (*AlertRule, error) {
+	var rule AlertRule
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.Name,
+		&rule.RuleType,
+		&rule.CategoryID,
+		&rule.BudgetEntryID,
+		&rule.AccountID,
+		&rule.Threshold,
+		&rule.DaysAhead,
+		&rule.IsActive,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetAlertRulesByUserID retrieves a user's alert rules, optionally only active ones
+func GetAlertRulesByUserID(ctx context.Context, userID uuid.UUID, activeOnly bool) ([]AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM budget.alert_rules
+		WHERE user_id = $1 AND (is_active OR NOT $2)
+		ORDER BY created_at
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// GetAlertRuleByID retrieves a specific alert rule
+func GetAlertRuleByID(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) (*AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM budget.alert_rules
+		WHERE id = $1 AND user_id = $2
+	`
+
+	rule, err := scanAlertRule(database.DB.QueryRow(ctx, query, ruleID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("alert rule not found")
+		}
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// CreateAlertRule saves a new alert rule. The rule must already be validated.
+func CreateAlertRule(ctx context.Context, userID uuid.UUID, rule *AlertRule) (*AlertRule, error) {
+	query := `
+		INSERT INTO budget.alert_rules
+		(user_id, name, rule_type, category_id, budget_entry_id, account_id, threshold, days_ahead)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + alertRuleColumns
+
+	created, err := scanAlertRule(database.DB.QueryRow(ctx, query,
+		userID,
+		rule.Name,
+		rule.RuleType,
+		rule.CategoryID,
+		rule.BudgetEntryID,
+		rule.AccountID,
+		rule.Threshold,
+		rule.DaysAhead,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	return created, nil
+}
+
+// UpdateAlertRule saves changes to an alert rule. The rule must already be validated.
+func UpdateAlertRule(ctx context.Context, userID uuid.UUID, rule *AlertRule) (*AlertRule, error) {
+	query := `
+		UPDATE budget.alert_rules
+		SET name = $3, category_id = $4, budget_entry_id = $5, account_id = $6,
+		    threshold = $7, days_ahead = $8, is_active = $9
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + alertRuleColumns
+
+	updated, err := scanAlertRule(database.DB.QueryRow(ctx, query,
+		rule.ID,
+		userID,
+		rule.Name,
+		rule.CategoryID,
+		rule.BudgetEntryID,
+		rule.AccountID,
+		rule.Threshold,
+		rule.DaysAhead,
+		rule.IsActive,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("alert rule not found")
+		}
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteAlertRule deletes an alert rule. Notifications it raised are kept.
+func DeleteAlertRule(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error {
+	result, err := database.DB.Exec(ctx, `
+		DELETE FROM budget.alert_rules WHERE id = $1 AND user_id = $2
+	`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+
+	return nil
+}
+
+// getUsersWithActiveAlertRules lists users the daily evaluation needs to visit
+func getUsersWithActiveAlertRules(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT DISTINCT r.user_id
+		FROM budget.alert_rules r
+		JOIN auth.users u ON u.id = r.user_id
+		WHERE r.is_active AND u.is_active = true
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users with alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users with alert rules: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+const notificationColumns = `id, user_id, alert_rule_id, notification_type, severity, title, message,
+	data, read_at, created_at`
+
+// scanNotification scans a single notification row
+func scanNotification(row pgx.Row) (*Notification, error) {
+	var notification Notification
+	var data []byte
+	err := row.Scan(
+		&notification.ID,
+		&notification.UserID,
+		&notification.AlertRuleID,
+		&notification.NotificationType,
+		&notification.Severity,
+		&notification.Title,
+		&notification.Message,
+		&data,
+		&notification.ReadAt,
+		&notification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &notification.Data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification data: %w", err)
+	}
+	notification.IsRead = notification.ReadAt != nil
+	return &notification, nil
+}
+
+// createNotification stores a notification unless one with the same dedupe
+// key already exists, in which case it returns nil
+func createNotification(ctx context.Context, userID uuid.UUID, n newNotification) (*Notification, error) {
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	query := `
+		INSERT INTO budget.notifications
+		(user_id, alert_rule_id, notification_type, severity, title, message, data, dedupe_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING ` + notificationColumns
+
+	notification, err := scanNotification(database.DB.QueryRow(ctx, query,
+		userID,
+		n.AlertRuleID,
+		n.NotificationType,
+		n.Severity,
+		n.Title,
+		n.Message,
+		data,
+		n.DedupeKey,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // Already raised
+		}
+		return nil, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return notification, nil
+}
+
+// GetNotifications retrieves a user's most recent notifications
+func GetNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM budget.notifications
+		WHERE user_id = $1 AND (read_at IS NULL OR NOT $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, *notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// GetUnreadNotificationCount counts a user's unread notifications
+func GetUnreadNotificationCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := database.DB.QueryRow(ctx, `
+		SELECT COUNT(*) FROM budget.notifications WHERE user_id = $1 AND read_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// SetNotificationRead marks a notification read or unread
+func SetNotificationRead(ctx context.Context, notificationID uuid.UUID, userID uuid.UUID, read bool) (*Notification, error) {
+	query := `
+		UPDATE budget.notifications
+		SET read_at = CASE WHEN $3 THEN COALESCE(read_at, CURRENT_TIMESTAMP) ELSE NULL END
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + notificationColumns
+
+	notification, err := scanNotification(database.DB.QueryRow(ctx, query, notificationID, userID, read))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("notification not found")
+		}
+		return nil, fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	return notification, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification read and returns how many changed
+func MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := database.DB.Exec(ctx, `
+		UPDATE budget.notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
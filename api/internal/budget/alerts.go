@@ -0,0 +1,593 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/export"
+	"github.com/brendenbissett/help-me-budget/api/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// alertScheduleInterval is how often every user's alert rules are evaluated
+// outside of transaction writes
+const alertScheduleInterval = 24 * time.Hour
+
+// alertEvaluationTimeout bounds an evaluation started by a transaction write
+const alertEvaluationTimeout = 30 * time.Second
+
+// Default rule settings, applied when a rule is created without them
+const (
+	defaultSpendWarningPercent = 80
+	defaultBillDueDays         = 3
+	defaultLowBalanceDays      = 30
+)
+
+// AlertRule is a user-configured condition that raises a notification
+type AlertRule struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Name          string     `json:"name"`
+	RuleType      string     `json:"rule_type"`                 // 'category_spend', 'entry_spend', 'bill_due', 'bill_overdue', 'low_balance', 'large_transaction'
+	CategoryID    *uuid.UUID `json:"category_id,omitempty"`     // category_spend
+	BudgetEntryID *uuid.UUID `json:"budget_entry_id,omitempty"` // entry_spend; limits bill_due/bill_overdue to one entry
+	AccountID     *uuid.UUID `json:"account_id,omitempty"`      // Limits low_balance/large_transaction to one account
+	Threshold     *float64   `json:"threshold,omitempty"`       // Warning percent for spend rules (100% always alerts too), amount for low_balance/large_transaction
+	DaysAhead     *int       `json:"days_ahead,omitempty"`      // bill_due: days before the due date; low_balance: projection horizon
+	IsActive      bool       `json:"is_active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// CreateAlertRuleRequest represents the request body for creating an alert rule
+type CreateAlertRuleRequest struct {
+	Name          string     `json:"name"`
+	RuleType      string     `json:"rule_type"`
+	CategoryID    *uuid.UUID `json:"category_id,omitempty"`
+	BudgetEntryID *uuid.UUID `json:"budget_entry_id,omitempty"`
+	AccountID     *uuid.UUID `json:"account_id,omitempty"`
+	Threshold     *float64   `json:"threshold,omitempty"`
+	DaysAhead     *int       `json:"days_ahead,omitempty"`
+}
+
+// UpdateAlertRuleRequest represents the request body for updating an alert
+// rule. The rule type can't change.
+type UpdateAlertRuleRequest struct {
+	Name          *string    `json:"name,omitempty"`
+	CategoryID    *uuid.UUID `json:"category_id,omitempty"`
+	BudgetEntryID *uuid.UUID `json:"budget_entry_id,omitempty"`
+	AccountID     *uuid.UUID `json:"account_id,omitempty"`
+	Threshold     *float64   `json:"threshold,omitempty"`
+	DaysAhead     *int       `json:"days_ahead,omitempty"`
+	IsActive      *bool      `json:"is_active,omitempty"`
+}
+
+// Notification is a message raised by an alert rule
+type Notification struct {
+	ID               uuid.UUID              `json:"id"`
+	UserID           uuid.UUID              `json:"user_id"`
+	AlertRuleID      *uuid.UUID             `json:"alert_rule_id,omitempty"`
+	NotificationType string                 `json:"notification_type"` // 'budget_warning', 'budget_exceeded', 'bill_due', 'bill_overdue', 'low_balance', 'large_transaction'
+	Severity         string                 `json:"severity"`          // 'info', 'warning', 'critical'
+	Title            string                 `json:"title"`
+	Message          string                 `json:"message"`
+	Data             map[string]interface{} `json:"data"`
+	IsRead           bool                   `json:"is_read"`
+	ReadAt           *time.Time             `json:"read_at,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+// newNotification is a notification an evaluation wants to raise. DedupeKey
+// identifies the condition, so it's raised once however often rules run.
+type newNotification struct {
+	AlertRuleID      uuid.UUID
+	NotificationType string
+	Severity         string
+	Title            string
+	Message          string
+	Data             map[string]interface{}
+	DedupeKey        string
+}
+
+// applyAlertRuleDefaults fills in the threshold and horizon a rule type needs
+// when they weren't given
+func applyAlertRuleDefaults(rule *AlertRule) {
+	switch rule.RuleType {
+	case "category_spend", "entry_spend":
+		if rule.Threshold == nil {
+			threshold := float64(defaultSpendWarningPercent)
+			rule.Threshold = &threshold
+		}
+	case "bill_due":
+		if rule.DaysAhead == nil {
+			days := defaultBillDueDays
+			rule.DaysAhead = &days
+		}
+	case "low_balance":
+		if rule.Threshold == nil {
+			threshold := 0.0
+			rule.Threshold = &threshold
+		}
+		if rule.DaysAhead == nil {
+			days := defaultLowBalanceDays
+			rule.DaysAhead = &days
+		}
+	}
+}
+
+// validateAlertRule checks a rule has what its type needs
+func validateAlertRule(rule *AlertRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	switch rule.RuleType {
+	case "category_spend":
+		if rule.CategoryID == nil {
+			return fmt.Errorf("category_id is required for category_spend rules")
+		}
+	case "entry_spend":
+		if rule.BudgetEntryID == nil {
+			return fmt.Errorf("budget_entry_id is required for entry_spend rules")
+		}
+	case "bill_due", "bill_overdue", "low_balance":
+	case "large_transaction":
+		if rule.Threshold == nil || *rule.Threshold <= 0 {
+			return fmt.Errorf("Threshold must be a positive amount for large_transaction rules")
+		}
+	default:
+		return fmt.Errorf("Rule type must be one of category_spend, entry_spend, bill_due, bill_overdue, low_balance, large_transaction")
+	}
+
+	if (rule.RuleType == "category_spend" || rule.RuleType == "entry_spend") && (*rule.Threshold <= 0 || *rule.Threshold > 100) {
+		return fmt.Errorf("Threshold must be a percentage between 1 and 100 for spend rules")
+	}
+	if rule.RuleType == "bill_due" && (*rule.DaysAhead < 0 || *rule.DaysAhead > 60) {
+		return fmt.Errorf("Days ahead must be between 0 and 60 for bill_due rules")
+	}
+	if rule.RuleType == "low_balance" && (*rule.DaysAhead < 1 || *rule.DaysAhead > 365) {
+		return fmt.Errorf("Days ahead must be between 1 and 365 for low_balance rules")
+	}
+
+	return nil
+}
+
+// checkAlertRuleReferences verifies the category, entry and account a rule
+// points at belong to the user
+func checkAlertRuleReferences(ctx context.Context, userID uuid.UUID, rule *AlertRule) error {
+	if rule.CategoryID != nil {
+		if _, err := GetCategoryByID(ctx, *rule.CategoryID, userID); err != nil {
+			return err
+		}
+	}
+	if rule.BudgetEntryID != nil {
+		if _, err := GetBudgetEntryByID(ctx, *rule.BudgetEntryID, userID); err != nil {
+			return err
+		}
+	}
+	if rule.AccountID != nil {
+		if _, err := GetAccountByID(ctx, *rule.AccountID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alertEvaluation holds what several rules share during one evaluation, loaded
+// on first use
+type alertEvaluation struct {
+	userID      uuid.UUID
+	today       time.Time
+	monthStart  time.Time
+	monthEnd    time.Time
+	currency    string
+	transaction *Transaction // The write that triggered the evaluation, if any
+
+	entries  []BudgetEntry
+	ledger   *occurrenceLedger
+	tree     *categoryTree
+	expenses []categoryAmount
+}
+
+// EvaluateAlerts checks a user's active alert rules and stores a notification
+// for each condition that hasn't been raised before. When transaction is set
+// (an evaluation after a write) large_transaction rules only look at it;
+// otherwise they look at transactions dated yesterday or today. A rule that
+// fails to evaluate is logged and skipped so it can't block the others.
+func EvaluateAlerts(ctx context.Context, userID uuid.UUID, transaction *Transaction) ([]Notification, error) {
+	rules, err := GetAlertRulesByUserID(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return []Notification{}, nil
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	eval := &alertEvaluation{
+		userID:      userID,
+		today:       today,
+		monthStart:  monthStart,
+		monthEnd:    monthStart.AddDate(0, 1, -1),
+		currency:    userCurrency(ctx, userID, nil),
+		transaction: transaction,
+	}
+
+	created := []Notification{}
+	for _, rule := range rules {
+		pending, err := eval.evaluate(ctx, rule)
+		if err != nil {
+			log.Printf("Error evaluating alert rule %s for user %s: %v", rule.ID, userID, err)
+			continue
+		}
+
+		for _, n := range pending {
+			notification, err := createNotification(ctx, userID, n)
+			if err != nil {
+				return created, err
+			}
+			if notification != nil {
+				created = append(created, *notification)
+				publishNotificationEvent(ctx, userID, notification)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// publishNotificationEvent sends webhook events for notifications that have one
+func publishNotificationEvent(ctx context.Context, userID uuid.UUID, notification *Notification) {
+	switch notification.NotificationType {
+	case "budget_exceeded":
+		webhooks.Publish(ctx, userID, webhooks.EventBudgetExceeded, notification)
+	case "bill_overdue":
+		webhooks.Publish(ctx, userID, webhooks.EventBillOverdue, notification)
+	}
+}
+
+// evaluateAlertsAfterWrite evaluates a user's alerts in the background after
+// a transaction changes, so the request doesn't wait on it
+func evaluateAlertsAfterWrite(userID uuid.UUID, transaction *Transaction) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), alertEvaluationTimeout)
+		defer cancel()
+
+		if _, err := EvaluateAlerts(ctx, userID, transaction); err != nil {
+			log.Printf("Error evaluating alerts for user %s: %v", userID, err)
+		}
+	}()
+}
+
+// evaluateAllAlerts is the daily scheduler task: it evaluates every user that
+// has an active alert rule. Dedupe keys make running it on several API
+// instances, or more than once a day, harmless.
+func evaluateAllAlerts(ctx context.Context) error {
+	userIDs, err := getUsersWithActiveAlertRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if _, err := EvaluateAlerts(ctx, userID, nil); err != nil {
+			log.Printf("Error evaluating alerts for user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// evaluate returns the notifications a rule raises right now
+func (e *alertEvaluation) evaluate(ctx context.Context, rule AlertRule) ([]newNotification, error) {
+	switch rule.RuleType {
+	case "category_spend":
+		return e.evaluateCategorySpend(ctx, rule)
+	case "entry_spend":
+		return e.evaluateEntrySpend(ctx, rule)
+	case "bill_due", "bill_overdue":
+		return e.evaluateBills(ctx, rule)
+	case "low_balance":
+		return e.evaluateLowBalance(ctx, rule)
+	case "large_transaction":
+		return e.evaluateLargeTransactions(ctx, rule)
+	}
+	return nil, fmt.Errorf("unknown rule type %q", rule.RuleType)
+}
+
+// evaluateCategorySpend compares this month's expenses in a category (and its
+// subcategories) with what the active budget's entries in it are due to cost
+func (e *alertEvaluation) evaluateCategorySpend(ctx context.Context, rule AlertRule) ([]newNotification, error) {
+	if err := e.loadEntries(ctx); err != nil {
+		return nil, err
+	}
+	if err := e.loadExpenses(ctx); err != nil {
+		return nil, err
+	}
+
+	budgeted := 0.0
+	for _, entry := range e.entries {
+		if !entry.IsActive || entry.EntryType != "expense" || entry.CategoryID == nil {
+			continue
+		}
+		if e.tree.isWithin(*entry.CategoryID, *rule.CategoryID) {
+			budgeted += entry.Amount * float64(countOccurrences(entry, e.monthStart, e.monthEnd))
+		}
+	}
+
+	actual := 0.0
+	for _, amount := range e.expenses {
+		if amount.CategoryID != nil && e.tree.isWithin(*amount.CategoryID, *rule.CategoryID) {
+			actual += amount.Amount
+		}
+	}
+
+	return e.spendNotifications(rule, e.tree.name(rule.CategoryID), "category:"+rule.CategoryID.String(), budgeted, actual), nil
+}
+
+// evaluateEntrySpend compares this month's transactions linked to an entry with
+// what the entry is due to cost this month
+func (e *alertEvaluation) evaluateEntrySpend(ctx context.Context, rule AlertRule) ([]newNotification, error) {
+	entry, err := GetBudgetEntryByID(ctx, *rule.BudgetEntryID, e.userID)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsActive || entry.EntryType != "expense" {
+		return nil, nil
+	}
+
+	budgeted := entry.Amount * float64(countOccurrences(*entry, e.monthStart, e.monthEnd))
+	actual, err := getActualForEntry(ctx, e.userID, entry.ID, e.monthStart, e.monthEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actual for entry %s: %w", entry.ID, err)
+	}
+
+	return e.spendNotifications(rule, entry.Name, "entry:"+entry.ID.String(), budgeted, actual), nil
+}
+
+// spendNotifications raises a warning once spending reaches the rule's
+// threshold and another once it reaches 100%, each at most once a month
+func (e *alertEvaluation) spendNotifications(rule AlertRule, name, scope string, budgeted, actual float64) []newNotification {
+	if budgeted <= 0 {
+		return nil
+	}
+
+	percent := actual / budgeted * 100
+	month := e.monthStart.Format("2006-01")
+	data := map[string]interface{}{
+		"name":     name,
+		"month":    month,
+		"budgeted": budgeted,
+		"actual":   actual,
+		"percent":  percent,
+	}
+	spent := fmt.Sprintf("You've spent %s of the %s budgeted for %s in %s.",
+		export.FormatCurrency(actual, e.currency), export.FormatCurrency(budgeted, e.currency), name, e.monthStart.Format("January 2006"))
+
+	var notifications []newNotification
+	if percent >= 100 {
+		notifications = append(notifications, newNotification{
+			AlertRuleID:      rule.ID,
+			NotificationType: "budget_exceeded",
+			Severity:         "critical",
+			Title:            fmt.Sprintf("%s is over budget", name),
+			Message:          spent,
+			Data:             data,
+			DedupeKey:        fmt.Sprintf("%s:%s:%s:100", rule.ID, scope, month),
+		})
+	}
+	// Once over budget the warning adds nothing, so it's only raised below 100%
+	if warning := *rule.Threshold; warning < 100 && percent >= warning && percent < 100 {
+		notifications = append(notifications, newNotification{
+			AlertRuleID:      rule.ID,
+			NotificationType: "budget_warning",
+			Severity:         "warning",
+			Title:            fmt.Sprintf("%s has reached %.0f%% of its budget", name, warning),
+			Message:          spent,
+			Data:             data,
+			DedupeKey:        fmt.Sprintf("%s:%s:%s:%.0f", rule.ID, scope, month, warning),
+		})
+	}
+	return notifications
+}
+
+// evaluateBills raises bill_due for unpaid expense occurrences due within the
+// rule's days ahead, and bill_overdue for unpaid ones from the past month. The
+// overdue scan crosses the month boundary so a bill due on the last day of a
+// month is still reported the next day; the dedupe key stops repeats.
+func (e *alertEvaluation) evaluateBills(ctx context.Context, rule AlertRule) ([]newNotification, error) {
+	if err := e.loadEntries(ctx); err != nil {
+		return nil, err
+	}
+	if err := e.loadLedger(ctx); err != nil {
+		return nil, err
+	}
+
+	from, end := e.today.AddDate(0, -1, 0), e.today.AddDate(0, 0, -1)
+	if rule.RuleType == "bill_due" {
+		from, end = e.monthStart, e.today.AddDate(0, 0, *rule.DaysAhead)
+	}
+
+	var notifications []newNotification
+	for _, entry := range e.entries {
+		if !entry.IsActive || entry.EntryType != "expense" {
+			continue
+		}
+		if rule.BudgetEntryID != nil && entry.ID != *rule.BudgetEntryID {
+			continue
+		}
+
+		for _, occurrence := range projectEntryOccurrences(entry, e.ledger, from, e.today, end) {
+			data := map[string]interface{}{
+				"entry_id":   entry.ID,
+				"entry_name": entry.Name,
+				"amount":     occurrence.Amount,
+				"due_date":   occurrence.DueDate,
+			}
+			amount := export.FormatCurrency(occurrence.Amount, e.currency)
+			dedupeKey := fmt.Sprintf("%s:%s:%s", rule.ID, entry.ID, occurrence.DueDate)
+
+			if rule.RuleType == "bill_overdue" && occurrence.Status == "overdue" {
+				notifications = append(notifications, newNotification{
+					AlertRuleID:      rule.ID,
+					NotificationType: "bill_overdue",
+					Severity:         "critical",
+					Title:            fmt.Sprintf("%s is overdue", entry.Name),
+					Message:          fmt.Sprintf("%s of %s was due on %s and no payment has been matched to it.", entry.Name, amount, occurrence.DueDate),
+					Data:             data,
+					DedupeKey:        dedupeKey,
+				})
+			}
+			if rule.RuleType == "bill_due" && occurrence.Status == "upcoming" {
+				notifications = append(notifications, newNotification{
+					AlertRuleID:      rule.ID,
+					NotificationType: "bill_due",
+					Severity:         "info",
+					Title:            fmt.Sprintf("%s is due on %s", entry.Name, occurrence.DueDate),
+					Message:          fmt.Sprintf("%s of %s is due on %s.", entry.Name, amount, occurrence.DueDate),
+					Data:             data,
+					DedupeKey:        dedupeKey,
+				})
+			}
+		}
+	}
+
+	return notifications, nil
+}
+
+// evaluateLowBalance projects the balance over the rule's horizon and raises a
+// notification for the first day it drops below the threshold, at most once a
+// month per rule. The breach date isn't part of the dedupe key because overdue
+// items roll into today's projection, moving it forward on every evaluation.
+func (e *alertEvaluation) evaluateLowBalance(ctx context.Context, rule AlertRule) ([]newNotification, error) {
+	if err := e.loadEntries(ctx); err != nil {
+		return nil, err
+	}
+
+	run, err := runCashFlowProjection(ctx, e.userID, e.entries, ProjectionOptions{
+		Days:      *rule.DaysAhead,
+		AccountID: rule.AccountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, day := range run.days {
+		if day.balance >= *rule.Threshold {
+			continue
+		}
+
+		return []newNotification{{
+			AlertRuleID:      rule.ID,
+			NotificationType: "low_balance",
+			Severity:         "warning",
+			Title:            fmt.Sprintf("Balance projected to drop below %s", export.FormatCurrency(*rule.Threshold, e.currency)),
+			Message: fmt.Sprintf("Your balance is projected to be %s on %s, and to reach a low of %s on %s.",
+				export.FormatCurrency(day.balance, e.currency), day.date,
+				export.FormatCurrency(run.lowestBalance, e.currency), run.lowestBalanceDate),
+			Data: map[string]interface{}{
+				"account_id":          rule.AccountID,
+				"threshold":           *rule.Threshold,
+				"date":                day.date,
+				"balance":             day.balance,
+				"lowest_balance":      run.lowestBalance,
+				"lowest_balance_date": run.lowestBalanceDate,
+			},
+			DedupeKey: fmt.Sprintf("%s:%s", rule.ID, e.monthStart.Format("2006-01")),
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+// evaluateLargeTransactions raises a notification for each transaction at or
+// above the rule's amount
+func (e *alertEvaluation) evaluateLargeTransactions(ctx context.Context, rule AlertRule) ([]newNotification, error) {
+	var transactions []Transaction
+	if e.transaction != nil {
+		transactions = []Transaction{*e.transaction}
+	} else {
+		since := e.today.AddDate(0, 0, -1).Format("2006-01-02")
+		recent, err := GetTransactionsByUserID(ctx, e.userID, rule.AccountID, nil, &since, nil)
+		if err != nil {
+			return nil, err
+		}
+		transactions = recent
+	}
+
+	var notifications []newNotification
+	for _, t := range transactions {
+		if t.Amount < *rule.Threshold || (rule.AccountID != nil && t.AccountID != *rule.AccountID) {
+			continue
+		}
+
+		description := "A transaction"
+		if t.Description != nil && *t.Description != "" {
+			description = *t.Description
+		}
+		notifications = append(notifications, newNotification{
+			AlertRuleID:      rule.ID,
+			NotificationType: "large_transaction",
+			Severity:         "info",
+			Title:            fmt.Sprintf("Large %s: %s", t.TransactionType, export.FormatCurrency(t.Amount, e.currency)),
+			Message: fmt.Sprintf("%s for %s on %s is above your %s alert.",
+				description, export.FormatCurrency(t.Amount, e.currency), t.TransactionDate, export.FormatCurrency(*rule.Threshold, e.currency)),
+			Data: map[string]interface{}{
+				"transaction_id":   t.ID,
+				"account_id":       t.AccountID,
+				"amount":           t.Amount,
+				"transaction_type": t.TransactionType,
+				"transaction_date": t.TransactionDate,
+			},
+			DedupeKey: fmt.Sprintf("%s:%s", rule.ID, t.ID),
+		})
+	}
+
+	return notifications, nil
+}
+
+// loadEntries loads the active budget's entries
+func (e *alertEvaluation) loadEntries(ctx context.Context) error {
+	if e.entries != nil {
+		return nil
+	}
+	entries, err := getActiveBudgetEntries(ctx, e.userID)
+	if err != nil {
+		return err
+	}
+	e.entries = entries
+	return nil
+}
+
+// loadLedger loads linked transactions so paid occurrences can be skipped
+func (e *alertEvaluation) loadLedger(ctx context.Context) error {
+	if e.ledger != nil {
+		return nil
+	}
+	ledger, err := loadOccurrenceLedger(ctx, e.userID)
+	if err != nil {
+		return err
+	}
+	e.ledger = ledger
+	return nil
+}
+
+// loadExpenses loads the category tree and this month's expenses per category
+func (e *alertEvaluation) loadExpenses(ctx context.Context) error {
+	if e.tree != nil {
+		return nil
+	}
+	tree, err := loadCategoryTree(ctx, e.userID)
+	if err != nil {
+		return fmt.Errorf("failed to load categories: %w", err)
+	}
+	expenses, err := getExpenseAmountsByCategory(ctx, e.userID, e.monthStart.Format("2006-01-02"), e.monthEnd.Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	e.tree = tree
+	e.expenses = expenses
+	return nil
+}
@@ -0,0 +1,304 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetAttachmentsHandler lists a transaction's attachments with download URLs
+func GetAttachmentsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	if attachmentStore == nil {
+		return attachmentsNotConfigured(c)
+	}
+
+	if _, err := GetTransactionByID(c.Context(), transactionID, userID); err != nil {
+		if err.Error() == "transaction not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve attachments",
+		})
+	}
+
+	attachments, err := GetAttachmentsByTransactionID(c.Context(), transactionID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve attachments",
+		})
+	}
+
+	if attachments == nil {
+		attachments = []Attachment{}
+	}
+
+	now := time.Now()
+	for i := range attachments {
+		if err := setAttachmentDownloadURL(&attachments[i], now); err != nil {
+			log.Printf("Error signing download URL for attachment %s: %v", attachments[i].ID, err)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"attachments": attachments,
+	})
+}
+
+// UploadAttachmentHandler attaches a file, sent as a multipart upload in the
+// "file" field, to a transaction. The file's type is detected from its
+// contents; only images and PDFs up to 10 MB are accepted.
+func UploadAttachmentHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	if attachmentStore == nil {
+		return attachmentsNotConfigured(c)
+	}
+
+	if _, err := GetTransactionByID(c.Context(), transactionID, userID); err != nil {
+		if err.Error() == "transaction not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload attachment",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A file is required (multipart field \"file\")",
+		})
+	}
+	if fileHeader.Size == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File is empty",
+		})
+	}
+	if fileHeader.Size > maxAttachmentSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "File is too large (maximum 10 MB)",
+		})
+	}
+
+	existing, err := GetAttachmentsByTransactionID(c.Context(), transactionID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload attachment",
+		})
+	}
+	if len(existing) >= maxAttachmentsPerTransaction {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Transactions can have at most 10 attachments",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	// The upload's own Content-Type is ignored in favour of sniffing the data
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	head = head[:n]
+
+	contentType, ok := detectAttachmentType(head)
+	if !ok {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Unsupported file type (allowed: JPEG, PNG, GIF, WebP and PDF)",
+		})
+	}
+
+	attachment := &Attachment{
+		ID:            uuid.New(),
+		TransactionID: transactionID,
+		UserID:        userID,
+		FileName:      sanitizeAttachmentFileName(fileHeader.Filename),
+		ContentType:   contentType,
+		SizeBytes:     fileHeader.Size,
+	}
+	attachment.StorageKey = attachmentStorageKey(userID, attachment.ID)
+
+	body := io.MultiReader(strings.NewReader(string(head)), file)
+	if err := attachmentStore.Put(c.Context(), attachment.StorageKey, body, attachment.SizeBytes, contentType); err != nil {
+		log.Printf("Error storing attachment for transaction %s: %v", transactionID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload attachment",
+		})
+	}
+
+	created, err := CreateAttachment(c.Context(), attachment)
+	if err != nil {
+		log.Printf("Error creating attachment for transaction %s: %v", transactionID, err)
+		removeStoredAttachments(c.Context(), []Attachment{*attachment})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload attachment",
+		})
+	}
+
+	if err := setAttachmentDownloadURL(created, time.Now()); err != nil {
+		log.Printf("Error signing download URL for attachment %s: %v", created.ID, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// DeleteAttachmentHandler removes an attachment and its stored file
+func DeleteAttachmentHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid attachment ID",
+		})
+	}
+
+	attachment, err := DeleteAttachment(c.Context(), attachmentID, transactionID, userID)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Attachment not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete attachment",
+		})
+	}
+
+	removeStoredAttachments(c.Context(), []Attachment{*attachment})
+
+	return c.JSON(fiber.Map{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// DownloadAttachmentHandler serves an attachment's file from a signed URL.
+// The signature identifies the attachment, so no X-User-ID is needed.
+func DownloadAttachmentHandler(c *fiber.Ctx) error {
+	attachmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid attachment ID",
+		})
+	}
+
+	if !verifyAttachmentDownload(attachmentID, c.Query("expires"), c.Query("signature"), time.Now()) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Download link is invalid or has expired",
+		})
+	}
+
+	if attachmentStore == nil {
+		return attachmentsNotConfigured(c)
+	}
+
+	attachment, err := getAttachmentForDownload(c.Context(), attachmentID)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Attachment not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve attachment",
+		})
+	}
+
+	file, err := attachmentStore.Get(c.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Attachment not found",
+			})
+		}
+		log.Printf("Error reading attachment %s: %v", attachment.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve attachment",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.SendStream(file, int(attachment.SizeBytes))
+}
+
+// attachmentsNotConfigured is the response when no storage has been set up
+func attachmentsNotConfigured(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Attachments are not configured",
+	})
+}
+
+// removeStoredAttachments deletes attachments' files after their records are
+// gone. Failures are logged rather than returned; the file is just orphaned.
+func removeStoredAttachments(ctx context.Context, attachments []Attachment) {
+	if attachmentStore == nil {
+		return
+	}
+	for _, attachment := range attachments {
+		if err := attachmentStore.Delete(ctx, attachment.StorageKey); err != nil {
+			log.Printf("Error deleting stored attachment %s: %v", attachment.StorageKey, err)
+		}
+	}
+}
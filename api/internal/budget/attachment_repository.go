@@ -0,0 +1,143 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const attachmentColumns = `id, transaction_id, user_id, file_name, content_type, size_bytes, storage_key, created_at`
+
+// scanAttachment scans a single attachment row
+func scanAttachment(row pgx.Row) (*Attachment, error) {
+	var attachment Attachment
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.TransactionID,
+		&attachment.UserID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetAttachmentsByTransactionID retrieves a transaction's attachments, oldest first
+func GetAttachmentsByTransactionID(ctx context.Context, transactionID uuid.UUID, userID uuid.UUID) ([]Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM budget.transaction_attachments
+		WHERE transaction_id = $1 AND user_id = $2
+		ORDER BY created_at ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, transactionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachmentByID retrieves one of a transaction's attachments
+func GetAttachmentByID(ctx context.Context, attachmentID uuid.UUID, transactionID uuid.UUID, userID uuid.UUID) (*Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM budget.transaction_attachments
+		WHERE id = $1 AND transaction_id = $2 AND user_id = $3
+	`
+
+	attachment, err := scanAttachment(database.DB.QueryRow(ctx, query, attachmentID, transactionID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// getAttachmentForDownload retrieves an attachment by ID alone; callers must
+// have checked a signed download URL first
+func getAttachmentForDownload(ctx context.Context, attachmentID uuid.UUID) (*Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM budget.transaction_attachments
+		WHERE id = $1
+	`
+
+	attachment, err := scanAttachment(database.DB.QueryRow(ctx, query, attachmentID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// CreateAttachment records an attachment whose file has already been stored
+func CreateAttachment(ctx context.Context, attachment *Attachment) (*Attachment, error) {
+	query := `
+		INSERT INTO budget.transaction_attachments (id, transaction_id, user_id, file_name, content_type, size_bytes, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + attachmentColumns
+
+	created, err := scanAttachment(database.DB.QueryRow(ctx, query,
+		attachment.ID,
+		attachment.TransactionID,
+		attachment.UserID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.StorageKey,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return created, nil
+}
+
+// DeleteAttachment removes an attachment's record and returns it, so the
+// caller can remove the stored file
+func DeleteAttachment(ctx context.Context, attachmentID uuid.UUID, transactionID uuid.UUID, userID uuid.UUID) (*Attachment, error) {
+	query := `
+		DELETE FROM budget.transaction_attachments
+		WHERE id = $1 AND transaction_id = $2 AND user_id = $3
+		RETURNING ` + attachmentColumns
+
+	attachment, err := scanAttachment(database.DB.QueryRow(ctx, query, attachmentID, transactionID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	return attachment, nil
+}
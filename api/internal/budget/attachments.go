@@ -0,0 +1,134 @@
+package budget
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/storage"
+	"github.com/google/uuid"
+)
+
+// maxAttachmentSize caps each uploaded file
+const maxAttachmentSize = 10 << 20
+
+// maxAttachmentsPerTransaction caps how many files one transaction can have
+const maxAttachmentsPerTransaction = 10
+
+// attachmentURLExpiry is how long a download URL stays valid
+const attachmentURLExpiry = 15 * time.Minute
+
+// allowedAttachmentTypes are the content types accepted for upload, as
+// detected from the file's first bytes
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// detectAttachmentType sniffs a file's content type from its first 512
+// bytes, reporting whether it's one that can be uploaded
+func detectAttachmentType(head []byte) (string, bool) {
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return contentType, allowedAttachmentTypes[contentType]
+}
+
+// attachmentStore holds uploaded files; nil until ConfigureAttachments is called
+var attachmentStore storage.Storage
+
+// attachmentSigningKey signs download URLs served by the API
+var attachmentSigningKey []byte
+
+// ConfigureAttachments sets where attachments are stored and the key used to
+// sign download URLs when the store can't sign its own
+func ConfigureAttachments(store storage.Storage, signingKey []byte) {
+	attachmentStore = store
+	attachmentSigningKey = signingKey
+}
+
+// Attachment is a file such as a receipt attached to a transaction
+type Attachment struct {
+	ID                   uuid.UUID  `json:"id"`
+	TransactionID        uuid.UUID  `json:"transaction_id"`
+	UserID               uuid.UUID  `json:"user_id"`
+	FileName             string     `json:"file_name"`
+	ContentType          string     `json:"content_type"`
+	SizeBytes            int64      `json:"size_bytes"`
+	StorageKey           string     `json:"-"`
+	DownloadURL          string     `json:"download_url,omitempty"` // Signed; relative to the app when served by the API
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// attachmentStorageKey is where an attachment's file is kept
+func attachmentStorageKey(userID, attachmentID uuid.UUID) string {
+	return "attachments/" + userID.String() + "/" + attachmentID.String()
+}
+
+// sanitizeAttachmentFileName keeps the base name of an uploaded file without
+// control characters or quotes, so it's safe in a Content-Disposition header
+func sanitizeAttachmentFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:]) // Keep the extension
+	}
+	return name
+}
+
+// signAttachmentDownload signs an attachment ID and expiry time for the
+// API's download route
+func signAttachmentDownload(attachmentID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, attachmentSigningKey)
+	fmt.Fprintf(mac, "%s.%d", attachmentID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyAttachmentDownload checks a download URL's signature and that it hasn't expired
+func verifyAttachmentDownload(attachmentID uuid.UUID, expiresParam, signature string, now time.Time) bool {
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil || now.Unix() > expires || len(attachmentSigningKey) == 0 {
+		return false
+	}
+	expected := signAttachmentDownload(attachmentID, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// setAttachmentDownloadURL fills in a time-limited download URL, presigned by
+// the store when it can, otherwise pointing at the API's download route
+func setAttachmentDownloadURL(attachment *Attachment, now time.Time) error {
+	expiresAt := now.Add(attachmentURLExpiry)
+
+	if signer, ok := attachmentStore.(storage.URLSigner); ok {
+		url, err := signer.SignedURL(attachment.StorageKey, attachment.FileName, attachment.ContentType, attachmentURLExpiry)
+		if err != nil {
+			return err
+		}
+		attachment.DownloadURL = url
+	} else {
+		expires := expiresAt.Unix()
+		attachment.DownloadURL = fmt.Sprintf("/api/attachments/%s/download?expires=%d&signature=%s",
+			attachment.ID, expires, signAttachmentDownload(attachment.ID, expires))
+	}
+
+	attachment.DownloadURLExpiresAt = &expiresAt
+	return nil
+}
@@ -1,8 +1,8 @@
 package budget
 
 import (
+	"fmt"
 	"log"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -261,20 +261,33 @@ func CreateBudgetEntryHandler(c *fiber.Ctx) error {
 	}
 
 	// Validate required fields
-	if req.Name == "" {
+	if err := validateBudgetEntryRequest(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Entry name is required",
+			"error": err.Error(),
 		})
 	}
-	if req.Amount <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Amount must be greater than zero",
+
+	entry, err := CreateBudgetEntry(c.Context(), budgetID, userID, req)
+	if err != nil {
+		log.Printf("Error creating budget entry for budget %s: %v", budgetID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create budget entry",
 		})
 	}
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// validateBudgetEntryRequest checks the fields required to create a budget entry
+func validateBudgetEntryRequest(req CreateBudgetEntryRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("Entry name is required")
+	}
+	if req.Amount <= 0 {
+		return fmt.Errorf("Amount must be greater than zero")
+	}
 	if req.EntryType != "income" && req.EntryType != "expense" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Entry type must be 'income' or 'expense'",
-		})
+		return fmt.Errorf("Entry type must be 'income' or 'expense'")
 	}
 
 	validFrequencies := map[string]bool{
@@ -286,20 +299,10 @@ func CreateBudgetEntryHandler(c *fiber.Ctx) error {
 		"annually":    true,
 	}
 	if !validFrequencies[req.Frequency] {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid frequency",
-		})
-	}
-
-	entry, err := CreateBudgetEntry(c.Context(), budgetID, userID, req)
-	if err != nil {
-		log.Printf("Error creating budget entry for budget %s: %v", budgetID, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create budget entry",
-		})
+		return fmt.Errorf("Invalid frequency")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(entry)
+	return nil
 }
 
 // UpdateBudgetEntryHandler updates an existing budget entry
@@ -438,25 +441,42 @@ func ProjectCashFlowHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get query parameters
-	startingBalance, _ := strconv.ParseFloat(c.Query("starting_balance", "0"), 64)
-	days, _ := strconv.Atoi(c.Query("days", "90"))
-
-	// Cap days at 365
-	if days > 365 {
-		days = 365
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
 	}
-	if days < 1 {
-		days = 30
+
+	// Starts from the user's account balances unless starting_balance is given
+	opts, err := parseProjectionOptions(c.Query("days"), c.Query("account_id"), c.Query("starting_balance"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	projection, err := ProjectCashFlow(c.Context(), budgetID, userID, startingBalance, days)
+	projection, err := ProjectCashFlow(c.Context(), budgetID, userID, opts)
 	if err != nil {
+		if err.Error() == "account not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Account not found",
+			})
+		}
 		log.Printf("Error projecting cash flow for budget %s: %v", budgetID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to project cash flow",
 		})
 	}
 
+	if format != "" {
+		budget, err := GetBudgetByID(c.Context(), budgetID, userID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Budget not found",
+			})
+		}
+		currency := userCurrency(c.Context(), userID, opts.AccountID)
+		return sendExport(c, userID, format, "budget-cash-flow", budgetCashFlowExport(budget, projection, currency))
+	}
+
 	return c.JSON(projection)
 }
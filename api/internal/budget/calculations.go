@@ -15,6 +15,11 @@ func CalculateBudgetSummary(ctx context.Context, budgetID uuid.UUID, userID uuid
 		return nil, err
 	}
 
+	return summarizeBudgetEntries(budgetID, entries), nil
+}
+
+// summarizeBudgetEntries totals a set of entries as monthly and annual amounts
+func summarizeBudgetEntries(budgetID uuid.UUID, entries []BudgetEntry) *BudgetSummary {
 	summary := &BudgetSummary{
 		BudgetID: budgetID,
 	}
@@ -37,7 +42,7 @@ func CalculateBudgetSummary(ctx context.Context, budgetID uuid.UUID, userID uuid
 	summary.MonthlySurplusDeficit = summary.TotalMonthlyIncome - summary.TotalMonthlyExpenses
 	summary.AnnualSurplusDeficit = summary.TotalAnnualIncome - summary.TotalAnnualExpenses
 
-	return summary, nil
+	return summary
 }
 
 // calculateMonthlyAmount converts any frequency to a monthly equivalent
@@ -65,21 +70,27 @@ type CashFlowProjection struct {
 	StartDate         string                  `json:"start_date"`
 	EndDate           string                  `json:"end_date"`
 	StartingBalance   float64                 `json:"starting_balance"`
+	BalanceSource     string                  `json:"balance_source"` // 'accounts', 'account' or 'manual'
+	AccountID         *uuid.UUID              `json:"account_id,omitempty"`
 	EndingBalance     float64                 `json:"ending_balance"`
+	LowestBalance     float64                 `json:"lowest_balance"`
+	LowestBalanceDate string                  `json:"lowest_balance_date"`
 	TotalIncome       float64                 `json:"total_income"`
 	TotalExpenses     float64                 `json:"total_expenses"`
 	NetCashFlow       float64                 `json:"net_cash_flow"`
+	Overdue           []ProjectedOccurrence   `json:"overdue"` // Unpaid occurrences from earlier this month, applied on the first day
 	DailyProjections  []DailyProjection       `json:"daily_projections"`
 	MonthlyBreakdown  []MonthlyBreakdown      `json:"monthly_breakdown"`
 }
 
 // DailyProjection represents projected balance for a specific day
 type DailyProjection struct {
-	Date            string  `json:"date"`
-	Balance         float64 `json:"balance"`
-	DailyIncome     float64 `json:"daily_income"`
-	DailyExpenses   float64 `json:"daily_expenses"`
-	DailyNet        float64 `json:"daily_net"`
+	Date            string                `json:"date"`
+	Balance         float64               `json:"balance"`
+	DailyIncome     float64               `json:"daily_income"`
+	DailyExpenses   float64               `json:"daily_expenses"`
+	DailyNet        float64               `json:"daily_net"`
+	Occurrences     []ProjectedOccurrence `json:"occurrences,omitempty"`
 }
 
 // MonthlyBreakdown represents monthly summary
@@ -91,84 +102,72 @@ type MonthlyBreakdown struct {
 	EndingBalance float64 `json:"ending_balance"`
 }
 
-// ProjectCashFlow projects future cash flow based on budget entries
-func ProjectCashFlow(ctx context.Context, budgetID uuid.UUID, userID uuid.UUID, startingBalance float64, days int) (*CashFlowProjection, error) {
+// ProjectCashFlow projects future cash flow for a budget, starting from the
+// user's actual account balances (see runCashFlowProjection)
+func ProjectCashFlow(ctx context.Context, budgetID uuid.UUID, userID uuid.UUID, opts ProjectionOptions) (*CashFlowProjection, error) {
 	// Get all budget entries
 	entries, err := GetBudgetEntriesByBudgetID(ctx, budgetID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
-	startDate := now.Format("2006-01-02")
-	endDate := now.AddDate(0, 0, days).Format("2006-01-02")
+	return projectBudgetEntries(ctx, userID, entries, opts)
+}
 
-	projection := &CashFlowProjection{
-		StartDate:       startDate,
-		EndDate:         endDate,
-		StartingBalance: startingBalance,
-		DailyProjections: make([]DailyProjection, 0),
-		MonthlyBreakdown: make([]MonthlyBreakdown, 0),
+// projectBudgetEntries builds a CashFlowProjection from a set of entries, which
+// needn't be saved (see EvaluateScenario)
+func projectBudgetEntries(ctx context.Context, userID uuid.UUID, entries []BudgetEntry, opts ProjectionOptions) (*CashFlowProjection, error) {
+	run, err := runCashFlowProjection(ctx, userID, entries, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	currentBalance := startingBalance
-	monthlyTotals := make(map[string]*MonthlyBreakdown)
-
-	// Iterate through each day in the projection period
-	for i := 0; i < days; i++ {
-		currentDate := now.AddDate(0, 0, i)
-		dateStr := currentDate.Format("2006-01-02")
-		monthStr := currentDate.Format("2006-01")
-
-		dailyIncome := 0.0
-		dailyExpenses := 0.0
-
-		// Check each budget entry to see if it occurs on this date
-		for _, entry := range entries {
-			if shouldEntryOccurOnDate(entry, currentDate) {
-				if entry.EntryType == "income" {
-					dailyIncome += entry.Amount
-				} else {
-					dailyExpenses += entry.Amount
-				}
-			}
-		}
+	projection := &CashFlowProjection{
+		StartingBalance:   run.startingBalance,
+		BalanceSource:     run.balanceSource,
+		AccountID:         run.accountID,
+		EndingBalance:     run.startingBalance,
+		LowestBalance:     run.lowestBalance,
+		LowestBalanceDate: run.lowestBalanceDate,
+		Overdue:           run.overdue,
+		DailyProjections:  make([]DailyProjection, 0, len(run.days)),
+		MonthlyBreakdown:  make([]MonthlyBreakdown, 0),
+	}
 
-		dailyNet := dailyIncome - dailyExpenses
-		currentBalance += dailyNet
+	for _, day := range run.days {
+		dailyNet := day.income - day.expenses
 
-		// Add to daily projections
 		projection.DailyProjections = append(projection.DailyProjections, DailyProjection{
-			Date:          dateStr,
-			Balance:       currentBalance,
-			DailyIncome:   dailyIncome,
-			DailyExpenses: dailyExpenses,
+			Date:          day.date,
+			Balance:       day.balance,
+			DailyIncome:   day.income,
+			DailyExpenses: day.expenses,
 			DailyNet:      dailyNet,
+			Occurrences:   day.occurrences,
 		})
 
-		// Update monthly totals
-		if monthlyTotals[monthStr] == nil {
-			monthlyTotals[monthStr] = &MonthlyBreakdown{
-				Month: monthStr,
-			}
+		// Days are in order, so months are appended in order too
+		monthStr := day.date[:7]
+		last := len(projection.MonthlyBreakdown) - 1
+		if last < 0 || projection.MonthlyBreakdown[last].Month != monthStr {
+			projection.MonthlyBreakdown = append(projection.MonthlyBreakdown, MonthlyBreakdown{Month: monthStr})
+			last++
 		}
-		monthlyTotals[monthStr].Income += dailyIncome
-		monthlyTotals[monthStr].Expenses += dailyExpenses
-		monthlyTotals[monthStr].Net += dailyNet
-		monthlyTotals[monthStr].EndingBalance = currentBalance
-
-		// Update projection totals
-		projection.TotalIncome += dailyIncome
-		projection.TotalExpenses += dailyExpenses
+		projection.MonthlyBreakdown[last].Income += day.income
+		projection.MonthlyBreakdown[last].Expenses += day.expenses
+		projection.MonthlyBreakdown[last].Net += dailyNet
+		projection.MonthlyBreakdown[last].EndingBalance = day.balance
+
+		projection.TotalIncome += day.income
+		projection.TotalExpenses += day.expenses
+		projection.EndingBalance = day.balance
 	}
 
-	projection.EndingBalance = currentBalance
-	projection.NetCashFlow = projection.TotalIncome - projection.TotalExpenses
-
-	// Convert monthly totals to sorted array
-	for _, breakdown := range monthlyTotals {
-		projection.MonthlyBreakdown = append(projection.MonthlyBreakdown, *breakdown)
+	if len(run.days) > 0 {
+		projection.StartDate = run.days[0].date
+		projection.EndDate = run.days[len(run.days)-1].date
 	}
+	projection.NetCashFlow = projection.TotalIncome - projection.TotalExpenses
 
 	return projection, nil
 }
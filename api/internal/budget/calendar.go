@@ -0,0 +1,201 @@
+package budget
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultCalendarMonthsAhead and maxCalendarMonthsAhead bound how far ahead a
+// calendar feed expands budget entries
+const (
+	defaultCalendarMonthsAhead = 3
+	maxCalendarMonthsAhead     = 12
+)
+
+// CalendarFeed is a user's iCalendar subscription. The token is the only
+// credential calendar apps send, so it's shown to the user and can be rotated.
+type CalendarFeed struct {
+	UserID         uuid.UUID  `json:"user_id"`
+	Token          string     `json:"token"`
+	Path           string     `json:"path"` // Feed path relative to the site, e.g. /api/calendar/<token>.ics
+	MonthsAhead    int        `json:"months_ahead"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// UpdateCalendarFeedRequest represents the request body for changing a calendar feed
+type UpdateCalendarFeedRequest struct {
+	MonthsAhead *int `json:"months_ahead,omitempty" validate:"omitempty,min=1,max=12"`
+}
+
+// calendarEvent is one budget entry occurrence in a feed
+type calendarEvent struct {
+	entry      BudgetEntry
+	occurrence ProjectedOccurrence
+	category   string
+}
+
+// BuildCalendarFeed renders the active budget's entries as an iCalendar
+// document. Occurrences run from the start of the current month, so this
+// month's paid and overdue items stay visible, to monthsAhead months from today.
+func BuildCalendarFeed(ctx context.Context, userID uuid.UUID, monthsAhead int, now time.Time) (string, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := today.AddDate(0, monthsAhead, 0)
+
+	calendarName := "Help Me Budget"
+	events := []calendarEvent{}
+
+	activeBudget, err := GetActiveBudget(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if activeBudget != nil {
+		calendarName += " - " + activeBudget.Name
+
+		entries, err := GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get budget entries: %w", err)
+		}
+		ledger, err := loadOccurrenceLedger(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		categories, err := GetCategoriesByUserID(ctx, userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get categories: %w", err)
+		}
+		categoryNames := make(map[uuid.UUID]string)
+		for _, category := range categories {
+			categoryNames[category.ID] = category.Name
+		}
+
+		for _, entry := range entries {
+			if !entry.IsActive {
+				continue
+			}
+			category := "Uncategorized"
+			if entry.CategoryID != nil && categoryNames[*entry.CategoryID] != "" {
+				category = categoryNames[*entry.CategoryID]
+			}
+			for _, occurrence := range scheduleEntryOccurrences(entry, ledger, from, today, end) {
+				events = append(events, calendarEvent{entry: entry, occurrence: occurrence, category: category})
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].occurrence.DueDate < events[j].occurrence.DueDate
+	})
+
+	return renderCalendar(calendarName, events, now), nil
+}
+
+// renderCalendar writes an iCalendar (RFC 5545) document with one all-day
+// VEVENT per occurrence. UIDs are stable per entry and date so calendar apps
+// update events in place when their status changes.
+func renderCalendar(name string, events []calendarEvent, now time.Time) string {
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Help Me Budget//Budget Calendar//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICalText(name))
+	writeLine("X-PUBLISHED-TTL:PT6H")
+	writeLine("REFRESH-INTERVAL;VALUE=DURATION:PT6H")
+
+	for _, event := range events {
+		date, err := time.Parse("2006-01-02", event.occurrence.DueDate)
+		if err != nil {
+			continue
+		}
+
+		kind := "Bill"
+		if event.entry.EntryType == "income" {
+			kind = "Payday"
+		}
+		summary := fmt.Sprintf("%s: %s (%.2f)", kind, event.entry.Name, event.entry.Amount)
+		if event.occurrence.Status != "upcoming" {
+			summary += " - " + event.occurrence.Status
+		}
+
+		description := fmt.Sprintf("Amount: %.2f\nCategory: %s\nStatus: %s", event.entry.Amount, event.category, event.occurrence.Status)
+		if event.entry.Description != nil && *event.entry.Description != "" {
+			description += "\n\n" + *event.entry.Description
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:%s-%s@help-me-budget", event.entry.ID, date.Format("20060102")))
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART;VALUE=DATE:" + date.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + date.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeICalText(summary))
+		writeLine("DESCRIPTION:" + escapeICalText(description))
+		writeLine("CATEGORIES:" + escapeICalText(event.category))
+		writeLine("TRANSP:TRANSPARENT")
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICalText escapes a TEXT property value
+func escapeICalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+}
+
+// foldICalLine splits a content line into 75-octet lines, continuing each
+// with a leading space, without breaking UTF-8 characters
+func foldICalLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+// generateCalendarToken creates a random feed token
+func generateCalendarToken() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// validateCalendarMonthsAhead requires 1 to 12 months
+func validateCalendarMonthsAhead(months int) error {
+	if months < 1 || months > maxCalendarMonthsAhead {
+		return fmt.Errorf("Months ahead must be between 1 and %d", maxCalendarMonthsAhead)
+	}
+	return nil
+}
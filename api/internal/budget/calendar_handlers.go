@@ -0,0 +1,159 @@
+package budget
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetCalendarFeedHandler returns the user's calendar feed, or enabled: false
+// when it hasn't been turned on
+func GetCalendarFeedHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	feed, err := GetCalendarFeed(c.Context(), userID)
+	if err != nil {
+		if err.Error() == "calendar feed not found" {
+			return c.JSON(fiber.Map{
+				"enabled": false,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve calendar feed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled": true,
+		"feed":    feed,
+	})
+}
+
+// CreateCalendarFeedHandler turns on the user's calendar feed, or replaces its
+// token if it's already on so previously shared URLs stop working
+func CreateCalendarFeedHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	feed, err := CreateCalendarFeed(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error creating calendar feed for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create calendar feed",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(feed)
+}
+
+// UpdateCalendarFeedHandler changes how many months ahead the feed covers
+func UpdateCalendarFeedHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req UpdateCalendarFeedRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.MonthsAhead != nil {
+		if err := validateCalendarMonthsAhead(*req.MonthsAhead); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	feed, err := UpdateCalendarFeed(c.Context(), userID, req)
+	if err != nil {
+		if err.Error() == "calendar feed not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Calendar feed not found",
+			})
+		}
+		log.Printf("Error updating calendar feed for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update calendar feed",
+		})
+	}
+
+	return c.JSON(feed)
+}
+
+// DeleteCalendarFeedHandler turns off the user's calendar feed
+func DeleteCalendarFeedHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if err := DeleteCalendarFeed(c.Context(), userID); err != nil {
+		if err.Error() == "calendar feed not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Calendar feed not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete calendar feed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Calendar feed deleted successfully",
+	})
+}
+
+// GetCalendarICSHandler serves a calendar feed to calendar apps. The token in
+// the path identifies the user, so no X-User-ID is needed.
+func GetCalendarICSHandler(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if len(token) != 64 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar feed not found",
+		})
+	}
+
+	feed, err := getCalendarFeedByToken(c.Context(), token)
+	if err != nil {
+		if err.Error() == "calendar feed not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Calendar feed not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve calendar feed",
+		})
+	}
+
+	calendar, err := BuildCalendarFeed(c.Context(), feed.UserID, feed.MonthsAhead, time.Now())
+	if err != nil {
+		log.Printf("Error building calendar feed for user %s: %v", feed.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build calendar feed",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="help-me-budget.ics"`)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=900")
+	return c.SendString(calendar)
+}
@@ -0,0 +1,125 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const calendarFeedColumns = `user_id, token, months_ahead, last_accessed_at, created_at, updated_at`
+
+// scanCalendarFeed scans a single calendar feed row
+func scanCalendarFeed(row pgx.Row) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	err := row.Scan(
+		&feed.UserID,
+		&feed.Token,
+		&feed.MonthsAhead,
+		&feed.LastAccessedAt,
+		&feed.CreatedAt,
+		&feed.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	feed.Path = "/api/calendar/" + feed.Token + ".ics"
+	return &feed, nil
+}
+
+// GetCalendarFeed retrieves a user's calendar feed
+func GetCalendarFeed(ctx context.Context, userID uuid.UUID) (*CalendarFeed, error) {
+	query := `
+		SELECT ` + calendarFeedColumns + `
+		FROM budget.calendar_feeds
+		WHERE user_id = $1
+	`
+
+	feed, err := scanCalendarFeed(database.DB.QueryRow(ctx, query, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("calendar feed not found")
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// getCalendarFeedByToken retrieves the feed a token belongs to and records the access
+func getCalendarFeedByToken(ctx context.Context, token string) (*CalendarFeed, error) {
+	query := `
+		UPDATE budget.calendar_feeds
+		SET last_accessed_at = CURRENT_TIMESTAMP
+		WHERE token = $1
+		RETURNING ` + calendarFeedColumns
+
+	feed, err := scanCalendarFeed(database.DB.QueryRow(ctx, query, token))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("calendar feed not found")
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// CreateCalendarFeed enables a user's calendar feed with a new token. If the
+// feed already exists its token is replaced, which stops the old URL working.
+func CreateCalendarFeed(ctx context.Context, userID uuid.UUID) (*CalendarFeed, error) {
+	token, err := generateCalendarToken()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO budget.calendar_feeds (user_id, token, months_ahead)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, last_accessed_at = NULL
+		RETURNING ` + calendarFeedColumns
+
+	feed, err := scanCalendarFeed(database.DB.QueryRow(ctx, query, userID, token, defaultCalendarMonthsAhead))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// UpdateCalendarFeed applies changes to a user's calendar feed
+func UpdateCalendarFeed(ctx context.Context, userID uuid.UUID, req UpdateCalendarFeedRequest) (*CalendarFeed, error) {
+	query := `
+		UPDATE budget.calendar_feeds
+		SET months_ahead = COALESCE($2, months_ahead)
+		WHERE user_id = $1
+		RETURNING ` + calendarFeedColumns
+
+	feed, err := scanCalendarFeed(database.DB.QueryRow(ctx, query, userID, req.MonthsAhead))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("calendar feed not found")
+		}
+		return nil, fmt.Errorf("failed to update calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+// DeleteCalendarFeed disables a user's calendar feed
+func DeleteCalendarFeed(ctx context.Context, userID uuid.UUID) error {
+	result, err := database.DB.Exec(ctx, `
+		DELETE FROM budget.calendar_feeds WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("calendar feed not found")
+	}
+
+	return nil
+}
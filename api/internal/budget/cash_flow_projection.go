@@ -0,0 +1,268 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProjectionOptions controls where a cash flow projection starts from
+type ProjectionOptions struct {
+	Days            int
+	AccountID       *uuid.UUID // Project a single account instead of all active accounts
+	StartingBalance *float64   // Overrides the account balance when set
+}
+
+// ProjectedOccurrence is a single budget entry occurrence included in a projection
+type ProjectedOccurrence struct {
+	EntryID   uuid.UUID `json:"entry_id"`
+	EntryName string    `json:"entry_name"`
+	EntryType string    `json:"entry_type"` // 'income' or 'expense'
+	Amount    float64   `json:"amount"`
+	DueDate   string    `json:"due_date"` // YYYY-MM-DD
+	Status    string    `json:"status"`   // 'overdue' or 'upcoming' ('paid' only from scheduleEntryOccurrences)
+}
+
+// cashFlowDay is one day of a projection run
+type cashFlowDay struct {
+	date        string
+	income      float64
+	expenses    float64
+	balance     float64
+	occurrences []ProjectedOccurrence
+}
+
+// cashFlowRun is the day-by-day projection shared by ProjectCashFlow,
+// GetCashFlowProjection and ForecastCashFlow
+type cashFlowRun struct {
+	startingBalance   float64
+	balanceSource     string // 'accounts', 'account' or 'manual'
+	accountID         *uuid.UUID
+	overdue           []ProjectedOccurrence
+	days              []cashFlowDay
+	lowestBalance     float64
+	lowestBalanceDate string
+	linked            []Transaction // The user's linked transactions, for callers that need payment history
+}
+
+// runCashFlowProjection projects a user's balance forward from today. It starts
+// from the real account balance (or opts.StartingBalance when given), skips
+// occurrences already covered by linked transactions, and applies occurrences
+// from earlier this month that are still unpaid on the first day.
+func runCashFlowProjection(ctx context.Context, userID uuid.UUID, entries []BudgetEntry, opts ProjectionOptions) (*cashFlowRun, error) {
+	run := &cashFlowRun{
+		accountID: opts.AccountID,
+		overdue:   []ProjectedOccurrence{},
+		days:      []cashFlowDay{},
+	}
+
+	switch {
+	case opts.StartingBalance != nil:
+		run.startingBalance = *opts.StartingBalance
+		run.balanceSource = "manual"
+	case opts.AccountID != nil:
+		account, err := GetAccountByID(ctx, *opts.AccountID, userID)
+		if err != nil {
+			return nil, err
+		}
+		run.startingBalance = account.Balance
+		run.balanceSource = "account"
+	default:
+		total, err := GetTotalBalance(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		run.startingBalance = total
+		run.balanceSource = "accounts"
+	}
+
+	linked, err := GetLinkedTransactions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load linked transactions: %w", err)
+	}
+	run.linked = linked
+	ledger := newOccurrenceLedger(linked)
+	entryAccounts := inferEntryAccounts(linked)
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := today.AddDate(0, 0, opts.Days-1)
+
+	scheduled := make(map[string][]ProjectedOccurrence)
+	for _, entry := range entries {
+		if !entry.IsActive {
+			continue
+		}
+		// Entries usually paid from another account don't affect this one.
+		// Entries with no payment history yet are kept.
+		if opts.AccountID != nil {
+			if accountID, ok := entryAccounts[entry.ID]; ok && accountID != *opts.AccountID {
+				continue
+			}
+		}
+
+		for _, occurrence := range projectEntryOccurrences(entry, ledger, monthStart, today, end) {
+			if occurrence.Status == "overdue" {
+				run.overdue = append(run.overdue, occurrence)
+				continue
+			}
+			scheduled[occurrence.DueDate] = append(scheduled[occurrence.DueDate], occurrence)
+		}
+	}
+
+	balance := run.startingBalance
+	for i := 0; i < opts.Days; i++ {
+		date := today.AddDate(0, 0, i).Format("2006-01-02")
+		day := cashFlowDay{
+			date:        date,
+			occurrences: scheduled[date],
+		}
+		// Anything still unpaid from earlier this month is assumed to go out today
+		if i == 0 {
+			day.occurrences = append(append([]ProjectedOccurrence{}, run.overdue...), day.occurrences...)
+		}
+
+		for _, occurrence := range day.occurrences {
+			if occurrence.EntryType == "income" {
+				day.income += occurrence.Amount
+			} else {
+				day.expenses += occurrence.Amount
+			}
+		}
+
+		balance += day.income - day.expenses
+		day.balance = balance
+
+		if i == 0 || balance < run.lowestBalance {
+			run.lowestBalance = balance
+			run.lowestBalanceDate = date
+		}
+
+		run.days = append(run.days, day)
+	}
+
+	return run, nil
+}
+
+// projectEntryOccurrences lists an entry's unpaid occurrences between from and
+// end, as scheduleEntryOccurrences marks them
+func projectEntryOccurrences(entry BudgetEntry, ledger *occurrenceLedger, from, today, end time.Time) []ProjectedOccurrence {
+	unpaid := []ProjectedOccurrence{}
+	for _, occurrence := range scheduleEntryOccurrences(entry, ledger, from, today, end) {
+		if occurrence.Status != "paid" {
+			unpaid = append(unpaid, occurrence)
+		}
+	}
+	return unpaid
+}
+
+// scheduleEntryOccurrences lists all of an entry's occurrences between from
+// and end. An occurrence is paid when its schedule window (see
+// occurrenceWindow) already holds enough linked transactions; occurrences
+// before today that aren't paid are marked overdue.
+func scheduleEntryOccurrences(entry BudgetEntry, ledger *occurrenceLedger, from, today, end time.Time) []ProjectedOccurrence {
+	occurrences := []ProjectedOccurrence{}
+	seen := make(map[time.Time]int) // window start -> occurrences scheduled so far
+
+	for date := from; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if !entryOccursOnDate(entry, date) {
+			continue
+		}
+
+		windowStart, windowEnd := occurrenceWindow(entry, date)
+		count, ok := seen[windowStart]
+		if !ok && entry.Frequency != "once_off" && windowStart.Before(from) {
+			// Occurrences earlier in the window claim linked transactions first
+			count = countOccurrences(entry, windowStart, from.AddDate(0, 0, -1))
+		}
+		count++
+		seen[windowStart] = count
+
+		status := "upcoming"
+		switch {
+		case count <= ledger.fulfilled(entry.ID, windowStart, windowEnd):
+			status = "paid"
+		case date.Before(today):
+			status = "overdue"
+		}
+
+		occurrences = append(occurrences, ProjectedOccurrence{
+			EntryID:   entry.ID,
+			EntryName: entry.Name,
+			EntryType: entry.EntryType,
+			Amount:    entry.Amount,
+			DueDate:   date.Format("2006-01-02"),
+			Status:    status,
+		})
+	}
+
+	return occurrences
+}
+
+// inferEntryAccounts maps each budget entry to the account most of its linked
+// transactions were paid from
+func inferEntryAccounts(linked []Transaction) map[uuid.UUID]uuid.UUID {
+	counts := make(map[uuid.UUID]map[uuid.UUID]int)
+	for _, t := range linked {
+		if t.BudgetEntryID == nil {
+			continue
+		}
+		if counts[*t.BudgetEntryID] == nil {
+			counts[*t.BudgetEntryID] = make(map[uuid.UUID]int)
+		}
+		counts[*t.BudgetEntryID][t.AccountID]++
+	}
+
+	accounts := make(map[uuid.UUID]uuid.UUID)
+	for entryID, byAccount := range counts {
+		best := 0
+		for accountID, count := range byAccount {
+			if count > best || (count == best && accountID.String() < accounts[entryID].String()) {
+				best = count
+				accounts[entryID] = accountID
+			}
+		}
+	}
+
+	return accounts
+}
+
+// parseProjectionOptions reads ?days, ?account_id and ?starting_balance.
+// Days defaults to 90 and is capped at 365.
+func parseProjectionOptions(daysStr, accountIDStr, startingBalanceStr string) (ProjectionOptions, error) {
+	opts := ProjectionOptions{Days: 90}
+
+	if daysStr != "" {
+		var days int
+		if _, err := fmt.Sscanf(daysStr, "%d", &days); err != nil {
+			return opts, fmt.Errorf("invalid days")
+		}
+		if days >= 1 {
+			opts.Days = days
+		}
+	}
+	if opts.Days > 365 {
+		opts.Days = 365
+	}
+
+	if accountIDStr != "" {
+		accountID, err := uuid.Parse(accountIDStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid account_id")
+		}
+		opts.AccountID = &accountID
+	}
+
+	if startingBalanceStr != "" {
+		var startingBalance float64
+		if _, err := fmt.Sscanf(startingBalanceStr, "%f", &startingBalance); err != nil {
+			return opts, fmt.Errorf("invalid starting_balance")
+		}
+		opts.StartingBalance = &startingBalance
+	}
+
+	return opts, nil
+}
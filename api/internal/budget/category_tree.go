@@ -0,0 +1,293 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// categoryTree indexes a user's categories by ID so reports can walk the hierarchy
+type categoryTree struct {
+	byID map[uuid.UUID]Category
+}
+
+// loadCategoryTree loads all of a user's categories into a tree
+func loadCategoryTree(ctx context.Context, userID uuid.UUID) (*categoryTree, error) {
+	categories, err := GetCategoriesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &categoryTree{byID: make(map[uuid.UUID]Category, len(categories))}
+	for _, category := range categories {
+		tree.byID[category.ID] = category
+	}
+
+	return tree, nil
+}
+
+// name returns a category's name, or "Uncategorized" for nil or unknown IDs
+func (t *categoryTree) name(categoryID *uuid.UUID) string {
+	if categoryID == nil {
+		return "Uncategorized"
+	}
+	if category, ok := t.byID[*categoryID]; ok {
+		return category.Name
+	}
+	return "Uncategorized"
+}
+
+// root returns the top-level ancestor of a category (the category itself if it
+// has no parent). A parent cycle stops at the first repeated category.
+func (t *categoryTree) root(categoryID uuid.UUID) uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	current := categoryID
+	for {
+		seen[current] = true
+		category, ok := t.byID[current]
+		if !ok || category.ParentCategoryID == nil || seen[*category.ParentCategoryID] {
+			return current
+		}
+		current = *category.ParentCategoryID
+	}
+}
+
+// parent returns a category's parent ID, or nil for top-level and unknown categories
+func (t *categoryTree) parent(categoryID uuid.UUID) *uuid.UUID {
+	if category, ok := t.byID[categoryID]; ok {
+		return category.ParentCategoryID
+	}
+	return nil
+}
+
+// ancestors returns the path from the top-level ancestor down to the category itself
+func (t *categoryTree) ancestors(categoryID uuid.UUID) []uuid.UUID {
+	var path []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for current := &categoryID; current != nil && !seen[*current]; current = t.parent(*current) {
+		seen[*current] = true
+		path = append([]uuid.UUID{*current}, path...)
+	}
+	return path
+}
+
+// depth returns how far below the top level a category sits (top-level = 0)
+func (t *categoryTree) depth(categoryID uuid.UUID) int {
+	return len(t.ancestors(categoryID)) - 1
+}
+
+// isWithin reports whether a category is ancestorID or one of its descendants
+func (t *categoryTree) isWithin(categoryID, ancestorID uuid.UUID) bool {
+	for _, id := range t.ancestors(categoryID) {
+		if id == ancestorID {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryRollupOptions controls how reports group categories in the hierarchy
+type CategoryRollupOptions struct {
+	Depth    *int       // Roll categories up to their ancestor at this depth (0 = top-level); nil keeps leaf categories
+	ParentID *uuid.UUID // Only include this category's subtree; Depth defaults to its children
+}
+
+// groupFor returns the category a transaction's category should be reported
+// under. ok is false when the category falls outside the requested subtree.
+// A nil group means uncategorized.
+func (t *categoryTree) groupFor(categoryID *uuid.UUID, opts CategoryRollupOptions) (group *uuid.UUID, ok bool) {
+	if categoryID == nil {
+		return nil, opts.ParentID == nil
+	}
+	if opts.ParentID != nil && !t.isWithin(*categoryID, *opts.ParentID) {
+		return nil, false
+	}
+
+	depth := opts.Depth
+	if depth == nil && opts.ParentID != nil {
+		childDepth := t.depth(*opts.ParentID) + 1
+		depth = &childDepth
+	}
+	if depth == nil {
+		return categoryID, true
+	}
+
+	path := t.ancestors(*categoryID)
+	if *depth < len(path) {
+		return &path[*depth], true
+	}
+	return categoryID, true
+}
+
+// CategoryRollup is a category's total including everything beneath it, with
+// its subcategories nested as children
+type CategoryRollup struct {
+	CategoryID       *uuid.UUID       `json:"category_id"` // nil for uncategorized
+	CategoryName     string           `json:"category_name"`
+	ParentCategoryID *uuid.UUID       `json:"parent_category_id,omitempty"`
+	Depth            int              `json:"depth"`
+	Color            *string          `json:"color,omitempty"`
+	TotalAmount      float64          `json:"total_amount"`  // Including all descendants
+	DirectAmount     float64          `json:"direct_amount"` // Transactions categorized directly on this category
+	Count            int              `json:"count"`         // Transactions including all descendants
+	Percentage       float64          `json:"percentage"`    // Share of the rollup's grand total
+	Children         []CategoryRollup `json:"children"`
+}
+
+// categoryAmount is a raw total for a single category
+type categoryAmount struct {
+	CategoryID *uuid.UUID
+	Amount     float64
+	Count      int
+}
+
+// buildCategoryRollup nests per-category totals into a tree with subtotals at
+// every level. With a ParentID the tree is rooted at that category's children.
+func (t *categoryTree) buildCategoryRollup(amounts []categoryAmount, opts CategoryRollupOptions) []CategoryRollup {
+	nodes := make(map[uuid.UUID]*CategoryRollup)
+	var uncategorized, parentDirect *CategoryRollup
+	grandTotal := 0.0
+
+	node := func(id uuid.UUID) *CategoryRollup {
+		if n, ok := nodes[id]; ok {
+			return n
+		}
+		n := &CategoryRollup{
+			CategoryID:   &uuid.UUID{},
+			CategoryName: t.name(&id),
+			Depth:        t.depth(id),
+			Children:     []CategoryRollup{},
+		}
+		*n.CategoryID = id
+		if category, ok := t.byID[id]; ok {
+			n.ParentCategoryID = category.ParentCategoryID
+			n.Color = category.Color
+		}
+		nodes[id] = n
+		return n
+	}
+
+	for _, a := range amounts {
+		if a.CategoryID == nil {
+			if opts.ParentID != nil {
+				continue
+			}
+			if uncategorized == nil {
+				uncategorized = &CategoryRollup{CategoryName: "Uncategorized", Children: []CategoryRollup{}}
+			}
+			uncategorized.TotalAmount += a.Amount
+			uncategorized.DirectAmount += a.Amount
+			uncategorized.Count += a.Count
+			grandTotal += a.Amount
+			continue
+		}
+		if opts.ParentID != nil && !t.isWithin(*a.CategoryID, *opts.ParentID) {
+			continue
+		}
+
+		grandTotal += a.Amount
+		if opts.ParentID != nil && *a.CategoryID == *opts.ParentID {
+			// Transactions on the parent itself are listed alongside its children
+			if parentDirect == nil {
+				parentDirect = &CategoryRollup{
+					CategoryID:       opts.ParentID,
+					CategoryName:     t.name(opts.ParentID),
+					ParentCategoryID: t.parent(*opts.ParentID),
+					Depth:            t.depth(*opts.ParentID),
+					Children:         []CategoryRollup{},
+				}
+			}
+			parentDirect.TotalAmount += a.Amount
+			parentDirect.DirectAmount += a.Amount
+			parentDirect.Count += a.Count
+			continue
+		}
+		node(*a.CategoryID).DirectAmount += a.Amount
+		for _, id := range t.ancestors(*a.CategoryID) {
+			n := node(id)
+			n.TotalAmount += a.Amount
+			n.Count += a.Count
+		}
+	}
+
+	// Link children to parents, deepest first so subtrees are complete when copied
+	ids := make([]uuid.UUID, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return nodes[ids[i]].Depth > nodes[ids[j]].Depth })
+
+	rootDepth := 0
+	if opts.ParentID != nil {
+		rootDepth = t.depth(*opts.ParentID) + 1
+	}
+
+	var roots []CategoryRollup
+	for _, id := range ids {
+		n := nodes[id]
+		if grandTotal != 0 {
+			n.Percentage = (n.TotalAmount / grandTotal) * 100
+		}
+		sortRollups(n.Children)
+		if opts.Depth != nil && n.Depth >= *opts.Depth {
+			n.Children = []CategoryRollup{} // Collapse below the requested depth
+		}
+
+		if n.Depth == rootDepth {
+			roots = append(roots, *n)
+		} else if n.Depth > rootDepth && n.ParentCategoryID != nil {
+			if parent, ok := nodes[*n.ParentCategoryID]; ok {
+				parent.Children = append(parent.Children, *n)
+			}
+		}
+	}
+
+	for _, extra := range []*CategoryRollup{parentDirect, uncategorized} {
+		if extra == nil {
+			continue
+		}
+		if grandTotal != 0 {
+			extra.Percentage = (extra.TotalAmount / grandTotal) * 100
+		}
+		roots = append(roots, *extra)
+	}
+
+	if roots == nil {
+		roots = []CategoryRollup{}
+	}
+	sortRollups(roots)
+	return roots
+}
+
+// sortRollups orders rollups by total, largest first
+func sortRollups(rollups []CategoryRollup) {
+	sort.Slice(rollups, func(i, j int) bool {
+		if rollups[i].TotalAmount != rollups[j].TotalAmount {
+			return rollups[i].TotalAmount > rollups[j].TotalAmount
+		}
+		return rollups[i].CategoryName < rollups[j].CategoryName
+	})
+}
+
+// parseCategoryRollupOptions reads ?depth= and ?parent_id= from a request
+func parseCategoryRollupOptions(depthStr, parentIDStr string) (CategoryRollupOptions, error) {
+	var opts CategoryRollupOptions
+	if depthStr != "" {
+		depth, err := strconv.Atoi(depthStr)
+		if err != nil || depth < 0 {
+			return opts, fmt.Errorf("invalid depth: must be a non-negative integer")
+		}
+		opts.Depth = &depth
+	}
+	if parentIDStr != "" {
+		parentID, err := uuid.Parse(parentIDStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid parent_id")
+		}
+		opts.ParentID = &parentID
+	}
+	return opts, nil
+}
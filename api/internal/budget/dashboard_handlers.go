@@ -1,6 +1,8 @@
 package budget
 
 import (
+	"context"
+	"sort"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -37,11 +39,13 @@ type UpcomingBill struct {
 
 // CategorySpending represents spending grouped by category
 type CategorySpending struct {
-	CategoryID   *uuid.UUID `json:"category_id,omitempty"`
-	CategoryName string     `json:"category_name"`
-	TotalAmount  float64    `json:"total_amount"`
-	Percentage   float64    `json:"percentage"`
-	Color        *string    `json:"color,omitempty"`
+	CategoryID       *uuid.UUID `json:"category_id,omitempty"`
+	CategoryName     string     `json:"category_name"`
+	ParentCategoryID *uuid.UUID `json:"parent_category_id,omitempty"`
+	Depth            int        `json:"depth"` // 0 = top-level category
+	TotalAmount      float64    `json:"total_amount"`
+	Percentage       float64    `json:"percentage"`
+	Color            *string    `json:"color,omitempty"`
 }
 
 // GetDashboardSummaryHandler returns comprehensive dashboard data
@@ -53,17 +57,32 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	summary, err := BuildDashboardSummary(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve accounts",
+		})
+	}
+
+	return c.JSON(summary)
+}
+
+// upcomingBillsDays is how far ahead the dashboard looks for bills
+const upcomingBillsDays = 30
+
+// BuildDashboardSummary gathers the dashboard overview for a user. Only a
+// failure to load accounts is an error; other sections are left empty when
+// their data can't be loaded.
+func BuildDashboardSummary(ctx context.Context, userID uuid.UUID) (*DashboardSummary, error) {
 	// Get current month start/end dates
 	now := time.Now()
 	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	monthEnd := monthStart.AddDate(0, 1, 0).Add(-time.Second)
 
 	// Get all accounts for total balance
-	accounts, err := GetAccountsByUserID(c.Context(), userID)
+	accounts, err := GetAccountsByUserID(ctx, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve accounts",
-		})
+		return nil, err
 	}
 
 	totalBalance := 0.0
@@ -80,7 +99,7 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 	monthIncome := 0.0
 	monthExpenses := 0.0
 
-	transactions, err := GetTransactionsByUserID(c.Context(), userID, nil, nil, &monthStartStr, &monthEndStr)
+	transactions, err := GetTransactionsByUserID(ctx, userID, nil, nil, &monthStartStr, &monthEndStr)
 	if err == nil {
 		for _, t := range transactions {
 			if t.TransactionType == "income" {
@@ -97,9 +116,9 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 	var budgetHealth int
 	var healthStatus, healthMessage, healthColor string
 
-	activeBudget, err := GetActiveBudget(c.Context(), userID)
+	activeBudget, err := GetActiveBudget(ctx, userID)
 	if err == nil && activeBudget != nil {
-		summary, err := CalculateBudgetSummary(c.Context(), activeBudget.ID, userID)
+		summary, err := CalculateBudgetSummary(ctx, activeBudget.ID, userID)
 		if err == nil {
 			budgetedIncome = summary.TotalMonthlyIncome
 			budgetedExpenses = summary.TotalMonthlyExpenses
@@ -113,31 +132,15 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get upcoming bills (next 30 days from budget entries)
+	// Get upcoming bills: unpaid expense occurrences due this month so far
+	// (overdue) or in the next 30 days
 	upcomingBills := []UpcomingBill{}
 	if activeBudget != nil {
-		entries, err := GetBudgetEntriesByBudgetID(c.Context(), activeBudget.ID, userID)
-		if err == nil {
-			// Get upcoming expenses only
-			for _, entry := range entries {
-				if entry.EntryType == "expense" && entry.IsActive {
-					// Simple logic: include all recurring entries
-					// In a real implementation, calculate actual due dates based on frequency
-					upcomingBills = append(upcomingBills, UpcomingBill{
-						ID:         entry.ID,
-						Name:       entry.Name,
-						Amount:     entry.Amount,
-						DueDate:    entry.StartDate,
-						CategoryID: entry.CategoryID,
-						IsOverdue:  false,
-					})
-				}
-			}
-		}
+		upcomingBills = getUpcomingBills(ctx, userID, activeBudget.ID, now, upcomingBillsDays)
 	}
 
 	// Get recent transactions (last 10)
-	recentTransactions, err := GetTransactionsByUserID(c.Context(), userID, nil, nil, nil, nil)
+	recentTransactions, err := GetTransactionsByUserID(ctx, userID, nil, nil, nil, nil)
 	if err != nil {
 		recentTransactions = []Transaction{}
 	}
@@ -148,7 +151,7 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 
 	// Get spending by category for this month
 	spendingByCategory := []CategorySpending{}
-	categories, err := GetCategoriesByUserID(c.Context(), userID)
+	categories, err := GetCategoriesByUserID(ctx, userID)
 	if err == nil && len(transactions) > 0 {
 		categoryMap := make(map[string]*CategorySpending)
 
@@ -195,9 +198,14 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 			}
 			spendingByCategory = append(spendingByCategory, *spending)
 		}
+
+		// Biggest categories first
+		sort.Slice(spendingByCategory, func(i, j int) bool {
+			return spendingByCategory[i].TotalAmount > spendingByCategory[j].TotalAmount
+		})
 	}
 
-	summary := DashboardSummary{
+	summary := &DashboardSummary{
 		TotalBalance:           totalBalance,
 		AccountCount:           len(accounts),
 		MonthToDateIncome:      monthIncome,
@@ -214,9 +222,49 @@ func GetDashboardSummaryHandler(c *fiber.Ctx) error {
 		SpendingByCategory:     spendingByCategory,
 	}
 
-	return c.JSON(summary)
+	return summary, nil
 }
 
+// getUpcomingBills expands the budget's expense entries into unpaid
+// occurrences from the start of the month to days ahead, soonest first
+func getUpcomingBills(ctx context.Context, userID, budgetID uuid.UUID, now time.Time, days int) []UpcomingBill {
+	entries, err := GetBudgetEntriesByBudgetID(ctx, budgetID, userID)
+	if err != nil {
+		return []UpcomingBill{}
+	}
+	ledger, err := loadOccurrenceLedger(ctx, userID)
+	if err != nil {
+		return []UpcomingBill{}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := today.AddDate(0, 0, days)
+
+	bills := []UpcomingBill{}
+	for _, entry := range entries {
+		if entry.EntryType != "expense" || !entry.IsActive {
+			continue
+		}
+		for _, occurrence := range projectEntryOccurrences(entry, ledger, from, today, end) {
+			bills = append(bills, UpcomingBill{
+				ID:         entry.ID,
+				Name:       entry.Name,
+				Amount:     entry.Amount,
+				DueDate:    occurrence.DueDate,
+				CategoryID: entry.CategoryID,
+				IsOverdue:  occurrence.Status == "overdue",
+			})
+		}
+	}
+
+	sort.SliceStable(bills, func(i, j int) bool {
+		return bills[i].DueDate < bills[j].DueDate
+	})
+	return bills
+}
+
+
 // GetRecentActivityHandler returns recent transactions
 func GetRecentActivityHandler(c *fiber.Ctx) error {
 	userID := getUserIDFromContext(c)
@@ -273,8 +321,17 @@ func GetSpendingByCategoryHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// Optional hierarchy grouping: ?depth=0 rolls up to top-level categories,
+	// ?parent_id= drills into one category's children
+	opts, err := parseCategoryRollupOptions(c.Query("depth"), c.Query("parent_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Get categories for name lookup
-	categories, err := GetCategoriesByUserID(c.Context(), userID)
+	tree, err := loadCategoryTree(c.Context(), userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve categories",
@@ -283,42 +340,39 @@ func GetSpendingByCategoryHandler(c *fiber.Ctx) error {
 
 	// Group by category
 	categoryMap := make(map[string]*CategorySpending)
+	amountsByCategory := make(map[string]*categoryAmount)
 	totalExpenses := 0.0
 
 	for _, t := range transactions {
 		if t.TransactionType == "expense" {
-			totalExpenses += t.Amount
-
-			var key string
-			var categoryName string
-			var categoryID *uuid.UUID
-			var color *string
-
-			if t.CategoryID != nil {
-				key = t.CategoryID.String()
-				categoryID = t.CategoryID
-
-				for _, cat := range categories {
-					if cat.ID == *t.CategoryID {
-						categoryName = cat.Name
-						color = cat.Color
-						break
-					}
-				}
-			} else {
-				key = "uncategorized"
-				categoryName = "Uncategorized"
+			group, ok := tree.groupFor(t.CategoryID, opts)
+			if !ok {
+				continue
 			}
+			totalExpenses += t.Amount
 
+			key := categoryKey(group)
 			if _, exists := categoryMap[key]; !exists {
-				categoryMap[key] = &CategorySpending{
-					CategoryID:   categoryID,
-					CategoryName: categoryName,
+				spending := &CategorySpending{
+					CategoryID:   group,
+					CategoryName: tree.name(group),
 					TotalAmount:  0,
-					Color:        color,
 				}
+				if group != nil {
+					spending.ParentCategoryID = tree.parent(*group)
+					spending.Depth = tree.depth(*group)
+					spending.Color = tree.byID[*group].Color
+				}
+				categoryMap[key] = spending
 			}
 			categoryMap[key].TotalAmount += t.Amount
+
+			leafKey := categoryKey(t.CategoryID)
+			if _, exists := amountsByCategory[leafKey]; !exists {
+				amountsByCategory[leafKey] = &categoryAmount{CategoryID: t.CategoryID}
+			}
+			amountsByCategory[leafKey].Amount += t.Amount
+			amountsByCategory[leafKey].Count++
 		}
 	}
 
@@ -331,8 +385,16 @@ func GetSpendingByCategoryHandler(c *fiber.Ctx) error {
 		spendingByCategory = append(spendingByCategory, *spending)
 	}
 
+	// Nested view of the same spending with subtotals at every level
+	amounts := make([]categoryAmount, 0, len(amountsByCategory))
+	for _, amount := range amountsByCategory {
+		amounts = append(amounts, *amount)
+	}
+	categoryTree := tree.buildCategoryRollup(amounts, opts)
+
 	return c.JSON(fiber.Map{
 		"spending_by_category": spendingByCategory,
+		"category_tree":        categoryTree,
 		"total_expenses":       totalExpenses,
 		"start_date":           startDate,
 		"end_date":             endDate,
@@ -0,0 +1,589 @@
+package budget
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+)
+
+// archiveFormat identifies help-me-budget archives; archiveVersion is bumped
+// whenever the layout changes in a way older importers can't read
+const (
+	archiveFormat  = "help-me-budget"
+	archiveVersion = 1
+)
+
+// maxArchiveFileSize caps each file read from an uploaded ZIP archive
+const maxArchiveFileSize = 64 << 20
+
+// DataArchive is a portable copy of a user's budget data. IDs are only
+// meaningful within the archive; they're remapped to new IDs on import.
+type DataArchive struct {
+	Format        string               `json:"format"` // Always "help-me-budget"
+	Version       int                  `json:"version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Accounts      []ArchiveAccount     `json:"accounts"`
+	Categories    []ArchiveCategory    `json:"categories"`
+	Budgets       []ArchiveBudget      `json:"budgets"`
+	BudgetEntries []ArchiveBudgetEntry `json:"budget_entries"`
+	Tags          []ArchiveTag         `json:"tags,omitempty"` // Absent from archives made before tags existed
+	Transactions  []ArchiveTransaction `json:"transactions"`
+}
+
+// ArchiveAccount is an account in a data archive
+type ArchiveAccount struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	AccountType string    `json:"account_type"`
+	Balance     float64   `json:"balance"`
+	Currency    string    `json:"currency"`
+	IsActive    bool      `json:"is_active"`
+}
+
+// ArchiveCategory is a category in a data archive. ParentCategoryID refers to
+// another category in the same archive.
+type ArchiveCategory struct {
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name"`
+	CategoryType     string     `json:"category_type"`
+	Color            *string    `json:"color,omitempty"`
+	Icon             *string    `json:"icon,omitempty"`
+	ParentCategoryID *uuid.UUID `json:"parent_category_id,omitempty"`
+	IsActive         bool       `json:"is_active"`
+}
+
+// ArchiveBudget is a budget in a data archive
+type ArchiveBudget struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	IsActive    bool      `json:"is_active"`
+}
+
+// ArchiveBudgetEntry is a budget entry, including its matching rules
+type ArchiveBudgetEntry struct {
+	ID            uuid.UUID              `json:"id"`
+	BudgetID      uuid.UUID              `json:"budget_id"`
+	CategoryID    *uuid.UUID             `json:"category_id,omitempty"`
+	Name          string                 `json:"name"`
+	Description   *string                `json:"description,omitempty"`
+	Amount        float64                `json:"amount"`
+	EntryType     string                 `json:"entry_type"`
+	Frequency     string                 `json:"frequency"`
+	DayOfMonth    *int                   `json:"day_of_month,omitempty"`
+	DayOfWeek     *int                   `json:"day_of_week,omitempty"`
+	StartDate     string                 `json:"start_date"`
+	EndDate       *string                `json:"end_date,omitempty"`
+	MatchingRules map[string]interface{} `json:"matching_rules,omitempty"`
+	IsActive      bool                   `json:"is_active"`
+}
+
+// ArchiveTag is a tag in a data archive
+type ArchiveTag struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Color *string   `json:"color,omitempty"`
+}
+
+// ArchiveTransaction is a transaction in a data archive. TagIDs refer to tags
+// in the same archive.
+type ArchiveTransaction struct {
+	ID              uuid.UUID   `json:"id"`
+	AccountID       uuid.UUID   `json:"account_id"`
+	CategoryID      *uuid.UUID  `json:"category_id,omitempty"`
+	BudgetEntryID   *uuid.UUID  `json:"budget_entry_id,omitempty"`
+	Amount          float64     `json:"amount"`
+	TransactionType string      `json:"transaction_type"`
+	Description     *string     `json:"description,omitempty"`
+	TransactionDate string      `json:"transaction_date"`
+	Notes           *string     `json:"notes,omitempty"`
+	MatchConfidence string      `json:"match_confidence"`
+	TagIDs          []uuid.UUID `json:"tag_ids,omitempty"`
+}
+
+// BuildDataArchive collects everything a user owns into an archive. Inactive
+// accounts, categories, budgets and entries are included since transactions
+// may still refer to them.
+func BuildDataArchive(ctx context.Context, userID uuid.UUID) (*DataArchive, error) {
+	archive := &DataArchive{
+		Format:        archiveFormat,
+		Version:       archiveVersion,
+		ExportedAt:    time.Now().UTC(),
+		Accounts:      []ArchiveAccount{},
+		Categories:    []ArchiveCategory{},
+		Budgets:       []ArchiveBudget{},
+		BudgetEntries: []ArchiveBudgetEntry{},
+		Tags:          []ArchiveTag{},
+		Transactions:  []ArchiveTransaction{},
+	}
+
+	accounts, err := GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		archive.Accounts = append(archive.Accounts, ArchiveAccount{
+			ID:          account.ID,
+			Name:        account.Name,
+			AccountType: account.AccountType,
+			Balance:     account.Balance,
+			Currency:    account.Currency,
+			IsActive:    account.IsActive,
+		})
+	}
+
+	categories, err := GetCategoriesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		archive.Categories = append(archive.Categories, ArchiveCategory{
+			ID:               category.ID,
+			Name:             category.Name,
+			CategoryType:     category.CategoryType,
+			Color:            category.Color,
+			Icon:             category.Icon,
+			ParentCategoryID: category.ParentCategoryID,
+			IsActive:         category.IsActive,
+		})
+	}
+
+	budgets, err := GetBudgetsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, budget := range budgets {
+		archive.Budgets = append(archive.Budgets, ArchiveBudget{
+			ID:          budget.ID,
+			Name:        budget.Name,
+			Description: budget.Description,
+			IsActive:    budget.IsActive,
+		})
+	}
+
+	entries, err := getAllBudgetEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	archive.BudgetEntries = append(archive.BudgetEntries, entries...)
+
+	tags, err := GetTagsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		archive.Tags = append(archive.Tags, ArchiveTag{
+			ID:    tag.ID,
+			Name:  tag.Name,
+			Color: tag.Color,
+		})
+	}
+
+	transactions, err := GetTransactionsByUserID(ctx, userID, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachTransactionTags(ctx, userID, transactions); err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		var tagIDs []uuid.UUID
+		for _, tag := range transaction.Tags {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+		archive.Transactions = append(archive.Transactions, ArchiveTransaction{
+			ID:              transaction.ID,
+			AccountID:       transaction.AccountID,
+			CategoryID:      transaction.CategoryID,
+			BudgetEntryID:   transaction.BudgetEntryID,
+			Amount:          transaction.Amount,
+			TransactionType: transaction.TransactionType,
+			Description:     transaction.Description,
+			TransactionDate: transaction.TransactionDate,
+			Notes:           transaction.Notes,
+			MatchConfidence: transaction.MatchConfidence,
+			TagIDs:          tagIDs,
+		})
+	}
+
+	return archive, nil
+}
+
+// getAllBudgetEntries loads every entry across the user's budgets, including
+// inactive ones
+func getAllBudgetEntries(ctx context.Context, userID uuid.UUID) ([]ArchiveBudgetEntry, error) {
+	query := `
+		SELECT e.id, e.budget_id, e.category_id, e.name, e.description, e.amount, e.entry_type,
+		       e.frequency, e.day_of_month, e.day_of_week, e.start_date::text, e.end_date::text,
+		       e.matching_rules, e.is_active
+		FROM budget.budget_entries e
+		JOIN budget.budgets b ON b.id = e.budget_id
+		WHERE b.user_id = $1
+		ORDER BY e.budget_id, e.created_at
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budget entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []ArchiveBudgetEntry
+	for rows.Next() {
+		var entry ArchiveBudgetEntry
+		var matchingRulesJSON []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.BudgetID,
+			&entry.CategoryID,
+			&entry.Name,
+			&entry.Description,
+			&entry.Amount,
+			&entry.EntryType,
+			&entry.Frequency,
+			&entry.DayOfMonth,
+			&entry.DayOfWeek,
+			&entry.StartDate,
+			&entry.EndDate,
+			&matchingRulesJSON,
+			&entry.IsActive,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget entry: %w", err)
+		}
+
+		if matchingRulesJSON != nil {
+			if err := json.Unmarshal(matchingRulesJSON, &entry.MatchingRules); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal matching rules: %w", err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating budget entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ============================================================================
+// ZIP of CSVs
+// ============================================================================
+
+// archiveManifest is manifest.json inside a ZIP archive
+type archiveManifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Files      []string  `json:"files"`
+}
+
+// archiveCellKind says how a CSV cell maps to a JSON value
+type archiveCellKind int
+
+const (
+	cellString archiveCellKind = iota
+	cellNumber
+	cellBool
+	cellJSON // Nested objects such as matching rules, stored as JSON text
+)
+
+type archiveColumn struct {
+	name string
+	kind archiveCellKind
+}
+
+// archiveTable describes one CSV file. Column names match the JSON field
+// names of the archive types, which is how rows are converted both ways.
+type archiveTable struct {
+	file    string
+	columns []archiveColumn
+	records func(a *DataArchive) interface{} // Pointer to the archive slice the file holds
+}
+
+var archiveTables = []archiveTable{
+	{
+		file: "accounts.csv",
+		columns: []archiveColumn{
+			{"id", cellString}, {"name", cellString}, {"account_type", cellString},
+			{"balance", cellNumber}, {"currency", cellString}, {"is_active", cellBool},
+		},
+		records: func(a *DataArchive) interface{} { return &a.Accounts },
+	},
+	{
+		file: "categories.csv",
+		columns: []archiveColumn{
+			{"id", cellString}, {"name", cellString}, {"category_type", cellString},
+			{"color", cellString}, {"icon", cellString}, {"parent_category_id", cellString},
+			{"is_active", cellBool},
+		},
+		records: func(a *DataArchive) interface{} { return &a.Categories },
+	},
+	{
+		file: "budgets.csv",
+		columns: []archiveColumn{
+			{"id", cellString}, {"name", cellString}, {"description", cellString}, {"is_active", cellBool},
+		},
+		records: func(a *DataArchive) interface{} { return &a.Budgets },
+	},
+	{
+		file: "budget_entries.csv",
+		columns: []archiveColumn{
+			{"id", cellString}, {"budget_id", cellString}, {"category_id", cellString},
+			{"name", cellString}, {"description", cellString}, {"amount", cellNumber},
+			{"entry_type", cellString}, {"frequency", cellString}, {"day_of_month", cellNumber},
+			{"day_of_week", cellNumber}, {"start_date", cellString}, {"end_date", cellString},
+			{"matching_rules", cellJSON}, {"is_active", cellBool},
+		},
+		records: func(a *DataArchive) interface{} { return &a.BudgetEntries },
+	},
+	{
+		file: "tags.csv",
+		columns: []archiveColumn{
+			{"id", cellString}, {"name", cellString}, {"color", cellString},
+		},
+		records: func(a *DataArchive) interface{} { return &a.Tags },
+	},
+	{
+		file: "transactions.csv",
+		columns: []archiveColumn{
+			{"id", cellString}, {"account_id", cellString}, {"category_id", cellString},
+			{"budget_entry_id", cellString}, {"amount", cellNumber}, {"transaction_type", cellString},
+			{"description", cellString}, {"transaction_date", cellString}, {"notes", cellString},
+			{"match_confidence", cellString}, {"tag_ids", cellJSON},
+		},
+		records: func(a *DataArchive) interface{} { return &a.Transactions },
+	},
+}
+
+// WriteArchiveZip writes the archive as manifest.json plus one CSV per table
+func WriteArchiveZip(w io.Writer, archive *DataArchive) error {
+	zw := zip.NewWriter(w)
+
+	manifest := archiveManifest{
+		Format:     archive.Format,
+		Version:    archive.Version,
+		ExportedAt: archive.ExportedAt,
+	}
+	for _, table := range archiveTables {
+		manifest.Files = append(manifest.Files, table.file)
+	}
+
+	fw, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(fw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+
+	for _, table := range archiveTables {
+		fw, err := zw.Create(table.file)
+		if err != nil {
+			return err
+		}
+		if err := table.writeCSV(fw, archive); err != nil {
+			return fmt.Errorf("failed to write %s: %w", table.file, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeCSV round-trips each record through JSON so cells can be looked up by
+// column name
+func (t archiveTable) writeCSV(w io.Writer, archive *DataArchive) error {
+	data, err := json.Marshal(t.records(archive))
+	if err != nil {
+		return err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		row := make([]string, len(t.columns))
+		for i, column := range t.columns {
+			row[i], err = formatArchiveCell(record[column.name])
+			if err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatArchiveCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
+
+// ReadArchiveZip reads an archive written by WriteArchiveZip. Missing table
+// files are treated as empty.
+func ReadArchiveZip(data []byte) (*DataArchive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	manifestFile, ok := files["manifest.json"]
+	if !ok {
+		return nil, fmt.Errorf("archive is missing manifest.json")
+	}
+	manifestData, err := readArchiveFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest archiveManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest.json: %w", err)
+	}
+
+	archive := &DataArchive{
+		Format:     manifest.Format,
+		Version:    manifest.Version,
+		ExportedAt: manifest.ExportedAt,
+	}
+	for _, table := range archiveTables {
+		file, ok := files[table.file]
+		if !ok {
+			continue
+		}
+		content, err := readArchiveFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := table.readCSV(content, archive); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", table.file, err)
+		}
+	}
+
+	return archive, nil
+}
+
+func readArchiveFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxArchiveFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	if len(data) > maxArchiveFileSize {
+		return nil, fmt.Errorf("%s is too large", file.Name)
+	}
+	return data, nil
+}
+
+// readCSV converts rows to JSON objects keyed by the header row, then decodes
+// them into the archive slice. Unknown columns are ignored and blank cells
+// are left unset, apart from flags which default to true.
+func (t archiveTable) readCSV(content []byte, archive *DataArchive) error {
+	reader := csv.NewReader(bytes.NewReader(content))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	kinds := make(map[string]archiveCellKind, len(t.columns))
+	for _, column := range t.columns {
+		kinds[column.name] = column.kind
+	}
+
+	header := rows[0]
+	records := make([]map[string]interface{}, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		record := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			kind, known := kinds[name]
+			if !known || i >= len(row) || row[i] == "" {
+				continue
+			}
+			value, err := parseArchiveCell(row[i], kind)
+			if err != nil {
+				return fmt.Errorf("row %d, column %s: %w", line+2, name, err)
+			}
+			record[name] = value
+		}
+		// Flags default to true like their database columns
+		for _, column := range t.columns {
+			if _, ok := record[column.name]; !ok && column.kind == cellBool {
+				record[column.name] = true
+			}
+		}
+		records = append(records, record)
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, t.records(archive))
+}
+
+func parseArchiveCell(cell string, kind archiveCellKind) (interface{}, error) {
+	switch kind {
+	case cellNumber:
+		return strconv.ParseFloat(cell, 64)
+	case cellBool:
+		return strconv.ParseBool(cell)
+	case cellJSON:
+		var value interface{}
+		if err := json.Unmarshal([]byte(cell), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON")
+		}
+		return value, nil
+	default:
+		return cell, nil
+	}
+}
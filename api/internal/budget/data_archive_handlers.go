@@ -0,0 +1,146 @@
+package budget
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ExportDataHandler downloads all of the user's budget data as a versioned
+// archive: a single JSON document, or a ZIP of CSVs with ?format=zip
+func ExportDataHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "zip" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid format (use json or zip)",
+		})
+	}
+
+	archive, err := BuildDataArchive(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error building data export for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export data",
+		})
+	}
+
+	var buf bytes.Buffer
+	contentType := fiber.MIMEApplicationJSON
+	if format == "zip" {
+		contentType = "application/zip"
+		err = WriteArchiveZip(&buf, archive)
+	} else {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(archive)
+	}
+	if err != nil {
+		log.Printf("Error writing data export for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export data",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="help-me-budget-export-%s.%s"`, time.Now().Format("2006-01-02"), format))
+	return c.Send(buf.Bytes())
+}
+
+// ImportDataHandler restores an archive produced by ExportDataHandler into an
+// account with no existing data. The archive can be sent as the raw request
+// body or as a multipart upload in the "file" field; JSON and ZIP archives are
+// told apart by their content.
+func ImportDataHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	data := c.Body()
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read uploaded file",
+			})
+		}
+		defer file.Close()
+
+		data, err = io.ReadAll(file)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read uploaded file",
+			})
+		}
+	}
+
+	if len(data) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Archive is required",
+		})
+	}
+
+	var archive *DataArchive
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		parsed, err := ReadArchiveZip(data)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		archive = parsed
+	} else {
+		archive = &DataArchive{}
+		if err := json.Unmarshal(data, archive); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid archive (expected a JSON or ZIP export)",
+			})
+		}
+	}
+
+	result, err := ImportDataArchive(c.Context(), userID, archive)
+	if err != nil {
+		var validationErr *ArchiveValidationError
+		if errors.As(err, &validationErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":    "Invalid archive",
+				"problems": validationErr.Problems,
+			})
+		}
+		if err.Error() == "account is not empty" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   "Account is not empty",
+				"message": "Archives can only be imported into an account with no accounts, budgets or transactions",
+			})
+		}
+		log.Printf("Error importing data for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import data",
+		})
+	}
+
+	events.Publish(c.Context(), userID, events.DataImported, result)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Data imported successfully",
+		"imported": result,
+	})
+}
@@ -0,0 +1,468 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxImportErrors caps how many validation problems are reported at once
+const maxImportErrors = 50
+
+// ImportResult counts the records created by an import
+type ImportResult struct {
+	Accounts              int      `json:"accounts"`
+	Categories            int      `json:"categories"`
+	Budgets               int      `json:"budgets"`
+	BudgetEntries         int      `json:"budget_entries"`
+	Tags                  int      `json:"tags"`
+	Transactions          int      `json:"transactions"`
+	ReplacedCategories    int      `json:"replaced_categories"`     // Existing, unused categories removed in favour of the archive's
+	ReplacedCategoryNames []string `json:"replaced_category_names"` // Names of the removed categories
+	KeptCategories        int      `json:"kept_categories"`         // Existing categories kept because something (such as an alert rule) uses them
+}
+
+// ArchiveValidationError lists everything wrong with an archive so it can be
+// fixed in one go
+type ArchiveValidationError struct {
+	Problems []string
+}
+
+func (e *ArchiveValidationError) Error() string {
+	return fmt.Sprintf("invalid archive: %d problem(s)", len(e.Problems))
+}
+
+// archiveValidator collects problems while checking an archive
+type archiveValidator struct {
+	problems []string
+}
+
+func (v *archiveValidator) addf(format string, args ...interface{}) {
+	if len(v.problems) < maxImportErrors {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// collectIDs records each ID in a table, flagging blanks and duplicates
+func (v *archiveValidator) collectIDs(table string, ids []uuid.UUID) map[uuid.UUID]bool {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for i, id := range ids {
+		if id == uuid.Nil {
+			v.addf("%s[%d]: id is required", table, i)
+			continue
+		}
+		if seen[id] {
+			v.addf("%s[%d]: duplicate id %s", table, i, id)
+		}
+		seen[id] = true
+	}
+	return seen
+}
+
+func validDate(date string) bool {
+	_, err := time.Parse("2006-01-02", date)
+	return err == nil
+}
+
+// validateDataArchive checks the archive's version, field values and that
+// every reference points at a record in the same archive
+func validateDataArchive(archive *DataArchive) error {
+	if archive.Format != archiveFormat {
+		return &ArchiveValidationError{Problems: []string{"not a help-me-budget archive"}}
+	}
+	if archive.Version < 1 || archive.Version > archiveVersion {
+		return &ArchiveValidationError{Problems: []string{fmt.Sprintf("unsupported archive version %d (this server reads up to version %d)", archive.Version, archiveVersion)}}
+	}
+
+	v := &archiveValidator{}
+
+	accountIDs := make([]uuid.UUID, len(archive.Accounts))
+	validAccountTypes := map[string]bool{"checking": true, "savings": true, "credit_card": true, "cash": true, "investment": true}
+	for i, account := range archive.Accounts {
+		accountIDs[i] = account.ID
+		if account.Name == "" || len(account.Name) > 255 {
+			v.addf("accounts[%d]: name is required and must be at most 255 characters", i)
+		}
+		if !validAccountTypes[account.AccountType] {
+			v.addf("accounts[%d]: invalid account_type %q", i, account.AccountType)
+		}
+		if account.Currency != "" && len(account.Currency) != 3 {
+			v.addf("accounts[%d]: currency must be a 3-letter code", i)
+		}
+	}
+	accounts := v.collectIDs("accounts", accountIDs)
+
+	categoryIDs := make([]uuid.UUID, len(archive.Categories))
+	parents := make(map[uuid.UUID]uuid.UUID)
+	for i, category := range archive.Categories {
+		categoryIDs[i] = category.ID
+		if category.Name == "" || len(category.Name) > 255 {
+			v.addf("categories[%d]: name is required and must be at most 255 characters", i)
+		}
+		if category.CategoryType != "income" && category.CategoryType != "expense" {
+			v.addf("categories[%d]: category_type must be 'income' or 'expense'", i)
+		}
+		if category.Color != nil && len(*category.Color) != 7 {
+			v.addf("categories[%d]: color must be a hex color like #FF5733", i)
+		}
+		if category.Icon != nil && len(*category.Icon) > 50 {
+			v.addf("categories[%d]: icon must be at most 50 characters", i)
+		}
+		if category.ParentCategoryID != nil {
+			parents[category.ID] = *category.ParentCategoryID
+		}
+	}
+	categories := v.collectIDs("categories", categoryIDs)
+	for i, category := range archive.Categories {
+		if category.ParentCategoryID == nil {
+			continue
+		}
+		if !categories[*category.ParentCategoryID] {
+			v.addf("categories[%d]: parent_category_id %s is not in the archive", i, *category.ParentCategoryID)
+			continue
+		}
+		// Walk up the hierarchy; a chain longer than the number of categories loops
+		current, steps := category.ID, 0
+		for {
+			parent, ok := parents[current]
+			if !ok {
+				break
+			}
+			if parent == category.ID || steps > len(archive.Categories) {
+				v.addf("categories[%d]: parent_category_id creates a cycle", i)
+				break
+			}
+			current = parent
+			steps++
+		}
+	}
+
+	budgetIDs := make([]uuid.UUID, len(archive.Budgets))
+	for i, budget := range archive.Budgets {
+		budgetIDs[i] = budget.ID
+		if budget.Name == "" || len(budget.Name) > 255 {
+			v.addf("budgets[%d]: name is required and must be at most 255 characters", i)
+		}
+	}
+	budgets := v.collectIDs("budgets", budgetIDs)
+
+	entryIDs := make([]uuid.UUID, len(archive.BudgetEntries))
+	for i, entry := range archive.BudgetEntries {
+		entryIDs[i] = entry.ID
+		if !budgets[entry.BudgetID] {
+			v.addf("budget_entries[%d]: budget_id %s is not in the archive", i, entry.BudgetID)
+		}
+		if entry.CategoryID != nil && !categories[*entry.CategoryID] {
+			v.addf("budget_entries[%d]: category_id %s is not in the archive", i, *entry.CategoryID)
+		}
+		if err := validateBudgetEntryRequest(CreateBudgetEntryRequest{
+			Name:      entry.Name,
+			Amount:    entry.Amount,
+			EntryType: entry.EntryType,
+			Frequency: entry.Frequency,
+		}); err != nil {
+			v.addf("budget_entries[%d]: %s", i, err.Error())
+		}
+		if entry.DayOfMonth != nil && (*entry.DayOfMonth < 1 || *entry.DayOfMonth > 31) {
+			v.addf("budget_entries[%d]: day_of_month must be between 1 and 31", i)
+		}
+		if entry.DayOfWeek != nil && (*entry.DayOfWeek < 0 || *entry.DayOfWeek > 6) {
+			v.addf("budget_entries[%d]: day_of_week must be between 0 and 6", i)
+		}
+		if !validDate(entry.StartDate) {
+			v.addf("budget_entries[%d]: start_date must be YYYY-MM-DD", i)
+		}
+		if entry.EndDate != nil && !validDate(*entry.EndDate) {
+			v.addf("budget_entries[%d]: end_date must be YYYY-MM-DD", i)
+		}
+	}
+	entries := v.collectIDs("budget_entries", entryIDs)
+
+	tagIDs := make([]uuid.UUID, len(archive.Tags))
+	tagNames := make(map[string]bool, len(archive.Tags))
+	for i, tag := range archive.Tags {
+		tagIDs[i] = tag.ID
+		if err := validateTagName(tag.Name); err != nil {
+			v.addf("tags[%d]: %s", i, err.Error())
+		} else if normalizeTagName(tag.Name) != tag.Name {
+			v.addf("tags[%d]: name must be lowercase without spaces", i)
+		}
+		if tagNames[tag.Name] {
+			v.addf("tags[%d]: duplicate name %q", i, tag.Name)
+		}
+		tagNames[tag.Name] = true
+		if err := validateTagColor(tag.Color); err != nil {
+			v.addf("tags[%d]: %s", i, err.Error())
+		}
+	}
+	tags := v.collectIDs("tags", tagIDs)
+
+	transactionIDs := make([]uuid.UUID, len(archive.Transactions))
+	validConfidence := map[string]bool{"": true, "manual": true, "auto_high": true, "auto_low": true, "unmatched": true}
+	for i, transaction := range archive.Transactions {
+		transactionIDs[i] = transaction.ID
+		if !accounts[transaction.AccountID] {
+			v.addf("transactions[%d]: account_id %s is not in the archive", i, transaction.AccountID)
+		}
+		if transaction.CategoryID != nil && !categories[*transaction.CategoryID] {
+			v.addf("transactions[%d]: category_id %s is not in the archive", i, *transaction.CategoryID)
+		}
+		if transaction.BudgetEntryID != nil && !entries[*transaction.BudgetEntryID] {
+			v.addf("transactions[%d]: budget_entry_id %s is not in the archive", i, *transaction.BudgetEntryID)
+		}
+		if transaction.Amount <= 0 {
+			v.addf("transactions[%d]: amount must be greater than zero", i)
+		}
+		if transaction.TransactionType != "income" && transaction.TransactionType != "expense" {
+			v.addf("transactions[%d]: transaction_type must be 'income' or 'expense'", i)
+		}
+		if !validDate(transaction.TransactionDate) {
+			v.addf("transactions[%d]: transaction_date must be YYYY-MM-DD", i)
+		}
+		if !validConfidence[transaction.MatchConfidence] {
+			v.addf("transactions[%d]: invalid match_confidence %q", i, transaction.MatchConfidence)
+		}
+		for _, tagID := range transaction.TagIDs {
+			if !tags[tagID] {
+				v.addf("transactions[%d]: tag_id %s is not in the archive", i, tagID)
+			}
+		}
+	}
+	v.collectIDs("transactions", transactionIDs)
+
+	if len(v.problems) > 0 {
+		return &ArchiveValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// ImportDataArchive restores an archive into a user's account. The account
+// must not have any accounts, budgets or transactions yet; unused categories
+// (such as the onboarding defaults) are replaced when the archive has its own.
+// Categories that alert rules use are kept, along with their parents, since
+// deleting them would silently delete the rules too.
+// Every record gets a new ID and the whole import runs in one transaction.
+func ImportDataArchive(ctx context.Context, userID uuid.UUID, archive *DataArchive) (*ImportResult, error) {
+	if err := validateDataArchive(archive); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var accountCount, budgetCount, transactionCount int
+	err = tx.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM budget.accounts WHERE user_id = $1),
+			(SELECT COUNT(*) FROM budget.budgets WHERE user_id = $1),
+			(SELECT COUNT(*) FROM budget.transactions WHERE user_id = $1)
+	`, userID).Scan(&accountCount, &budgetCount, &transactionCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing data: %w", err)
+	}
+	if accountCount > 0 || budgetCount > 0 || transactionCount > 0 {
+		return nil, fmt.Errorf("account is not empty")
+	}
+
+	result := &ImportResult{ReplacedCategoryNames: []string{}}
+
+	if len(archive.Categories) > 0 {
+		if err := replaceUnusedCategories(ctx, tx, userID, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// With no transactions yet, any existing tags are unused
+	if len(archive.Tags) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM budget.tags WHERE user_id = $1`, userID); err != nil {
+			return nil, fmt.Errorf("failed to replace tags: %w", err)
+		}
+	}
+
+	ids := make(map[uuid.UUID]uuid.UUID)
+	remap := func(id *uuid.UUID) *uuid.UUID {
+		if id == nil {
+			return nil
+		}
+		newID := ids[*id]
+		return &newID
+	}
+	for _, account := range archive.Accounts {
+		ids[account.ID] = uuid.New()
+	}
+	for _, category := range archive.Categories {
+		ids[category.ID] = uuid.New()
+	}
+	for _, budget := range archive.Budgets {
+		ids[budget.ID] = uuid.New()
+	}
+	for _, entry := range archive.BudgetEntries {
+		ids[entry.ID] = uuid.New()
+	}
+	for _, tag := range archive.Tags {
+		ids[tag.ID] = uuid.New()
+	}
+	for _, transaction := range archive.Transactions {
+		ids[transaction.ID] = uuid.New()
+	}
+
+	batch := &pgx.Batch{}
+
+	for _, account := range archive.Accounts {
+		currency := account.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		batch.Queue(`
+			INSERT INTO budget.accounts (id, user_id, name, account_type, balance, currency, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, ids[account.ID], userID, account.Name, account.AccountType, account.Balance, currency, account.IsActive)
+	}
+	result.Accounts = len(archive.Accounts)
+
+	// Parents are inserted before their children
+	inserted := make(map[uuid.UUID]bool, len(archive.Categories))
+	for len(inserted) < len(archive.Categories) {
+		for _, category := range archive.Categories {
+			if inserted[category.ID] || (category.ParentCategoryID != nil && !inserted[*category.ParentCategoryID]) {
+				continue
+			}
+			batch.Queue(`
+				INSERT INTO budget.categories (id, user_id, name, category_type, color, icon, parent_category_id, is_active)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, ids[category.ID], userID, category.Name, category.CategoryType, category.Color, category.Icon,
+				remap(category.ParentCategoryID), category.IsActive)
+			inserted[category.ID] = true
+		}
+	}
+	result.Categories = len(archive.Categories)
+
+	for _, budget := range archive.Budgets {
+		batch.Queue(`
+			INSERT INTO budget.budgets (id, user_id, name, description, is_active)
+			VALUES ($1, $2, $3, $4, $5)
+		`, ids[budget.ID], userID, budget.Name, budget.Description, budget.IsActive)
+	}
+	result.Budgets = len(archive.Budgets)
+
+	for _, entry := range archive.BudgetEntries {
+		var matchingRulesJSON []byte
+		if entry.MatchingRules != nil {
+			matchingRulesJSON, err = json.Marshal(entry.MatchingRules)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal matching rules: %w", err)
+			}
+		}
+		batch.Queue(`
+			INSERT INTO budget.budget_entries (id, budget_id, category_id, name, description, amount, entry_type,
+			                                   frequency, day_of_month, day_of_week, start_date, end_date,
+			                                   matching_rules, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, ids[entry.ID], ids[entry.BudgetID], remap(entry.CategoryID), entry.Name, entry.Description, entry.Amount,
+			entry.EntryType, entry.Frequency, entry.DayOfMonth, entry.DayOfWeek, entry.StartDate, entry.EndDate,
+			matchingRulesJSON, entry.IsActive)
+	}
+	result.BudgetEntries = len(archive.BudgetEntries)
+
+	for _, tag := range archive.Tags {
+		batch.Queue(`
+			INSERT INTO budget.tags (id, user_id, name, color)
+			VALUES ($1, $2, $3, $4)
+		`, ids[tag.ID], userID, tag.Name, tag.Color)
+	}
+	result.Tags = len(archive.Tags)
+
+	for _, transaction := range archive.Transactions {
+		// A match without an entry to point at would be meaningless
+		confidence := transaction.MatchConfidence
+		if confidence == "" || transaction.BudgetEntryID == nil {
+			confidence = "unmatched"
+		}
+		batch.Queue(`
+			INSERT INTO budget.transactions (id, user_id, account_id, category_id, budget_entry_id, amount,
+			                                 transaction_type, description, transaction_date, notes, match_confidence)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, ids[transaction.ID], userID, ids[transaction.AccountID], remap(transaction.CategoryID), remap(transaction.BudgetEntryID),
+			transaction.Amount, transaction.TransactionType, transaction.Description, transaction.TransactionDate,
+			transaction.Notes, confidence)
+		for _, tagID := range transaction.TagIDs {
+			batch.Queue(`
+				INSERT INTO budget.transaction_tags (transaction_id, tag_id)
+				VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+			`, ids[transaction.ID], ids[tagID])
+		}
+	}
+	result.Transactions = len(archive.Transactions)
+
+	results := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return nil, fmt.Errorf("failed to import record %d: %w", i+1, err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("failed to import records: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return result, nil
+}
+
+// replaceUnusedCategories deletes the user's categories that nothing refers
+// to, keeping any used by alert rules (or entries and transactions) and the
+// parents of those, and records what happened in result
+func replaceUnusedCategories(ctx context.Context, tx pgx.Tx, userID uuid.UUID, result *ImportResult) error {
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE kept AS (
+			SELECT c.id, c.parent_category_id
+			FROM budget.categories c
+			WHERE c.user_id = $1
+			  AND (EXISTS (SELECT 1 FROM budget.alert_rules r WHERE r.category_id = c.id)
+			    OR EXISTS (SELECT 1 FROM budget.budget_entries e WHERE e.category_id = c.id)
+			    OR EXISTS (SELECT 1 FROM budget.transactions t WHERE t.category_id = c.id))
+			UNION
+			SELECT p.id, p.parent_category_id
+			FROM budget.categories p
+			JOIN kept k ON p.id = k.parent_category_id
+		)
+		DELETE FROM budget.categories
+		WHERE user_id = $1 AND id NOT IN (SELECT id FROM kept)
+		RETURNING name
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to replace categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan replaced category: %w", err)
+		}
+		result.ReplacedCategoryNames = append(result.ReplacedCategoryNames, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to replace categories: %w", err)
+	}
+	result.ReplacedCategories = len(result.ReplacedCategoryNames)
+
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM budget.categories WHERE user_id = $1`, userID).Scan(&result.KeptCategories)
+	if err != nil {
+		return fmt.Errorf("failed to count kept categories: %w", err)
+	}
+
+	return nil
+}
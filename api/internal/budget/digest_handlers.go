@@ -0,0 +1,150 @@
+package budget
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetDigestSettingsHandler returns the user's email digest schedule
+func GetDigestSettingsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	settings, err := GetDigestSettings(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching digest settings for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch digest settings",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"settings":         settings,
+		"email_configured": digestMailer != nil,
+	})
+}
+
+// UpdateDigestSettingsHandler changes the digest schedule or opts out
+func UpdateDigestSettingsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req UpdateDigestSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validateDigestSettings(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	settings, err := UpdateDigestSettings(c.Context(), userID, req)
+	if err != nil {
+		log.Printf("Error updating digest settings for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update digest settings",
+		})
+	}
+
+	return c.JSON(settings)
+}
+
+// PreviewDigestHandler renders the digest the user would receive now without
+// sending it (supports ?frequency=weekly|monthly, defaulting to their schedule)
+func PreviewDigestHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	frequency := c.Query("frequency")
+	if frequency == "" {
+		settings, err := GetDigestSettings(c.Context(), userID)
+		if err != nil {
+			log.Printf("Error fetching digest settings for user %s: %v", userID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch digest settings",
+			})
+		}
+		frequency = settings.Frequency
+	}
+	if frequency != "weekly" && frequency != "monthly" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Frequency must be 'weekly' or 'monthly'",
+		})
+	}
+
+	digest, err := BuildDigest(c.Context(), userID, frequency)
+	if err != nil {
+		log.Printf("Error building digest for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build digest",
+		})
+	}
+
+	rendered, err := RenderDigest(digest)
+	if err != nil {
+		log.Printf("Error rendering digest for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build digest",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"digest":   digest,
+		"rendered": rendered,
+	})
+}
+
+// SendTestDigestHandler emails the digest to the user immediately, ignoring
+// the schedule, so the SMTP setup can be checked
+func SendTestDigestHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if digestMailer == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Email is not configured on this server",
+		})
+	}
+
+	settings, err := GetDigestSettings(c.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching digest settings for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch digest settings",
+		})
+	}
+
+	digest, err := sendDigest(c.Context(), userID, settings.Frequency)
+	if err != nil {
+		log.Printf("Error sending test digest to user %s: %v", userID, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to send digest",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Digest sent to " + digest.Email,
+	})
+}
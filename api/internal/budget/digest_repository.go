@@ -0,0 +1,205 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/brendenbissett/help-me-budget/api/internal/jobs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxDigestsPerRun caps how many digests one scheduler run queues
+const maxDigestsPerRun = 500
+
+const digestSettingsColumns = `user_id, enabled, frequency, day_of_week, day_of_month, send_hour,
+	timezone, next_send_at, last_sent_at, created_at, updated_at`
+
+// scanDigestSettings scans a single digest settings row
+func scanDigestSettings(row pgx.Row) (*DigestSettings, error) {
+	var settings DigestSettings
+	err := row.Scan(
+		&settings.UserID,
+		&settings.Enabled,
+		&settings.Frequency,
+		&settings.DayOfWeek,
+		&settings.DayOfMonth,
+		&settings.SendHour,
+		&settings.Timezone,
+		&settings.NextSendAt,
+		&settings.LastSentAt,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// GetDigestSettings returns a user's digest settings, creating the defaults
+// (weekly on Monday at 08:00 UTC) the first time
+func GetDigestSettings(ctx context.Context, userID uuid.UUID) (*DigestSettings, error) {
+	defaults := defaultDigestSettings()
+
+	query := `
+		INSERT INTO budget.digest_settings (user_id, next_send_at)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING ` + digestSettingsColumns
+
+	settings, err := scanDigestSettings(database.DB.QueryRow(ctx, query, userID, defaults.nextSendAfter(time.Now())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest settings: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateDigestSettings applies a settings change and reschedules the next digest
+func UpdateDigestSettings(ctx context.Context, userID uuid.UUID, req UpdateDigestSettingsRequest) (*DigestSettings, error) {
+	settings, err := GetDigestSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Frequency != nil {
+		settings.Frequency = *req.Frequency
+	}
+	if req.DayOfWeek != nil {
+		settings.DayOfWeek = *req.DayOfWeek
+	}
+	if req.DayOfMonth != nil {
+		settings.DayOfMonth = *req.DayOfMonth
+	}
+	if req.SendHour != nil {
+		settings.SendHour = *req.SendHour
+	}
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
+
+	query := `
+		UPDATE budget.digest_settings
+		SET enabled = $2, frequency = $3, day_of_week = $4, day_of_month = $5,
+		    send_hour = $6, timezone = $7, next_send_at = $8
+		WHERE user_id = $1
+		RETURNING ` + digestSettingsColumns
+
+	updated, err := scanDigestSettings(database.DB.QueryRow(ctx, query,
+		userID,
+		settings.Enabled,
+		settings.Frequency,
+		settings.DayOfWeek,
+		settings.DayOfMonth,
+		settings.SendHour,
+		settings.Timezone,
+		settings.nextSendAfter(time.Now()),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update digest settings: %w", err)
+	}
+	return updated, nil
+}
+
+// markDigestSent records when a user's digest went out
+func markDigestSent(ctx context.Context, userID uuid.UUID, sentAt time.Time) error {
+	_, err := database.DB.Exec(ctx, `
+		UPDATE budget.digest_settings SET last_sent_at = $2 WHERE user_id = $1
+	`, userID, sentAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+	return nil
+}
+
+// queueDueDigests is the scheduler task for email digests. Active users get
+// default settings the first time it sees them; every enabled schedule that
+// has come due then gets a send_digest job and moves to its next slot.
+// Rows are locked with SKIP LOCKED so several API instances can run it.
+func queueDueDigests(ctx context.Context) error {
+	now := time.Now()
+
+	_, err := database.DB.Exec(ctx, `
+		INSERT INTO budget.digest_settings (user_id, next_send_at)
+		SELECT u.id, $1
+		FROM auth.users u
+		WHERE u.is_active = true
+		  AND NOT EXISTS (SELECT 1 FROM budget.digest_settings d WHERE d.user_id = u.id)
+		ON CONFLICT (user_id) DO NOTHING
+	`, defaultDigestSettings().nextSendAfter(now))
+	if err != nil {
+		return fmt.Errorf("failed to create default digest settings: %w", err)
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+digestSettingsColumns+`
+		FROM budget.digest_settings
+		WHERE enabled = true AND next_send_at <= $1
+		ORDER BY next_send_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, now, maxDigestsPerRun)
+	if err != nil {
+		return fmt.Errorf("failed to query due digests: %w", err)
+	}
+
+	var due []*DigestSettings
+	for rows.Next() {
+		settings, err := scanDigestSettings(rows)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan digest settings: %w", err)
+		}
+		due = append(due, settings)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating due digests: %w", err)
+	}
+
+	// Each job is queued in the same transaction that moves next_send_at on, so
+	// a digest is never queued without being rescheduled or vice versa
+	for _, settings := range due {
+		if err := queueDigestInTx(ctx, tx, settings, now); err != nil {
+			// A failed enqueue leaves the schedule alone so the next run retries it
+			log.Printf("Error queueing digest for user %s: %v", settings.UserID, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// queueDigestInTx queues one user's digest job and reschedules their next
+// digest inside a savepoint, so one failure doesn't abort the whole run
+func queueDigestInTx(ctx context.Context, tx pgx.Tx, settings *DigestSettings, now time.Time) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin savepoint: %w", err)
+	}
+	defer savepoint.Rollback(ctx)
+
+	if _, err := jobs.EnqueueTx(ctx, savepoint, settings.UserID, JobTypeSendDigest, nil); err != nil {
+		return err
+	}
+
+	_, err = savepoint.Exec(ctx, `
+		UPDATE budget.digest_settings SET next_send_at = $2 WHERE user_id = $1
+	`, settings.UserID, settings.nextSendAfter(now))
+	if err != nil {
+		return fmt.Errorf("failed to reschedule digest: %w", err)
+	}
+
+	return savepoint.Commit(ctx)
+}
@@ -0,0 +1,342 @@
+package budget
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+	"time"
+	_ "time/tzdata" // Digest timezones must resolve even on hosts without zoneinfo
+
+	"github.com/brendenbissett/help-me-budget/api/internal/auth"
+	"github.com/brendenbissett/help-me-budget/api/internal/export"
+	"github.com/brendenbissett/help-me-budget/api/internal/jobs"
+	"github.com/brendenbissett/help-me-budget/api/internal/mailer"
+	"github.com/google/uuid"
+)
+
+// JobTypeSendDigest emails a user their budget digest
+const JobTypeSendDigest = "send_digest"
+
+// digestScheduleInterval is how often the scheduler looks for due digests
+const digestScheduleInterval = 5 * time.Minute
+
+// digestTopCategories is how many expense categories a digest lists
+const digestTopCategories = 5
+
+// digestMailer sends digests; nil when SMTP isn't configured
+var digestMailer mailer.Mailer
+
+// ConfigureDigests sets the mailer used for email digests. Digests are only
+// scheduled once a mailer is configured.
+func ConfigureDigests(m mailer.Mailer) {
+	digestMailer = m
+}
+
+// DigestSettings is a user's email digest schedule
+type DigestSettings struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Enabled    bool       `json:"enabled"`
+	Frequency  string     `json:"frequency"`    // 'weekly' or 'monthly'
+	DayOfWeek  int        `json:"day_of_week"`  // 0-6 (Sunday-Saturday), weekly digests
+	DayOfMonth int        `json:"day_of_month"` // 1-28, monthly digests
+	SendHour   int        `json:"send_hour"`    // 0-23 in Timezone
+	Timezone   string     `json:"timezone"`
+	NextSendAt time.Time  `json:"next_send_at"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// UpdateDigestSettingsRequest represents the request body for changing a digest schedule
+type UpdateDigestSettingsRequest struct {
+	Enabled    *bool   `json:"enabled,omitempty"`
+	Frequency  *string `json:"frequency,omitempty" validate:"omitempty,oneof=weekly monthly"`
+	DayOfWeek  *int    `json:"day_of_week,omitempty" validate:"omitempty,gte=0,lte=6"`
+	DayOfMonth *int    `json:"day_of_month,omitempty" validate:"omitempty,gte=1,lte=28"`
+	SendHour   *int    `json:"send_hour,omitempty" validate:"omitempty,gte=0,lte=23"`
+	Timezone   *string `json:"timezone,omitempty"`
+}
+
+// Digest is the content of one digest email
+type Digest struct {
+	Name           string             `json:"name"`
+	Email          string             `json:"email"`
+	Frequency      string             `json:"frequency"`
+	Period         string             `json:"period"` // e.g. "Week of 12 Oct 2026" or "October 2026"
+	Currency       string             `json:"currency"`
+	MonthToDateNet float64            `json:"month_to_date_net"`
+	Income         float64            `json:"month_to_date_income"`
+	Expenses       float64            `json:"month_to_date_expenses"`
+	TotalBalance   float64            `json:"total_balance"`
+	HealthStatus   string             `json:"health_status"`
+	HealthMessage  string             `json:"health_message"`
+	HealthScore    int                `json:"health_score"`
+	UpcomingBills  []UpcomingBill     `json:"upcoming_bills"` // Overdue bills and those due before the next digest
+	TopCategories  []CategorySpending `json:"top_categories"`
+	UnmatchedCount int                `json:"unmatched_count"`
+}
+
+// RenderedDigest is a digest rendered as an email
+type RenderedDigest struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// defaultDigestSettings is the schedule new users start with
+func defaultDigestSettings() *DigestSettings {
+	return &DigestSettings{
+		Enabled:    true,
+		Frequency:  "weekly",
+		DayOfWeek:  1,
+		DayOfMonth: 1,
+		SendHour:   8,
+		Timezone:   "UTC",
+	}
+}
+
+// nextSendAfter returns the first scheduled send time strictly after the given time
+func (s *DigestSettings) nextSendAfter(after time.Time) time.Time {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := after.In(loc)
+
+	if s.Frequency == "monthly" {
+		next := time.Date(local.Year(), local.Month(), s.DayOfMonth, s.SendHour, 0, 0, 0, loc)
+		if !next.After(after) {
+			next = time.Date(local.Year(), local.Month()+1, s.DayOfMonth, s.SendHour, 0, 0, 0, loc)
+		}
+		return next
+	}
+
+	next := time.Date(local.Year(), local.Month(), local.Day(), s.SendHour, 0, 0, 0, loc)
+	next = next.AddDate(0, 0, (s.DayOfWeek-int(next.Weekday())+7)%7)
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
+
+// validateDigestSettings checks a settings change
+func validateDigestSettings(req UpdateDigestSettingsRequest) error {
+	if req.Frequency != nil && *req.Frequency != "weekly" && *req.Frequency != "monthly" {
+		return fmt.Errorf("Frequency must be 'weekly' or 'monthly'")
+	}
+	if req.DayOfWeek != nil && (*req.DayOfWeek < 0 || *req.DayOfWeek > 6) {
+		return fmt.Errorf("Day of week must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if req.DayOfMonth != nil && (*req.DayOfMonth < 1 || *req.DayOfMonth > 28) {
+		return fmt.Errorf("Day of month must be between 1 and 28")
+	}
+	if req.SendHour != nil && (*req.SendHour < 0 || *req.SendHour > 23) {
+		return fmt.Errorf("Send hour must be between 0 and 23")
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return fmt.Errorf("Unknown timezone")
+		}
+	}
+	return nil
+}
+
+// BuildDigest assembles a user's digest from their dashboard summary
+func BuildDigest(ctx context.Context, userID uuid.UUID, frequency string) (*Digest, error) {
+	user, err := auth.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := BuildDashboardSummary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unmatched, err := GetUnmatchedTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	period := now.Format("January 2006")
+	horizon := now.AddDate(0, 0, upcomingBillsDays)
+	if frequency == "weekly" {
+		period = "Week of " + now.Format("2 Jan 2006")
+		horizon = now.AddDate(0, 0, 7)
+	}
+
+	bills := []UpcomingBill{}
+	for _, bill := range summary.UpcomingBills {
+		if bill.IsOverdue || bill.DueDate <= horizon.Format("2006-01-02") {
+			bills = append(bills, bill)
+		}
+	}
+
+	topCategories := summary.SpendingByCategory
+	if len(topCategories) > digestTopCategories {
+		topCategories = topCategories[:digestTopCategories]
+	}
+
+	currency := userCurrency(ctx, userID, nil)
+
+	return &Digest{
+		Name:           user.Name,
+		Email:          user.Email,
+		Frequency:      frequency,
+		Period:         period,
+		Currency:       currency,
+		MonthToDateNet: summary.MonthToDateNet,
+		Income:         summary.MonthToDateIncome,
+		Expenses:       summary.MonthToDateExpenses,
+		TotalBalance:   summary.TotalBalance,
+		HealthStatus:   summary.BudgetHealthStatus,
+		HealthMessage:  summary.BudgetHealthMessage,
+		HealthScore:    summary.BudgetHealthScore,
+		UpcomingBills:  bills,
+		TopCategories:  topCategories,
+		UnmatchedCount: len(unmatched),
+	}, nil
+}
+
+// RenderDigest renders a digest as a plain text and HTML email
+func RenderDigest(digest *Digest) (*RenderedDigest, error) {
+	funcs := map[string]interface{}{
+		"money": func(amount float64) string {
+			return export.FormatCurrency(amount, digest.Currency)
+		},
+	}
+
+	var text bytes.Buffer
+	if err := template.Must(template.New("digest").Funcs(funcs).Parse(digestTextTemplate)).Execute(&text, digest); err != nil {
+		return nil, fmt.Errorf("failed to render digest text: %w", err)
+	}
+
+	var html bytes.Buffer
+	if err := htmltemplate.Must(htmltemplate.New("digest").Funcs(funcs).Parse(digestHTMLTemplate)).Execute(&html, digest); err != nil {
+		return nil, fmt.Errorf("failed to render digest HTML: %w", err)
+	}
+
+	subject := fmt.Sprintf("Your budget digest: %s", digest.Period)
+	return &RenderedDigest{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// sendDigest builds, renders and emails a user's digest
+func sendDigest(ctx context.Context, userID uuid.UUID, frequency string) (*Digest, error) {
+	if digestMailer == nil {
+		return nil, fmt.Errorf("email is not configured")
+	}
+
+	digest, err := BuildDigest(ctx, userID, frequency)
+	if err != nil {
+		return nil, err
+	}
+	if digest.Email == "" {
+		return nil, fmt.Errorf("user has no email address")
+	}
+
+	rendered, err := RenderDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = digestMailer.Send(ctx, mailer.Message{
+		To:      digest.Email,
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return digest, markDigestSent(ctx, userID, time.Now())
+}
+
+// runSendDigestJob sends a scheduled digest. Users who opted out after the
+// job was queued are skipped.
+func runSendDigestJob(ctx context.Context, job *jobs.Job, report jobs.ProgressReporter) (interface{}, error) {
+	settings, err := GetDigestSettings(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return map[string]interface{}{"skipped": "digests disabled"}, nil
+	}
+
+	digest, err := sendDigest(ctx, job.UserID, settings.Frequency)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"sent_to": digest.Email, "period": digest.Period}, nil
+}
+
+const digestTextTemplate = `Hi{{if .Name}} {{.Name}}{{end}},
+
+Here's your budget digest for {{.Period}}.
+
+MONTH TO DATE
+  Income:   {{money .Income}}
+  Expenses: {{money .Expenses}}
+  Net:      {{money .MonthToDateNet}}
+  Balance across accounts: {{money .TotalBalance}}
+{{if .HealthStatus}}
+BUDGET HEALTH: {{.HealthStatus}} ({{.HealthScore}}/100)
+  {{.HealthMessage}}
+{{end}}
+UPCOMING BILLS
+{{- range .UpcomingBills}}
+  {{.DueDate}}  {{.Name}}  {{money .Amount}}{{if .IsOverdue}}  OVERDUE{{end}}
+{{- else}}
+  Nothing due.
+{{- end}}
+
+TOP EXPENSE CATEGORIES THIS MONTH
+{{- range .TopCategories}}
+  {{.CategoryName}}  {{money .TotalAmount}} ({{printf "%.0f" .Percentage}}%)
+{{- else}}
+  No spending recorded yet.
+{{- end}}
+{{if .UnmatchedCount}}
+{{.UnmatchedCount}} transaction(s) aren't matched to a budget entry yet.
+{{end}}
+You can change how often you get this email, or turn it off, in your settings.
+`
+
+const digestHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, Helvetica, sans-serif; color: #1f2937; max-width: 600px; margin: 0 auto;">
+<h2 style="margin-bottom: 4px;">Your budget digest</h2>
+<p style="color: #6b7280; margin-top: 0;">{{.Period}}</p>
+<p>Hi{{if .Name}} {{.Name}}{{end}}, here's where your budget stands.</p>
+
+<h3>Month to date</h3>
+<table cellpadding="4" style="border-collapse: collapse;">
+<tr><td>Income</td><td align="right">{{money .Income}}</td></tr>
+<tr><td>Expenses</td><td align="right">{{money .Expenses}}</td></tr>
+<tr><td><strong>Net</strong></td><td align="right"><strong style="color: {{if lt .MonthToDateNet 0.0}}#dc2626{{else}}#16a34a{{end}};">{{money .MonthToDateNet}}</strong></td></tr>
+<tr><td>Balance across accounts</td><td align="right">{{money .TotalBalance}}</td></tr>
+</table>
+{{if .HealthStatus}}
+<h3>Budget health: {{.HealthStatus}} ({{.HealthScore}}/100)</h3>
+<p>{{.HealthMessage}}</p>
+{{end}}
+<h3>Upcoming bills</h3>
+{{if .UpcomingBills}}<table cellpadding="4" style="border-collapse: collapse;">
+{{range .UpcomingBills}}<tr><td>{{.DueDate}}</td><td>{{.Name}}</td><td align="right">{{money .Amount}}</td><td>{{if .IsOverdue}}<strong style="color: #dc2626;">Overdue</strong>{{end}}</td></tr>
+{{end}}</table>{{else}}<p>Nothing due.</p>{{end}}
+
+<h3>Top expense categories this month</h3>
+{{if .TopCategories}}<table cellpadding="4" style="border-collapse: collapse;">
+{{range .TopCategories}}<tr><td>{{.CategoryName}}</td><td align="right">{{money .TotalAmount}}</td><td align="right">{{printf "%.0f" .Percentage}}%</td></tr>
+{{end}}</table>{{else}}<p>No spending recorded yet.</p>{{end}}
+{{if .UnmatchedCount}}
+<p><strong>{{.UnmatchedCount}}</strong> transaction(s) aren't matched to a budget entry yet.</p>
+{{end}}
+<p style="color: #6b7280; font-size: 12px;">You can change how often you get this email, or turn it off, in your settings.</p>
+</body>
+</html>
+`
@@ -0,0 +1,51 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/brendenbissett/help-me-budget/api/internal/jobs"
+)
+
+// JobTypeBulkAutoMatch runs BulkAutoMatch for a user in the background
+const JobTypeBulkAutoMatch = "bulk_auto_match"
+
+// RegisterJobHandlers registers the budget package's background job handlers
+func RegisterJobHandlers() {
+	jobs.Register(JobTypeBulkAutoMatch, 3, runBulkAutoMatchJob)
+	jobs.Register(JobTypeSendDigest, 3, runSendDigestJob)
+}
+
+// RegisterScheduledTasks registers the budget package's periodic tasks. Call
+// ConfigureDigests first; digests aren't scheduled without a mailer.
+func RegisterScheduledTasks() {
+	jobs.Schedule("evaluate_alerts", alertScheduleInterval, evaluateAllAlerts)
+	if digestMailer != nil {
+		jobs.Schedule("queue_due_digests", digestScheduleInterval, queueDueDigests)
+	}
+}
+
+// runBulkAutoMatchJob performs a queued bulk auto-match, reporting progress per link
+func runBulkAutoMatchJob(ctx context.Context, job *jobs.Job, report jobs.ProgressReporter) (interface{}, error) {
+	report(0, "Planning matches")
+
+	result, err := BulkAutoMatch(ctx, job.UserID, func(done, total int) {
+		report(done*100/total, fmt.Sprintf("Linked %d of %d transactions", done, total))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MatchedCount > 0 {
+		events.Publish(ctx, job.UserID, events.MatchingBatchCompleted, result)
+
+		// New links count towards entry and category spending
+		if _, err := EvaluateAlerts(ctx, job.UserID, nil); err != nil {
+			log.Printf("Error evaluating alerts for user %s: %v", job.UserID, err)
+		}
+	}
+
+	return result, nil
+}
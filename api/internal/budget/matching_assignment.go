@@ -0,0 +1,260 @@
+package budget
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// plannedMatch is a transaction paired with the budget entry chosen for it
+type plannedMatch struct {
+	Transaction Transaction
+	Suggestion  MatchSuggestion
+}
+
+// matchCandidate is an unmatched transaction with its scored suggestions
+type matchCandidate struct {
+	Transaction Transaction
+	Suggestions []MatchSuggestion
+}
+
+// occurrenceSlot groups every candidate link that would consume the same
+// budget entry occurrence (entry + schedule period)
+type occurrenceSlot struct {
+	capacity     int
+	transactions []int // indexes into the candidates slice
+}
+
+// planBulkMatches resolves which transaction gets which budget entry across all
+// candidates at once. Each entry period only accepts as many transactions as it
+// has unmatched occurrences, and the total confidence score is maximized rather
+// than handing each transaction its best entry in turn.
+func planBulkMatches(candidates []matchCandidate, ledger *occurrenceLedger) []plannedMatch {
+	// Build one slot per (entry, period) that still has room
+	slots := make(map[string]*occurrenceSlot)
+	var slotOrder []string
+	edges := make([]map[string]MatchSuggestion, len(candidates)) // candidate -> slot key -> suggestion
+
+	for i, candidate := range candidates {
+		edges[i] = make(map[string]MatchSuggestion)
+
+		transDate, err := time.Parse("2006-01-02", candidate.Transaction.TransactionDate)
+		if err != nil {
+			continue
+		}
+
+		for _, suggestion := range candidate.Suggestions {
+			if suggestion.ConfidenceScore < 70 || suggestion.OccurrenceFulfilled {
+				continue
+			}
+
+			remaining, windowStart := ledger.remaining(suggestion.BudgetEntry, transDate)
+			if remaining <= 0 {
+				continue
+			}
+
+			key := suggestion.BudgetEntry.ID.String() + "|" + windowStart.Format("2006-01-02")
+			slot, exists := slots[key]
+			if !exists {
+				slot = &occurrenceSlot{capacity: remaining}
+				slots[key] = slot
+				slotOrder = append(slotOrder, key)
+			}
+			slot.transactions = append(slot.transactions, i)
+			edges[i][key] = suggestion
+		}
+	}
+
+	// Split into independent components so each assignment problem stays small
+	parent := make([]int, len(candidates))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, key := range slotOrder {
+		slot := slots[key]
+		for _, i := range slot.transactions[1:] {
+			parent[find(i)] = find(slot.transactions[0])
+		}
+	}
+
+	components := make(map[int][]int)
+	var componentOrder []int
+	for i := range candidates {
+		if len(edges[i]) == 0 {
+			continue
+		}
+		root := find(i)
+		if _, exists := components[root]; !exists {
+			componentOrder = append(componentOrder, root)
+		}
+		components[root] = append(components[root], i)
+	}
+
+	var plan []plannedMatch
+	for _, root := range componentOrder {
+		rows := components[root]
+
+		// Expand each slot touched by this component into one column per free occurrence
+		var columns []string
+		seen := make(map[string]bool)
+		for _, i := range rows {
+			keys := make([]string, 0, len(edges[i]))
+			for key := range edges[i] {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				slot := slots[key]
+				capacity := slot.capacity
+				if capacity > len(slot.transactions) {
+					capacity = len(slot.transactions)
+				}
+				for c := 0; c < capacity; c++ {
+					columns = append(columns, key)
+				}
+			}
+		}
+
+		weights := make([][]float64, len(rows))
+		for r, i := range rows {
+			weights[r] = make([]float64, len(columns))
+			for c, key := range columns {
+				if suggestion, ok := edges[i][key]; ok {
+					weights[r][c] = suggestion.ConfidenceScore
+				}
+			}
+		}
+
+		assigned := solveAssignment(weights, len(columns))
+		for r, c := range assigned {
+			if c < 0 {
+				continue
+			}
+			i := rows[r]
+			plan = append(plan, plannedMatch{
+				Transaction: candidates[i].Transaction,
+				Suggestion:  edges[i][columns[c]],
+			})
+		}
+	}
+
+	return plan
+}
+
+// solveAssignment returns, for each row, the column assigned to it (or -1) so
+// that the summed weight of all assigned pairs is maximal. A weight of zero
+// means "no edge" and such pairs are never returned. Uses the Hungarian
+// algorithm on a square matrix padded with zero weights.
+func solveAssignment(weights [][]float64, cols int) []int {
+	rows := len(weights)
+	result := make([]int, rows)
+	for i := range result {
+		result[i] = -1
+	}
+	if rows == 0 || cols == 0 {
+		return result
+	}
+
+	n := rows
+	if cols > n {
+		n = cols
+	}
+
+	maxWeight := 0.0
+	for _, row := range weights {
+		for _, w := range row {
+			if w > maxWeight {
+				maxWeight = w
+			}
+		}
+	}
+
+	// Convert to a minimization problem
+	cost := func(i, j int) float64 {
+		if i < rows && j < cols {
+			return maxWeight - weights[i][j]
+		}
+		return maxWeight
+	}
+
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1)   // p[j] = row assigned to column j (1-based, 0 = none)
+	way := make([]int, n+1) // previous column on the augmenting path
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+			if j0 == 0 {
+				break
+			}
+		}
+	}
+
+	for j := 1; j <= n; j++ {
+		i := p[j] - 1
+		col := j - 1
+		if i >= 0 && i < rows && col < cols && weights[i][col] > 0 {
+			result[i] = col
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,263 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateMatchingBatch starts a new matching batch for a user
+func CreateMatchingBatch(ctx context.Context, userID uuid.UUID, source string) (*MatchingBatch, error) {
+	query := `
+		INSERT INTO budget.matching_batches (user_id, source)
+		VALUES ($1, $2)
+		RETURNING id, user_id, source, item_count, undone_at, created_at
+	`
+
+	var batch MatchingBatch
+	err := database.DB.QueryRow(ctx, query, userID, source).Scan(
+		&batch.ID,
+		&batch.UserID,
+		&batch.Source,
+		&batch.ItemCount,
+		&batch.UndoneAt,
+		&batch.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create matching batch: %w", err)
+	}
+
+	return &batch, nil
+}
+
+// setTransactionMatchInBatch sets a transaction's budget entry link and match
+// confidence and records the change in a matching batch. Both writes happen in
+// one database transaction, so a batch can always undo every change it made.
+// The update only applies while the transaction still holds before's link and
+// confidence; if it was re-matched since then (say, by hand while a bulk run
+// was going) nothing is changed and "transaction changed since it was read"
+// is returned.
+func setTransactionMatchInBatch(ctx context.Context, batchID uuid.UUID, userID uuid.UUID, before *Transaction, budgetEntryID *uuid.UUID, confidence string) (*Transaction, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE budget.transactions
+		SET budget_entry_id = $1, match_confidence = $2, updated_at = $3
+		WHERE id = $4 AND user_id = $5
+		  AND budget_entry_id IS NOT DISTINCT FROM $6 AND match_confidence = $7
+		RETURNING id, user_id, account_id, category_id, budget_entry_id, amount,
+		          transaction_type, description, transaction_date::text, notes,
+		          match_confidence, created_at, updated_at
+	`
+
+	var t Transaction
+	err = tx.QueryRow(ctx, query, budgetEntryID, confidence, time.Now(), before.ID, userID, before.BudgetEntryID, before.MatchConfidence).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.AccountID,
+		&t.CategoryID,
+		&t.BudgetEntryID,
+		&t.Amount,
+		&t.TransactionType,
+		&t.Description,
+		&t.TransactionDate,
+		&t.Notes,
+		&t.MatchConfidence,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("transaction changed since it was read")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update transaction match: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		WITH item AS (
+			INSERT INTO budget.matching_batch_items
+			(batch_id, transaction_id, previous_budget_entry_id, previous_match_confidence,
+			 new_budget_entry_id, new_match_confidence)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		)
+		UPDATE budget.matching_batches SET item_count = item_count + 1 WHERE id = $1
+	`,
+		batchID,
+		before.ID,
+		before.BudgetEntryID,
+		before.MatchConfidence,
+		t.BudgetEntryID,
+		t.MatchConfidence,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record matching batch item: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction match: %w", err)
+	}
+
+	return &t, nil
+}
+
+// deleteEmptyMatchingBatch removes a batch that ended up recording nothing,
+// so runs where every link failed don't leave empty batches to undo
+func deleteEmptyMatchingBatch(ctx context.Context, batchID uuid.UUID) error {
+	_, err := database.DB.Exec(ctx, `
+		DELETE FROM budget.matching_batches
+		WHERE id = $1 AND item_count = 0
+	`, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to delete empty matching batch: %w", err)
+	}
+
+	return nil
+}
+
+// GetMatchingBatchesByUserID retrieves a user's most recent matching batches
+func GetMatchingBatchesByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]MatchingBatch, error) {
+	query := `
+		SELECT id, user_id, source, item_count, undone_at, created_at
+		FROM budget.matching_batches
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query matching batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []MatchingBatch
+	for rows.Next() {
+		var batch MatchingBatch
+		err := rows.Scan(
+			&batch.ID,
+			&batch.UserID,
+			&batch.Source,
+			&batch.ItemCount,
+			&batch.UndoneAt,
+			&batch.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan matching batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating matching batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+// UndoMatchingBatch restores every transaction touched by a batch to its previous
+// budget entry and match confidence. Transactions that have been re-matched since
+// the batch ran are left alone and counted as skipped.
+func UndoMatchingBatch(ctx context.Context, batchID uuid.UUID, userID uuid.UUID) (restored int, skipped int, err error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the batch so it can't be undone twice concurrently
+	var undoneAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT undone_at
+		FROM budget.matching_batches
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, batchID, userID).Scan(&undoneAt)
+	if err == pgx.ErrNoRows {
+		return 0, 0, fmt.Errorf("matching batch not found")
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query matching batch: %w", err)
+	}
+	if undoneAt != nil {
+		return 0, 0, fmt.Errorf("matching batch already undone")
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT transaction_id, previous_budget_entry_id, previous_match_confidence,
+		       new_budget_entry_id, new_match_confidence
+		FROM budget.matching_batch_items
+		WHERE batch_id = $1
+		ORDER BY created_at DESC
+	`, batchID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query matching batch items: %w", err)
+	}
+
+	var items []MatchingBatchItem
+	for rows.Next() {
+		var item MatchingBatchItem
+		err := rows.Scan(
+			&item.TransactionID,
+			&item.PreviousBudgetEntryID,
+			&item.PreviousMatchConfidence,
+			&item.NewBudgetEntryID,
+			&item.NewMatchConfidence,
+		)
+		if err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan matching batch item: %w", err)
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("error iterating matching batch items: %w", err)
+	}
+
+	for _, item := range items {
+		// Only restore transactions that still carry the state this batch gave them
+		result, err := tx.Exec(ctx, `
+			UPDATE budget.transactions
+			SET budget_entry_id = $1, match_confidence = $2, updated_at = $3
+			WHERE id = $4 AND user_id = $5
+				AND budget_entry_id IS NOT DISTINCT FROM $6
+				AND match_confidence = $7
+		`,
+			item.PreviousBudgetEntryID,
+			item.PreviousMatchConfidence,
+			time.Now(),
+			item.TransactionID,
+			userID,
+			item.NewBudgetEntryID,
+			item.NewMatchConfidence,
+		)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to restore transaction %s: %w", item.TransactionID, err)
+		}
+
+		if result.RowsAffected() == 0 {
+			skipped++
+		} else {
+			restored++
+		}
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE budget.matching_batches SET undone_at = $1 WHERE id = $2`, time.Now(), batchID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to mark matching batch undone: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit undo: %w", err)
+	}
+
+	return restored, skipped, nil
+}
@@ -3,19 +3,23 @@ package budget
 import (
 	"context"
 	"fmt"
+	"log"
 	"math"
 	"strings"
 	"time"
 
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/brendenbissett/help-me-budget/api/internal/webhooks"
 	"github.com/google/uuid"
 )
 
 // MatchSuggestion represents a suggested match between a transaction and budget entry
 type MatchSuggestion struct {
-	BudgetEntry      BudgetEntry `json:"budget_entry"`
-	ConfidenceScore  float64     `json:"confidence_score"` // 0-100
-	ConfidenceLevel  string      `json:"confidence_level"` // 'auto_high', 'auto_low'
-	MatchReasons     []string    `json:"match_reasons"`
+	BudgetEntry         BudgetEntry `json:"budget_entry"`
+	ConfidenceScore     float64     `json:"confidence_score"` // 0-100
+	ConfidenceLevel     string      `json:"confidence_level"` // 'auto_high', 'auto_low'
+	MatchReasons        []string    `json:"match_reasons"`
+	OccurrenceFulfilled bool        `json:"occurrence_fulfilled"` // Entry already has all its linked transactions for this period
 }
 
 // MatchingCriteria defines how to match transactions to budget entries
@@ -43,6 +47,17 @@ func SuggestMatches(ctx context.Context, transaction *Transaction, userID uuid.U
 		return nil, fmt.Errorf("failed to get budget entries: %w", err)
 	}
 
+	// Load which entry occurrences are already satisfied
+	ledger, err := loadOccurrenceLedger(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load matched occurrences: %w", err)
+	}
+
+	return suggestMatchesFromEntries(transaction, entries, ledger), nil
+}
+
+// suggestMatchesFromEntries scores a transaction against a preloaded set of entries
+func suggestMatchesFromEntries(transaction *Transaction, entries []BudgetEntry, ledger *occurrenceLedger) []MatchSuggestion {
 	// Filter to same transaction type (income/expense)
 	var candidates []BudgetEntry
 	for _, entry := range entries {
@@ -51,22 +66,35 @@ func SuggestMatches(ctx context.Context, transaction *Transaction, userID uuid.U
 		}
 	}
 
+	transDate, dateErr := time.Parse("2006-01-02", transaction.TransactionDate)
+
 	// Score each candidate
 	var suggestions []MatchSuggestion
 	for _, entry := range candidates {
 		score, reasons := scoreMatch(transaction, &entry)
 
+		// Score down entries whose occurrence for this period is already matched
+		fulfilled := false
+		if score > 0 && dateErr == nil {
+			if remaining, _ := ledger.remaining(entry, transDate); remaining <= 0 {
+				fulfilled = true
+				score -= 50
+				reasons = append(reasons, "Already matched for this period")
+			}
+		}
+
 		if score > 0 {
 			confidenceLevel := "auto_low"
-			if score >= 70 {
+			if score >= 70 && !fulfilled {
 				confidenceLevel = "auto_high"
 			}
 
 			suggestions = append(suggestions, MatchSuggestion{
-				BudgetEntry:     entry,
-				ConfidenceScore: score,
-				ConfidenceLevel: confidenceLevel,
-				MatchReasons:    reasons,
+				BudgetEntry:         entry,
+				ConfidenceScore:     score,
+				ConfidenceLevel:     confidenceLevel,
+				MatchReasons:        reasons,
+				OccurrenceFulfilled: fulfilled,
 			})
 		}
 	}
@@ -80,7 +108,7 @@ func SuggestMatches(ctx context.Context, transaction *Transaction, userID uuid.U
 		}
 	}
 
-	return suggestions, nil
+	return suggestions
 }
 
 // scoreMatch calculates match confidence score and reasons
@@ -143,13 +171,14 @@ func scoreByRules(transaction *Transaction, entry *BudgetEntry) (float64, []stri
 
 	rules := entry.MatchingRules
 
-	// Check description_contains
+	// Check description_contains (punctuation-insensitive, so "UBER *EATS" matches "uber eats")
 	if descContains, ok := rules["description_contains"].([]interface{}); ok {
 		if transaction.Description != nil {
-			transDesc := strings.ToLower(*transaction.Description)
+			transDesc := normalizeDescription(*transaction.Description)
 			for _, pattern := range descContains {
 				if patternStr, ok := pattern.(string); ok {
-					if strings.Contains(transDesc, strings.ToLower(patternStr)) {
+					normalizedPattern := normalizeDescription(patternStr)
+					if normalizedPattern != "" && strings.Contains(transDesc, normalizedPattern) {
 						score += 30
 						reasons = append(reasons, fmt.Sprintf("Description contains '%s'", patternStr))
 						break
@@ -331,8 +360,8 @@ func AutoMatchTransaction(ctx context.Context, transactionID, userID uuid.UUID)
 		return nil, err
 	}
 
-	// Auto-link if high confidence match found
-	if len(suggestions) > 0 && suggestions[0].ConfidenceScore >= 70 {
+	// Auto-link if high confidence match found for an occurrence that's still open
+	if len(suggestions) > 0 && suggestions[0].ConfidenceScore >= 70 && !suggestions[0].OccurrenceFulfilled {
 		bestMatch := suggestions[0]
 
 		// Link transaction to budget entry
@@ -340,6 +369,8 @@ func AutoMatchTransaction(ctx context.Context, transactionID, userID uuid.UUID)
 		if err != nil {
 			return nil, err
 		}
+		webhooks.Publish(ctx, userID, webhooks.EventTransactionMatched, updated)
+		events.Publish(ctx, userID, events.TransactionMatched, updated)
 
 		return updated, nil
 	}
@@ -347,25 +378,343 @@ func AutoMatchTransaction(ctx context.Context, transactionID, userID uuid.UUID)
 	return transaction, nil
 }
 
-// BulkAutoMatch attempts to auto-match multiple unmatched transactions
-func BulkAutoMatch(ctx context.Context, userID uuid.UUID) (int, error) {
+// BulkMatchProposal explains what bulk auto-match would do with one unmatched transaction
+type BulkMatchProposal struct {
+	Transaction Transaction       `json:"transaction"`
+	Proposed    *MatchSuggestion  `json:"proposed,omitempty"` // nil when no link would be made
+	RunnerUps   []MatchSuggestion `json:"runner_ups"`
+	Explanation string            `json:"explanation"`
+}
+
+// BulkMatchLink is a single transaction -> budget entry link selected for commit
+type BulkMatchLink struct {
+	TransactionID   uuid.UUID `json:"transaction_id"`
+	BudgetEntryID   uuid.UUID `json:"budget_entry_id"`
+	MatchConfidence string    `json:"match_confidence,omitempty"` // Defaults to 'auto_high'
+}
+
+// BulkMatchLinkResult reports the outcome of committing a single link
+type BulkMatchLinkResult struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	BudgetEntryID uuid.UUID `json:"budget_entry_id"`
+	Status        string    `json:"status"` // 'linked', 'skipped', 'failed'
+	Error         string    `json:"error,omitempty"`
+}
+
+// BulkAutoMatchResult summarizes a bulk auto-match run
+type BulkAutoMatchResult struct {
+	MatchedCount int                   `json:"matched_count"`
+	FailedCount  int                   `json:"failed_count"`
+	BatchID      *uuid.UUID            `json:"batch_id"`
+	Failures     []BulkMatchLinkResult `json:"failures"`
+}
+
+// BulkAutoMatch attempts to auto-match multiple unmatched transactions.
+// Links are resolved across all unmatched transactions at once so that each
+// budget entry occurrence is claimed by at most one transaction. Every link
+// is recorded in a matching batch so the whole run can be undone; the batch
+// ID is nil when nothing was linked. Links that fail are reported in the
+// result rather than skipped silently, as are transactions matched elsewhere
+// while the run was going (with status "skipped"). progress, if non-nil, is called after
+// each planned link is processed.
+func BulkAutoMatch(ctx context.Context, userID uuid.UUID, progress func(done, total int)) (*BulkAutoMatchResult, error) {
+	result := &BulkAutoMatchResult{Failures: []BulkMatchLinkResult{}}
+
+	_, plan, err := planBulkAutoMatch(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plan) == 0 {
+		return result, nil
+	}
+
+	batch, err := CreateMatchingBatch(ctx, userID, "bulk_auto_match")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, match := range plan {
+		if err := ctx.Err(); err != nil {
+			if discardEmptyMatchingBatch(ctx, batch) {
+				result.BatchID = &batch.ID
+			}
+			return result, err
+		}
+
+		_, err := linkInBatch(ctx, batch, userID, &match.Transaction, match.Suggestion.BudgetEntry.ID, "auto_high")
+		if err != nil && err.Error() == "transaction changed since it was read" {
+			// Matched by something else while the run was going; leave it be
+			result.Failures = append(result.Failures, BulkMatchLinkResult{
+				TransactionID: match.Transaction.ID,
+				BudgetEntryID: match.Suggestion.BudgetEntry.ID,
+				Status:        "skipped",
+				Error:         "Transaction was matched while the run was in progress",
+			})
+		} else if err != nil {
+			log.Printf("Bulk auto-match failed to link transaction %s: %v", match.Transaction.ID, err)
+			result.FailedCount++
+			result.Failures = append(result.Failures, BulkMatchLinkResult{
+				TransactionID: match.Transaction.ID,
+				BudgetEntryID: match.Suggestion.BudgetEntry.ID,
+				Status:        "failed",
+				Error:         err.Error(),
+			})
+		} else {
+			result.MatchedCount++
+		}
+
+		if progress != nil {
+			progress(i+1, len(plan))
+		}
+	}
+
+	if discardEmptyMatchingBatch(ctx, batch) {
+		result.BatchID = &batch.ID
+	}
+
+	return result, nil
+}
+
+// linkInBatch links a transaction to a budget entry and records the change in
+// a matching batch. The webhook is only sent once both are committed.
+func linkInBatch(ctx context.Context, batch *MatchingBatch, userID uuid.UUID, before *Transaction, budgetEntryID uuid.UUID, confidence string) (*Transaction, error) {
+	updated, err := setTransactionMatchInBatch(ctx, batch.ID, userID, before, &budgetEntryID, confidence)
+	if err != nil {
+		return nil, err
+	}
+	batch.ItemCount++
+
+	webhooks.Publish(ctx, userID, webhooks.EventTransactionMatched, updated)
+
+	return updated, nil
+}
+
+// discardEmptyMatchingBatch deletes a batch nothing was recorded in and
+// reports whether the batch is kept. A failed delete is only logged.
+func discardEmptyMatchingBatch(ctx context.Context, batch *MatchingBatch) bool {
+	if batch.ItemCount > 0 {
+		return true
+	}
+	// The run may have stopped because ctx was cancelled; clean up regardless
+	if err := deleteEmptyMatchingBatch(context.WithoutCancel(ctx), batch.ID); err != nil {
+		log.Printf("Error deleting empty matching batch %s: %v", batch.ID, err)
+	}
+	return false
+}
+
+// UnlinkTransactionMatch removes a transaction's budget entry link, recording
+// the change as a single-item matching batch so it can be undone
+func UnlinkTransactionMatch(ctx context.Context, transactionID, userID uuid.UUID) (*Transaction, *MatchingBatch, error) {
+	before, err := GetTransactionByID(ctx, transactionID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Nothing to unlink
+	if before.BudgetEntryID == nil && before.MatchConfidence == "unmatched" {
+		return before, nil, nil
+	}
+
+	batch, err := CreateMatchingBatch(ctx, userID, "unlink")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := setTransactionMatchInBatch(ctx, batch.ID, userID, before, nil, "unmatched")
+	if err != nil {
+		discardEmptyMatchingBatch(ctx, batch)
+		return nil, nil, err
+	}
+	batch.ItemCount = 1
+
+	return updated, batch, nil
+}
+
+// PreviewBulkAutoMatch runs the bulk matcher without linking anything and
+// explains the outcome for every unmatched transaction
+func PreviewBulkAutoMatch(ctx context.Context, userID uuid.UUID) ([]BulkMatchProposal, error) {
+	candidates, plan, err := planBulkAutoMatch(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make(map[uuid.UUID]MatchSuggestion)
+	for _, match := range plan {
+		planned[match.Transaction.ID] = match.Suggestion
+	}
+
+	proposals := make([]BulkMatchProposal, 0, len(candidates))
+	for _, candidate := range candidates {
+		proposal := BulkMatchProposal{
+			Transaction: candidate.Transaction,
+			RunnerUps:   []MatchSuggestion{},
+		}
+
+		chosen, ok := planned[candidate.Transaction.ID]
+		if ok {
+			proposal.Proposed = &chosen
+			proposal.Explanation = fmt.Sprintf("Link to '%s' (%.0f%% confidence)", chosen.BudgetEntry.Name, chosen.ConfidenceScore)
+		} else {
+			proposal.Explanation = explainNoMatch(candidate.Suggestions)
+		}
+
+		// Keep the next best alternatives for review
+		for _, suggestion := range candidate.Suggestions {
+			if ok && suggestion.BudgetEntry.ID == chosen.BudgetEntry.ID {
+				continue
+			}
+			proposal.RunnerUps = append(proposal.RunnerUps, suggestion)
+			if len(proposal.RunnerUps) == 3 {
+				break
+			}
+		}
+
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, nil
+}
+
+// explainNoMatch describes why bulk auto-match left a transaction unlinked
+func explainNoMatch(suggestions []MatchSuggestion) string {
+	if len(suggestions) == 0 {
+		return "No budget entries resemble this transaction"
+	}
+
+	best := suggestions[0]
+	if best.OccurrenceFulfilled {
+		return fmt.Sprintf("'%s' is already matched for this period", best.BudgetEntry.Name)
+	}
+	if best.ConfidenceScore < 70 {
+		return fmt.Sprintf("Best match '%s' is below the auto-match threshold (%.0f%% < 70%%)", best.BudgetEntry.Name, best.ConfidenceScore)
+	}
+	return fmt.Sprintf("'%s' was assigned to a better-matching transaction for this period", best.BudgetEntry.Name)
+}
+
+// CommitBulkMatches applies a reviewed subset of bulk auto-match proposals.
+// Each link is validated independently; transactions that have been matched
+// since the preview was generated are skipped. Applied links share one
+// matching batch so the commit can be undone; the batch is nil when nothing
+// was linked.
+func CommitBulkMatches(ctx context.Context, userID uuid.UUID, links []BulkMatchLink) ([]BulkMatchLinkResult, *MatchingBatch, error) {
+	activeBudget, err := GetActiveBudget(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if activeBudget == nil {
+		return nil, nil, fmt.Errorf("no active budget")
+	}
+
+	entries, err := GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get budget entries: %w", err)
+	}
+
+	entriesByID := make(map[uuid.UUID]BudgetEntry)
+	for _, entry := range entries {
+		entriesByID[entry.ID] = entry
+	}
+
+	var batch *MatchingBatch
+	results := make([]BulkMatchLinkResult, 0, len(links))
+	for _, link := range links {
+		result := BulkMatchLinkResult{
+			TransactionID: link.TransactionID,
+			BudgetEntryID: link.BudgetEntryID,
+		}
+
+		confidence := link.MatchConfidence
+		if confidence == "" {
+			confidence = "auto_high"
+		}
+
+		transaction, err := GetTransactionByID(ctx, link.TransactionID, userID)
+		entry, entryFound := entriesByID[link.BudgetEntryID]
+		switch {
+		case err != nil:
+			result.Status = "failed"
+			result.Error = "Transaction not found"
+		case !entryFound:
+			result.Status = "failed"
+			result.Error = "Budget entry not found in active budget"
+		case entry.EntryType != transaction.TransactionType:
+			result.Status = "failed"
+			result.Error = fmt.Sprintf("Cannot link an %s transaction to an %s entry", transaction.TransactionType, entry.EntryType)
+		case confidence != "manual" && confidence != "auto_high" && confidence != "auto_low":
+			result.Status = "failed"
+			result.Error = "Invalid match confidence"
+		case transaction.BudgetEntryID != nil || transaction.MatchConfidence != "unmatched":
+			result.Status = "skipped"
+			result.Error = "Transaction is already matched"
+		default:
+			// The batch is only created once a link passes validation
+			if batch == nil {
+				batch, err = CreateMatchingBatch(ctx, userID, "bulk_commit")
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			if _, err := linkInBatch(ctx, batch, userID, transaction, link.BudgetEntryID, confidence); err != nil {
+				if err.Error() == "transaction changed since it was read" {
+					result.Status = "skipped"
+					result.Error = "Transaction is already matched"
+				} else {
+					log.Printf("Bulk commit failed to link transaction %s: %v", link.TransactionID, err)
+					result.Status = "failed"
+					result.Error = "Failed to link transaction"
+				}
+			} else {
+				result.Status = "linked"
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if batch != nil && !discardEmptyMatchingBatch(ctx, batch) {
+		batch = nil
+	}
+
+	return results, batch, nil
+}
+
+// planBulkAutoMatch scores every unmatched transaction and returns the best
+// overall set of links without applying them
+func planBulkAutoMatch(ctx context.Context, userID uuid.UUID) ([]matchCandidate, []plannedMatch, error) {
 	// Get unmatched transactions
 	unmatched, err := GetUnmatchedTransactions(ctx, userID)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
 
-	matchedCount := 0
-	for _, transaction := range unmatched {
-		matched, err := AutoMatchTransaction(ctx, transaction.ID, userID)
-		if err != nil {
-			continue // Skip errors, keep processing
+	activeBudget, err := GetActiveBudget(ctx, userID)
+	if err != nil || activeBudget == nil {
+		// No active budget: nothing can be matched
+		candidates := make([]matchCandidate, 0, len(unmatched))
+		for _, t := range unmatched {
+			candidates = append(candidates, matchCandidate{Transaction: t})
 		}
+		return candidates, []plannedMatch{}, nil
+	}
 
-		if matched.BudgetEntryID != nil {
-			matchedCount++
-		}
+	entries, err := GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get budget entries: %w", err)
+	}
+
+	ledger, err := loadOccurrenceLedger(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load matched occurrences: %w", err)
+	}
+
+	candidates := make([]matchCandidate, 0, len(unmatched))
+	for i := range unmatched {
+		candidates = append(candidates, matchCandidate{
+			Transaction: unmatched[i],
+			Suggestions: suggestMatchesFromEntries(&unmatched[i], entries, ledger),
+		})
 	}
 
-	return matchedCount, nil
+	return candidates, planBulkMatches(candidates, ledger), nil
 }
@@ -3,7 +3,11 @@ package budget
 import (
 	"context"
 	"fmt"
+	"log"
 
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/brendenbissett/help-me-budget/api/internal/jobs"
+	"github.com/brendenbissett/help-me-budget/api/internal/webhooks"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -71,6 +75,9 @@ func AutoMatchTransactionHandler(c *fiber.Ctx) error {
 	}
 
 	matched := transaction.BudgetEntryID != nil
+	if matched {
+		evaluateAlertsAfterWrite(userID, transaction)
+	}
 
 	return c.JSON(fiber.Map{
 		"transaction": transaction,
@@ -78,7 +85,8 @@ func AutoMatchTransactionHandler(c *fiber.Ctx) error {
 	})
 }
 
-// BulkAutoMatchHandler attempts to auto-match all unmatched transactions
+// BulkAutoMatchHandler attempts to auto-match all unmatched transactions.
+// With ?dry_run=true nothing is linked; the proposed links are returned for review.
 func BulkAutoMatchHandler(c *fiber.Ctx) error {
 	userID := getUserIDFromContext(c)
 	if userID == uuid.Nil {
@@ -87,17 +95,179 @@ func BulkAutoMatchHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Run bulk auto-match
-	matchedCount, err := BulkAutoMatch(c.Context(), userID)
+	if c.QueryBool("dry_run", false) {
+		proposals, err := PreviewBulkAutoMatch(c.Context(), userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to preview bulk auto-match",
+			})
+		}
+
+		proposedCount := 0
+		for _, proposal := range proposals {
+			if proposal.Proposed != nil {
+				proposedCount++
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"dry_run":        true,
+			"proposals":      proposals,
+			"proposed_count": proposedCount,
+		})
+	}
+
+	// Run bulk auto-match in the background; reuse a run that's already queued
+	job, err := jobs.FindActiveJob(c.Context(), userID, JobTypeBulkAutoMatch)
+	if err == nil && job == nil {
+		job, err = jobs.Enqueue(c.Context(), userID, JobTypeBulkAutoMatch, nil)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start bulk auto-match",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"job_id":  job.ID,
+		"status":  job.Status,
+		"message": "Auto-match started",
+	})
+}
+
+// CommitBulkAutoMatchHandler applies a reviewed subset of dry-run proposals
+func CommitBulkAutoMatchHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req struct {
+		Links []BulkMatchLink `json:"links"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Links) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one link is required",
+		})
+	}
+
+	results, batch, err := CommitBulkMatches(c.Context(), userID, req.Links)
 	if err != nil {
+		if err.Error() == "no active budget" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "No active budget",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to bulk auto-match transactions",
+			"error": "Failed to commit matches",
+		})
+	}
+
+	matchedCount := 0
+	for _, result := range results {
+		if result.Status == "linked" {
+			matchedCount++
+		}
+	}
+
+	// No batch is kept when nothing was linked
+	var batchID *uuid.UUID
+	if batch != nil {
+		batchID = &batch.ID
+		events.Publish(c.Context(), userID, events.MatchingBatchCompleted, fiber.Map{
+			"batch_id":      batchID,
+			"matched_count": matchedCount,
 		})
 	}
 
 	return c.JSON(fiber.Map{
 		"matched_count": matchedCount,
-		"message":       "Auto-match completed",
+		"batch_id":      batchID,
+		"results":       results,
+	})
+}
+
+// GetMatchingBatchesHandler returns the user's recent matching batches
+func GetMatchingBatchesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	limit := 20
+	if limitParam := c.QueryInt("limit", 20); limitParam > 0 && limitParam <= 100 {
+		limit = limitParam
+	}
+
+	batches, err := GetMatchingBatchesByUserID(c.Context(), userID, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve matching batches",
+		})
+	}
+
+	if batches == nil {
+		batches = []MatchingBatch{}
+	}
+
+	return c.JSON(fiber.Map{
+		"batches": batches,
+	})
+}
+
+// UndoMatchingBatchHandler restores all transactions touched by a matching batch
+func UndoMatchingBatchHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	batchID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid batch ID",
+		})
+	}
+
+	restored, skipped, err := UndoMatchingBatch(c.Context(), batchID, userID)
+	if err != nil {
+		switch err.Error() {
+		case "matching batch not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Matching batch not found",
+			})
+		case "matching batch already undone":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Matching batch has already been undone",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to undo matching batch",
+		})
+	}
+
+	events.Publish(c.Context(), userID, events.MatchingBatchUndone, fiber.Map{
+		"batch_id":       batchID,
+		"restored_count": restored,
+	})
+
+	return c.JSON(fiber.Map{
+		"batch_id":       batchID,
+		"restored_count": restored,
+		"skipped_count":  skipped, // Transactions changed again since the batch ran
+		"message":        "Matching batch undone",
 	})
 }
 
@@ -193,37 +363,42 @@ func TeachMatchHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// If create_rules is true, extract matching rules from transaction
+	webhooks.Publish(c.Context(), userID, webhooks.EventTransactionMatched, updated)
+	events.Publish(c.Context(), userID, events.TransactionMatched, updated)
+	evaluateAlertsAfterWrite(userID, updated)
+
+	// If create_rules is true, learn matching rules from this transaction and
+	// everything already linked to the entry, merging with any existing rules
+	var learnedRules map[string]interface{}
 	if req.CreateRules && transaction.Description != nil {
 		// Get budget entry to find budget ID
 		entry, err := GetBudgetEntryByID(c.Context(), req.BudgetEntryID, userID)
 		if err == nil {
-			// Create matching rules based on transaction
-			rules := make(map[string]interface{})
-
-			// Add description pattern
-			desc := *transaction.Description
-			rules["description_contains"] = []string{desc}
-
-			// Add amount tolerance if specified
-			if req.AmountTolerance > 0 {
-				rules["amount_tolerance"] = req.AmountTolerance
-			} else {
-				rules["amount_tolerance"] = 2.0 // Default $2 tolerance
+			linked, err := GetTransactionsByBudgetEntryID(c.Context(), userID, entry.ID)
+			if err != nil {
+				log.Printf("Error fetching transactions linked to entry %s: %v", entry.ID, err)
+				linked = []Transaction{*updated}
 			}
 
+			rules := learnMatchingRules(entry, transaction, linked, req.AmountTolerance)
+
 			// Update budget entry with rules
 			updateReq := UpdateBudgetEntryRequest{
 				MatchingRules: rules,
 			}
 
-			_, _ = UpdateBudgetEntry(c.Context(), entry.BudgetID, req.BudgetEntryID, userID, updateReq)
+			if _, err := UpdateBudgetEntry(c.Context(), entry.ID, entry.BudgetID, userID, updateReq); err != nil {
+				log.Printf("Error saving learned matching rules for entry %s: %v", entry.ID, err)
+			} else {
+				learnedRules = rules
+			}
 		}
 	}
 
 	return c.JSON(fiber.Map{
 		"transaction":   updated,
-		"rules_created": req.CreateRules,
+		"rules_created": learnedRules != nil,
+		"rules":         learnedRules,
 	})
 }
 
@@ -0,0 +1,113 @@
+package budget
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// occurrenceLedger tracks which budget entry occurrences are already satisfied
+// by linked transactions, so the matcher doesn't link five Netflix charges to
+// a single monthly Netflix entry
+type occurrenceLedger struct {
+	linked map[uuid.UUID][]time.Time // budget entry ID -> dates of linked transactions
+}
+
+// loadOccurrenceLedger builds a ledger from all of a user's linked transactions
+func loadOccurrenceLedger(ctx context.Context, userID uuid.UUID) (*occurrenceLedger, error) {
+	linked, err := GetLinkedTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOccurrenceLedger(linked), nil
+}
+
+// newOccurrenceLedger builds a ledger from already-loaded linked transactions
+func newOccurrenceLedger(linked []Transaction) *occurrenceLedger {
+	ledger := &occurrenceLedger{
+		linked: make(map[uuid.UUID][]time.Time),
+	}
+
+	for _, t := range linked {
+		if t.BudgetEntryID == nil {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", t.TransactionDate)
+		if err != nil {
+			continue
+		}
+		ledger.linked[*t.BudgetEntryID] = append(ledger.linked[*t.BudgetEntryID], date)
+	}
+
+	return ledger
+}
+
+// fulfilled counts linked transactions for an entry within [start, end]
+func (l *occurrenceLedger) fulfilled(entryID uuid.UUID, start, end time.Time) int {
+	count := 0
+	for _, date := range l.linked[entryID] {
+		if !date.Before(start) && !date.After(end) {
+			count++
+		}
+	}
+	return count
+}
+
+// remaining returns how many occurrences of an entry are still unmatched in the
+// period containing date, along with the start of that period
+func (l *occurrenceLedger) remaining(entry BudgetEntry, date time.Time) (int, time.Time) {
+	start, end := occurrenceWindow(entry, date)
+	expected := expectedOccurrences(entry, start, end)
+	return expected - l.fulfilled(entry.ID, start, end), start
+}
+
+// occurrenceWindow returns the period of an entry's schedule that contains date.
+// Each period is expected to hold a fixed number of occurrences (usually one).
+func occurrenceWindow(entry BudgetEntry, date time.Time) (time.Time, time.Time) {
+	startDate, err := time.Parse("2006-01-02", entry.StartDate)
+	if err != nil {
+		startDate = date
+	}
+
+	switch entry.Frequency {
+	case "daily":
+		return date, date
+
+	case "weekly", "fortnightly":
+		// Align periods to the entry's start date so each one holds exactly one occurrence
+		length := 7
+		if entry.Frequency == "fortnightly" {
+			length = 14
+		}
+		daysSince := int(date.Sub(startDate).Hours() / 24)
+		offset := daysSince % length
+		if offset < 0 {
+			offset += length
+		}
+		start := date.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, length-1)
+
+	case "monthly":
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+
+	case "annually":
+		start := time.Date(date.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+
+	default:
+		// once_off entries only ever occur once, regardless of when
+		return time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// expectedOccurrences counts how many times an entry is scheduled within [start, end]
+func expectedOccurrences(entry BudgetEntry, start, end time.Time) int {
+	if entry.Frequency == "once_off" {
+		return 1
+	}
+
+	return countOccurrences(entry, start, end)
+}
@@ -0,0 +1,254 @@
+package budget
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultAmountTolerance is used when there isn't enough history to derive one
+const defaultAmountTolerance = 2.0
+
+// minAmountTolerance keeps learned tolerances from collapsing to zero for fixed-price charges
+const minAmountTolerance = 0.50
+
+// descriptionNoiseWords are bank statement tokens that say nothing about the merchant
+var descriptionNoiseWords = map[string]bool{
+	"pos": true, "eftpos": true, "visa": true, "mastercard": true, "debit": true,
+	"credit": true, "card": true, "purchase": true, "payment": true, "pmt": true,
+	"direct": true, "dd": true, "transfer": true, "xfer": true, "ref": true,
+	"pty": true, "ltd": true, "inc": true, "llc": true, "the": true,
+	"au": true, "aus": true, "us": true, "usa": true, "nz": true, "uk": true,
+	"to": true, "from": true, "at": true, "on": true, "via": true,
+}
+
+// normalizeDescription lowercases a description and collapses punctuation so
+// "UBER *EATS" and "uber eats" compare equal. Dots and ampersands are kept
+// because they're part of merchant names like "netflix.com" and "m&s".
+func normalizeDescription(description string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(description) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '&' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// merchantTokens extracts the stable, merchant-identifying words from a
+// transaction description. Store numbers, card references, dates and other
+// tokens containing digits are dropped along with common banking noise words.
+func merchantTokens(description string) []string {
+	var tokens []string
+	for _, token := range strings.Fields(normalizeDescription(description)) {
+		token = strings.Trim(token, ".&")
+		if len(token) < 2 || descriptionNoiseWords[token] || strings.IndexFunc(token, unicode.IsDigit) >= 0 {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// merchantKey returns the leading merchant words of a description, e.g.
+// "NETFLIX.COM 8231 SYDNEY" -> "netflix.com". The run stops at the first token
+// that was dropped, since whatever follows a store number is usually a location.
+func merchantKey(description string) string {
+	var key []string
+	for _, token := range strings.Fields(normalizeDescription(description)) {
+		trimmed := strings.Trim(token, ".&")
+		if len(trimmed) < 2 || strings.IndexFunc(trimmed, unicode.IsDigit) >= 0 {
+			if len(key) > 0 {
+				break
+			}
+			continue
+		}
+		if descriptionNoiseWords[trimmed] {
+			if len(key) > 0 {
+				break
+			}
+			continue
+		}
+		key = append(key, trimmed)
+		if len(key) == 2 {
+			break
+		}
+	}
+	return strings.Join(key, " ")
+}
+
+// learnDescriptionPattern picks the pattern to teach from a description. When
+// other transactions are already linked to the entry, the words they all share
+// with this one are preferred; otherwise the leading merchant words are used.
+func learnDescriptionPattern(description string, linkedDescriptions []string) string {
+	tokens := merchantTokens(description)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	if len(linkedDescriptions) > 0 {
+		var shared []string
+		for _, token := range tokens {
+			inAll := true
+			for _, other := range linkedDescriptions {
+				if !containsToken(merchantTokens(other), token) {
+					inAll = false
+					break
+				}
+			}
+			if inAll {
+				shared = append(shared, token)
+			}
+		}
+		if len(shared) > 0 {
+			// Prefer the leading merchant words when every one of them recurs
+			key := merchantKey(description)
+			keyTokens := strings.Fields(key)
+			var sharedKeyTokens []string
+			for _, token := range keyTokens {
+				if containsToken(shared, token) {
+					sharedKeyTokens = append(sharedKeyTokens, token)
+				}
+			}
+			if len(keyTokens) > 0 && len(sharedKeyTokens) == len(keyTokens) {
+				return key
+			}
+			if len(sharedKeyTokens) > 0 {
+				shared = sharedKeyTokens
+			}
+
+			// Otherwise use the longest shared token; it's the least likely to match unrelated merchants
+			best := shared[0]
+			for _, token := range shared[1:] {
+				if len(token) > len(best) {
+					best = token
+				}
+			}
+			return best
+		}
+	}
+
+	if key := merchantKey(description); key != "" {
+		return key
+	}
+	return tokens[0]
+}
+
+// mergeDescriptionPatterns adds a learned pattern to an existing
+// description_contains list. Existing patterns that the new one generalizes
+// (e.g. a full raw description containing it) are replaced.
+func mergeDescriptionPatterns(existing interface{}, pattern string) []string {
+	var merged []string
+	normalizedPattern := normalizeDescription(pattern)
+
+	if list, ok := existing.([]interface{}); ok {
+		for _, item := range list {
+			str, ok := item.(string)
+			if !ok || str == "" {
+				continue
+			}
+			normalized := normalizeDescription(str)
+			if normalizedPattern != "" && strings.Contains(normalized, normalizedPattern) {
+				continue // Subsumed by the more general pattern
+			}
+			merged = append(merged, str)
+		}
+	}
+
+	if normalizedPattern == "" {
+		return merged
+	}
+
+	// Skip the new pattern if an existing, more general one already covers it
+	for _, str := range merged {
+		if strings.Contains(normalizedPattern, normalizeDescription(str)) {
+			return merged
+		}
+	}
+
+	return append(merged, pattern)
+}
+
+// deriveAmountTolerance computes how far a transaction may stray from the
+// entry amount and still match, based on the amounts already linked to it
+func deriveAmountTolerance(entryAmount float64, amounts []float64) float64 {
+	if len(amounts) == 0 {
+		return defaultAmountTolerance
+	}
+
+	maxDeviation := 0.0
+	sum := 0.0
+	for _, amount := range amounts {
+		maxDeviation = math.Max(maxDeviation, math.Abs(amount-entryAmount))
+		sum += amount
+	}
+
+	tolerance := maxDeviation
+	if len(amounts) == 1 {
+		// A single observation says little about variance
+		tolerance = math.Max(tolerance, defaultAmountTolerance)
+	} else {
+		mean := sum / float64(len(amounts))
+		variance := 0.0
+		for _, amount := range amounts {
+			variance += (amount - mean) * (amount - mean)
+		}
+		stdDev := math.Sqrt(variance / float64(len(amounts)-1))
+		tolerance = math.Max(tolerance, 2*stdDev)
+	}
+
+	tolerance = math.Max(tolerance, minAmountTolerance)
+	return math.Ceil(tolerance*100) / 100
+}
+
+// learnMatchingRules merges what a newly linked transaction teaches us into an
+// entry's existing matching rules. Keys other than description_contains and
+// amount_tolerance are preserved as-is.
+func learnMatchingRules(entry *BudgetEntry, transaction *Transaction, linked []Transaction, explicitTolerance float64) map[string]interface{} {
+	rules := make(map[string]interface{})
+	for key, value := range entry.MatchingRules {
+		rules[key] = value
+	}
+
+	var linkedDescriptions []string
+	var amounts []float64
+	seenTransaction := false
+	for _, t := range linked {
+		if t.ID == transaction.ID {
+			seenTransaction = true
+		} else if t.Description != nil && *t.Description != "" {
+			linkedDescriptions = append(linkedDescriptions, *t.Description)
+		}
+		amounts = append(amounts, t.Amount)
+	}
+	if !seenTransaction {
+		amounts = append(amounts, transaction.Amount)
+	}
+
+	if transaction.Description != nil {
+		pattern := learnDescriptionPattern(*transaction.Description, linkedDescriptions)
+		if patterns := mergeDescriptionPatterns(rules["description_contains"], pattern); len(patterns) > 0 {
+			rules["description_contains"] = patterns
+		}
+	}
+
+	if explicitTolerance > 0 {
+		rules["amount_tolerance"] = explicitTolerance
+	} else {
+		rules["amount_tolerance"] = deriveAmountTolerance(entry.Amount, amounts)
+	}
+
+	return rules
+}
+
+// containsToken reports whether token is present in tokens
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
@@ -176,6 +176,7 @@ type Transaction struct {
 	TransactionDate string     `json:"transaction_date"` // DATE format
 	Notes           *string    `json:"notes,omitempty"`
 	MatchConfidence string     `json:"match_confidence"` // 'manual', 'auto_high', 'auto_low', 'unmatched'
+	Tags            []Tag      `json:"tags,omitempty"`   // Only loaded by the transaction list and detail endpoints
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
@@ -203,3 +204,25 @@ type UpdateTransactionRequest struct {
 	Notes           *string    `json:"notes,omitempty"`
 	MatchConfidence *string    `json:"match_confidence,omitempty" validate:"omitempty,oneof=manual auto_high auto_low unmatched"`
 }
+
+// MatchingBatch groups transaction match changes made by one operation so they can be undone together
+type MatchingBatch struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Source    string     `json:"source"` // 'bulk_auto_match', 'bulk_commit', 'unlink'
+	ItemCount int        `json:"item_count"`
+	UndoneAt  *time.Time `json:"undone_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MatchingBatchItem records the before/after match state of one transaction in a batch
+type MatchingBatchItem struct {
+	ID                      uuid.UUID  `json:"id"`
+	BatchID                 uuid.UUID  `json:"batch_id"`
+	TransactionID           uuid.UUID  `json:"transaction_id"`
+	PreviousBudgetEntryID   *uuid.UUID `json:"previous_budget_entry_id,omitempty"`
+	PreviousMatchConfidence string     `json:"previous_match_confidence"`
+	NewBudgetEntryID        *uuid.UUID `json:"new_budget_entry_id,omitempty"`
+	NewMatchConfidence      string     `json:"new_match_confidence"`
+	CreatedAt               time.Time  `json:"created_at"`
+}
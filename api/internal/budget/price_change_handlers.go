@@ -0,0 +1,155 @@
+package budget
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetPriceChangesHandler returns entries whose linked charges have settled at a new amount
+func GetPriceChangesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	changes, err := DetectPriceChanges(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to detect price changes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"price_changes": changes,
+	})
+}
+
+// ApplyPriceChangeHandler updates an entry's amount to its recently charged price
+func ApplyPriceChangeHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	entryID, err := uuid.Parse(c.Params("entryId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid entry ID",
+		})
+	}
+
+	// Amount is optional; defaults to the detected new price
+	var req struct {
+		Amount *float64 `json:"amount"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	entry, err := GetBudgetEntryByID(c.Context(), entryID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Budget entry not found",
+		})
+	}
+
+	amount := req.Amount
+	if amount == nil {
+		transactions, err := GetTransactionsByBudgetEntryID(c.Context(), userID, entry.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to retrieve linked transactions",
+			})
+		}
+
+		change := detectPriceChange(*entry, transactions)
+		if change == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "No sustained price change detected for this entry",
+			})
+		}
+		amount = &change.SuggestedAmount
+	}
+	if *amount <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Amount must be greater than zero",
+		})
+	}
+
+	previousAmount := entry.Amount
+	updated, err := UpdateBudgetEntry(c.Context(), entry.ID, entry.BudgetID, userID, UpdateBudgetEntryRequest{
+		Amount: amount,
+	})
+	if err != nil {
+		log.Printf("Error applying price change to budget entry %s: %v", entry.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update budget entry",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entry":           updated,
+		"previous_amount": previousAmount,
+	})
+}
+
+// GetEntryAmountHistoryHandler returns the amounts charged against a budget entry over time
+func GetEntryAmountHistoryHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid budget ID",
+		})
+	}
+
+	entryID, err := uuid.Parse(c.Params("entryId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid entry ID",
+		})
+	}
+
+	entry, err := GetBudgetEntryByID(c.Context(), entryID, userID)
+	if err != nil || entry.BudgetID != budgetID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Budget entry not found",
+		})
+	}
+
+	transactions, err := GetTransactionsByBudgetEntryID(c.Context(), userID, entry.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve amount history",
+		})
+	}
+
+	var priceChange *PriceChange
+	if entry.IsActive && entry.Frequency != "once_off" {
+		priceChange = detectPriceChange(*entry, transactions)
+	}
+
+	return c.JSON(fiber.Map{
+		"entry_id":     entry.ID,
+		"amount":       entry.Amount,
+		"tolerance":    priceChangeTolerance(*entry),
+		"history":      amountHistory(*entry, transactions),
+		"price_change": priceChange,
+	})
+}
@@ -0,0 +1,184 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// minSustainedCharges is how many consecutive off-price charges make a change
+// sustained. A single odd charge is usually a one-off (pro-rata, refund, fee).
+const minSustainedCharges = 2
+
+// AmountHistoryPoint is one linked transaction in an entry's amount history
+type AmountHistoryPoint struct {
+	TransactionID   uuid.UUID `json:"transaction_id"`
+	TransactionDate string    `json:"transaction_date"`
+	Amount          float64   `json:"amount"`
+	Difference      float64   `json:"difference"` // Amount minus the entry's budgeted amount
+}
+
+// PriceChange is a sustained difference between what an entry budgets and what is actually charged
+type PriceChange struct {
+	EntryID            uuid.UUID            `json:"entry_id"`
+	BudgetID           uuid.UUID            `json:"budget_id"`
+	EntryName          string               `json:"entry_name"`
+	EntryType          string               `json:"entry_type"`
+	Frequency          string               `json:"frequency"`
+	CurrentAmount      float64              `json:"current_amount"`   // Budgeted amount
+	SuggestedAmount    float64              `json:"suggested_amount"` // What has been charged recently
+	Change             float64              `json:"change"`
+	ChangePct          float64              `json:"change_pct"`
+	Direction          string               `json:"direction"` // 'increase' or 'decrease'
+	Since              string               `json:"since"`     // Date of the first charge at the new amount
+	ConsecutiveCharges int                  `json:"consecutive_charges"`
+	Tolerance          float64              `json:"tolerance"`
+	History            []AmountHistoryPoint `json:"history"`
+}
+
+// DetectPriceChanges checks every active entry in the user's active budget for
+// linked charges that have settled at a different amount than budgeted
+func DetectPriceChanges(ctx context.Context, userID uuid.UUID) ([]PriceChange, error) {
+	changes := []PriceChange{}
+
+	activeBudget, err := GetActiveBudget(ctx, userID)
+	if err != nil || activeBudget == nil {
+		return changes, nil // No active budget
+	}
+
+	entries, err := GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget entries: %w", err)
+	}
+
+	linked, err := GetLinkedTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byEntry := make(map[uuid.UUID][]Transaction)
+	for _, t := range linked {
+		byEntry[*t.BudgetEntryID] = append(byEntry[*t.BudgetEntryID], t)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsActive || entry.Frequency == "once_off" {
+			continue
+		}
+		if change := detectPriceChange(entry, byEntry[entry.ID]); change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return math.Abs(changes[i].ChangePct) > math.Abs(changes[j].ChangePct)
+	})
+
+	return changes, nil
+}
+
+// detectPriceChange looks at the most recent run of linked charges. If the
+// latest charges all sit outside the entry's tolerance on the same side, and
+// agree with each other, the entry's amount is out of date.
+func detectPriceChange(entry BudgetEntry, transactions []Transaction) *PriceChange {
+	history := amountHistory(entry, transactions)
+	if len(history) == 0 {
+		return nil
+	}
+
+	tolerance := priceChangeTolerance(entry)
+	latest := history[len(history)-1]
+	if math.Abs(latest.Difference) <= tolerance {
+		return nil
+	}
+	increase := latest.Difference > 0
+
+	// Walk back while charges stay off-price in the same direction and close to the latest amount
+	runStart := len(history) - 1
+	for i := len(history) - 2; i >= 0; i-- {
+		point := history[i]
+		if math.Abs(point.Difference) <= tolerance || (point.Difference > 0) != increase {
+			break
+		}
+		if math.Abs(point.Amount-latest.Amount) > tolerance {
+			break
+		}
+		runStart = i
+	}
+
+	run := history[runStart:]
+	required := minSustainedCharges
+	if entry.Frequency == "annually" {
+		required = 1 // A year is too long to wait for confirmation
+	}
+	if len(run) < required {
+		return nil
+	}
+
+	amounts := make([]float64, len(run))
+	for i, point := range run {
+		amounts[i] = point.Amount
+	}
+	suggested := math.Round(medianOf(amounts)*100) / 100
+	change := math.Round((suggested-entry.Amount)*100) / 100
+
+	direction := "decrease"
+	if increase {
+		direction = "increase"
+	}
+	changePct := 0.0
+	if entry.Amount > 0 {
+		changePct = (change / entry.Amount) * 100
+	}
+
+	return &PriceChange{
+		EntryID:            entry.ID,
+		BudgetID:           entry.BudgetID,
+		EntryName:          entry.Name,
+		EntryType:          entry.EntryType,
+		Frequency:          entry.Frequency,
+		CurrentAmount:      entry.Amount,
+		SuggestedAmount:    suggested,
+		Change:             change,
+		ChangePct:          changePct,
+		Direction:          direction,
+		Since:              run[0].TransactionDate,
+		ConsecutiveCharges: len(run),
+		Tolerance:          tolerance,
+		History:            history,
+	}
+}
+
+// amountHistory orders an entry's linked transactions by date
+func amountHistory(entry BudgetEntry, transactions []Transaction) []AmountHistoryPoint {
+	history := make([]AmountHistoryPoint, 0, len(transactions))
+	for _, t := range transactions {
+		history = append(history, AmountHistoryPoint{
+			TransactionID:   t.ID,
+			TransactionDate: t.TransactionDate,
+			Amount:          t.Amount,
+			Difference:      math.Round((t.Amount-entry.Amount)*100) / 100,
+		})
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		di, _ := time.Parse("2006-01-02", history[i].TransactionDate)
+		dj, _ := time.Parse("2006-01-02", history[j].TransactionDate)
+		return di.Before(dj)
+	})
+
+	return history
+}
+
+// priceChangeTolerance returns how far a charge may differ from the entry
+// amount before it counts as a different price. The entry's own
+// amount_tolerance rule is used when set.
+func priceChangeTolerance(entry BudgetEntry) float64 {
+	if tolerance, ok := entry.MatchingRules["amount_tolerance"].(float64); ok && tolerance > 0 {
+		return tolerance
+	}
+	return minAmountTolerance
+}
@@ -0,0 +1,205 @@
+package budget
+
+import (
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ExtractReceiptHandler reads a receipt image or PDF, sent as a multipart
+// upload in the "file" field, and proposes a transaction from it without
+// saving anything. An account can be chosen with the "account_id" form field
+// or query parameter.
+func ExtractReceiptHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if receiptEngine == nil {
+		return receiptExtractionNotConfigured(c)
+	}
+
+	accountIDStr := c.FormValue("account_id")
+	if accountIDStr == "" {
+		accountIDStr = c.Query("account_id")
+	}
+	var accountID *uuid.UUID
+	if accountIDStr != "" {
+		parsed, err := uuid.Parse(accountIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid account ID",
+			})
+		}
+		if _, err := GetAccountByID(c.Context(), parsed, userID); err != nil {
+			if err.Error() == "account not found" {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Account not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to extract receipt",
+			})
+		}
+		accountID = &parsed
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A file is required (multipart field \"file\")",
+		})
+	}
+	if fileHeader.Size == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File is empty",
+		})
+	}
+	if fileHeader.Size > maxAttachmentSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "File is too large (maximum 10 MB)",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+
+	return extractReceiptResponse(c, userID, data, accountID)
+}
+
+// ExtractAttachmentReceiptHandler proposes a transaction from a receipt
+// already attached to a transaction, defaulting to that transaction's account
+func ExtractAttachmentReceiptHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid attachment ID",
+		})
+	}
+
+	if receiptEngine == nil {
+		return receiptExtractionNotConfigured(c)
+	}
+	if attachmentStore == nil {
+		return attachmentsNotConfigured(c)
+	}
+
+	transaction, err := GetTransactionByID(c.Context(), transactionID, userID)
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to extract receipt",
+		})
+	}
+
+	attachment, err := GetAttachmentByID(c.Context(), attachmentID, transactionID, userID)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Attachment not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to extract receipt",
+		})
+	}
+
+	file, err := attachmentStore.Get(c.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Attachment not found",
+			})
+		}
+		log.Printf("Error reading attachment %s: %v", attachment.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to extract receipt",
+		})
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize))
+	if err != nil {
+		log.Printf("Error reading attachment %s: %v", attachment.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to extract receipt",
+		})
+	}
+
+	return extractReceiptResponse(c, userID, data, &transaction.AccountID)
+}
+
+// extractReceiptResponse runs extraction on a file and writes the proposal
+func extractReceiptResponse(c *fiber.Ctx, userID uuid.UUID, data []byte, accountID *uuid.UUID) error {
+	contentType, ok := detectAttachmentType(data)
+	if !ok {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Unsupported file type (allowed: JPEG, PNG, GIF, WebP and PDF)",
+		})
+	}
+
+	proposal, err := ExtractReceipt(c.Context(), userID, data, contentType, accountID)
+	if err != nil {
+		log.Printf("Error extracting receipt for user %s: %v", userID, err)
+		if err.Error() == "receipt extraction is busy" {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Receipt extraction is busy; try again shortly",
+			})
+		}
+		if strings.HasPrefix(err.Error(), "failed to read receipt") {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Could not read text from the receipt",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to extract receipt",
+		})
+	}
+
+	return c.JSON(proposal)
+}
+
+// receiptExtractionNotConfigured is the response when no OCR engine is installed
+func receiptExtractionNotConfigured(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Receipt extraction is not configured",
+	})
+}
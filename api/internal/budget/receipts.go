@@ -0,0 +1,254 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/ocr"
+	"github.com/google/uuid"
+)
+
+// maxConcurrentReceiptExtractions caps how many OCR runs happen at once;
+// each one can keep a CPU core busy for several seconds
+const maxConcurrentReceiptExtractions = 2
+
+// receiptSlotWait is how long an extraction waits for a free OCR slot before
+// giving up. The request context can't bound this: Fiber's c.Context() is
+// never cancelled when the client goes away.
+const receiptSlotWait = 30 * time.Second
+
+// categoryHistoryMonths is how far back past transactions are searched for a
+// receipt merchant's usual category
+const categoryHistoryMonths = 12
+
+// lowReceiptConfidence is the confidence below which a parsed field is
+// flagged for the user to check
+const lowReceiptConfidence = 0.6
+
+// receiptEngine reads text from receipts; nil until ConfigureReceiptExtraction is called
+var receiptEngine ocr.Engine
+
+// receiptSlots limits concurrent extractions
+var receiptSlots = make(chan struct{}, maxConcurrentReceiptExtractions)
+
+// ConfigureReceiptExtraction sets the OCR engine used to read receipts
+func ConfigureReceiptExtraction(engine ocr.Engine) {
+	receiptEngine = engine
+}
+
+// ReceiptProposal is a transaction pre-filled from a receipt for the user to
+// check and save. Nothing is created until they do.
+type ReceiptProposal struct {
+	Transaction CreateTransactionRequest `json:"transaction"`
+	Splits      []ProposedSplit          `json:"splits"`     // One transaction per line item, when the items add up to the total
+	Receipt     *ocr.Receipt             `json:"receipt"`    // Parsed fields with their confidence
+	Confidence  float64                  `json:"confidence"` // How much of the proposal was read reliably, 0 to 1
+	Warnings    []string                 `json:"warnings"`   // Fields the user needs to fill in or check
+}
+
+// ProposedSplit is one line item proposed as its own transaction, with its
+// share of the receipt's tax included in the amount
+type ProposedSplit struct {
+	Transaction CreateTransactionRequest `json:"transaction"`
+	Confidence  float64                  `json:"confidence"`
+}
+
+// ExtractReceipt runs OCR on a receipt image or PDF and proposes a
+// transaction from it. accountID is used when given; otherwise the user's
+// only active account is, if they have just one.
+func ExtractReceipt(ctx context.Context, userID uuid.UUID, data []byte, contentType string, accountID *uuid.UUID) (*ReceiptProposal, error) {
+	if receiptEngine == nil {
+		return nil, fmt.Errorf("receipt extraction is not configured")
+	}
+
+	select {
+	case receiptSlots <- struct{}{}:
+		defer func() { <-receiptSlots }()
+	case <-time.After(receiptSlotWait):
+		return nil, fmt.Errorf("receipt extraction is busy")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	text, err := receiptEngine.Recognize(ctx, data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	receipt := ocr.ParseReceipt(text, today)
+	return proposeTransaction(ctx, userID, receipt, accountID, today)
+}
+
+// proposeTransaction turns a parsed receipt into a transaction proposal
+func proposeTransaction(ctx context.Context, userID uuid.UUID, receipt *ocr.Receipt, accountID *uuid.UUID, today time.Time) (*ReceiptProposal, error) {
+	proposal := &ReceiptProposal{
+		Splits:     []ProposedSplit{},
+		Receipt:    receipt,
+		Confidence: receipt.Confidence,
+		Warnings:   []string{},
+	}
+
+	transaction := CreateTransactionRequest{
+		TransactionType: "expense",
+		TransactionDate: today.Format("2006-01-02"),
+	}
+
+	if accountID != nil {
+		transaction.AccountID = *accountID
+	} else {
+		accounts, err := GetAccountsByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		var active []Account
+		for _, account := range accounts {
+			if account.IsActive {
+				active = append(active, account)
+			}
+		}
+		if len(active) == 1 {
+			transaction.AccountID = active[0].ID
+		} else {
+			proposal.Warnings = append(proposal.Warnings, "Choose an account")
+		}
+	}
+
+	if receipt.Total != nil {
+		transaction.Amount = receipt.Total.Value
+	} else {
+		proposal.Warnings = append(proposal.Warnings, "Total could not be read; enter the amount")
+	}
+
+	if receipt.Date != nil {
+		transaction.TransactionDate = receipt.Date.Value
+	} else {
+		proposal.Warnings = append(proposal.Warnings, "Date could not be read; today's date was used")
+	}
+
+	if receipt.Merchant != nil {
+		description := receipt.Merchant.Value
+		transaction.Description = &description
+
+		categoryID, err := suggestCategoryForMerchant(ctx, userID, description, today)
+		if err != nil {
+			return nil, err
+		}
+		transaction.CategoryID = categoryID
+	} else {
+		proposal.Warnings = append(proposal.Warnings, "Merchant could not be read; enter a description")
+	}
+
+	if receipt.Total != nil && receipt.Total.Confidence < lowReceiptConfidence {
+		proposal.Warnings = append(proposal.Warnings, "Check the total; it was read with low confidence")
+	}
+	if receipt.Date != nil && receipt.Date.Confidence < lowReceiptConfidence {
+		proposal.Warnings = append(proposal.Warnings, "Check the date; it was read with low confidence")
+	}
+	if receipt.Merchant != nil && receipt.Merchant.Confidence < lowReceiptConfidence {
+		proposal.Warnings = append(proposal.Warnings, "Check the merchant; it was read with low confidence")
+	}
+
+	proposal.Transaction = transaction
+
+	splits, warning := proposeSplits(transaction, receipt)
+	proposal.Splits = splits
+	if warning != "" {
+		proposal.Warnings = append(proposal.Warnings, warning)
+	}
+
+	return proposal, nil
+}
+
+// proposeSplits proposes one transaction per line item when the items can be
+// reconciled with the total, either directly or once tax is shared between
+// them in proportion to their amounts
+func proposeSplits(transaction CreateTransactionRequest, receipt *ocr.Receipt) ([]ProposedSplit, string) {
+	splits := []ProposedSplit{}
+	if len(receipt.LineItems) < 2 || receipt.Total == nil {
+		return splits, ""
+	}
+
+	itemsTotal := 0.0
+	for _, item := range receipt.LineItems {
+		itemsTotal += item.Amount
+	}
+	itemsTotal = math.Round(itemsTotal*100) / 100
+	total := receipt.Total.Value
+
+	if itemsTotal <= 0 {
+		return splits, ""
+	}
+	if math.Abs(itemsTotal-total) > 0.011 {
+		// Items that add up to the subtotal are still usable: the difference
+		// is tax, so it's shared out
+		if receipt.Subtotal == nil || math.Abs(itemsTotal-receipt.Subtotal.Value) > 0.011 {
+			return splits, "Line items don't add up to the total, so no split was proposed"
+		}
+	}
+
+	allocated := 0.0
+	for i, item := range receipt.LineItems {
+		amount := math.Round(item.Amount*total/itemsTotal*100) / 100
+		if i == len(receipt.LineItems)-1 {
+			amount = math.Round((total-allocated)*100) / 100 // Rounding differences go on the last item
+		}
+		allocated += amount
+
+		split := transaction
+		split.Amount = amount
+		description := item.Description
+		if transaction.Description != nil {
+			description = *transaction.Description + ": " + item.Description
+		}
+		split.Description = &description
+
+		splits = append(splits, ProposedSplit{Transaction: split, Confidence: item.Confidence})
+	}
+
+	return splits, ""
+}
+
+// suggestCategoryForMerchant returns the category the user most often gave
+// past expenses from the same merchant, or nil if there are none
+func suggestCategoryForMerchant(ctx context.Context, userID uuid.UUID, merchant string, today time.Time) (*uuid.UUID, error) {
+	key := merchantKey(merchant)
+	if key == "" {
+		return nil, nil
+	}
+
+	startDate := today.AddDate(0, -categoryHistoryMonths, 0).Format("2006-01-02")
+	endDate := today.Format("2006-01-02")
+	transactions, err := GetTransactionsByUserID(ctx, userID, nil, nil, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int)
+	for _, t := range transactions {
+		if t.TransactionType != "expense" || t.CategoryID == nil || t.Description == nil {
+			continue
+		}
+		if merchantKey(*t.Description) == key {
+			counts[*t.CategoryID]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+
+	categoryIDs := make([]uuid.UUID, 0, len(counts))
+	for id := range counts {
+		categoryIDs = append(categoryIDs, id)
+	}
+	sort.Slice(categoryIDs, func(i, j int) bool {
+		if counts[categoryIDs[i]] != counts[categoryIDs[j]] {
+			return counts[categoryIDs[i]] > counts[categoryIDs[j]]
+		}
+		return categoryIDs[i].String() < categoryIDs[j].String()
+	})
+	return &categoryIDs[0], nil
+}
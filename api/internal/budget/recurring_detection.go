@@ -0,0 +1,410 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// recurringCandidateNamespace seeds deterministic candidate IDs so a candidate
+// can be referenced again after the list is re-detected
+var recurringCandidateNamespace = uuid.MustParse("6f1c7a52-3f7e-4d1b-9a55-0c2f1d8e4b17")
+
+// recurringCadence describes one repeat interval the detector looks for
+type recurringCadence struct {
+	Frequency string
+	Days      float64 // Typical interval
+	Tolerance float64 // Allowed deviation per interval, in days
+	MinCount  int     // Occurrences needed before a series is proposed
+}
+
+// recurringCadences are checked in order against a series' median interval
+var recurringCadences = []recurringCadence{
+	{Frequency: "weekly", Days: 7, Tolerance: 1, MinCount: 4},
+	{Frequency: "fortnightly", Days: 14, Tolerance: 2, MinCount: 3},
+	{Frequency: "monthly", Days: 30.44, Tolerance: 4, MinCount: 3},
+	{Frequency: "annually", Days: 365.25, Tolerance: 10, MinCount: 2},
+}
+
+// recurringAmountSpread is how far (as a fraction of the typical amount) a
+// charge may differ and still belong to the same series
+const recurringAmountSpread = 0.2
+
+// minRecurringRegularity is the share of intervals that must fit the cadence
+const minRecurringRegularity = 0.75
+
+// RecurringCandidate is a repeating charge or payment that may deserve a budget entry
+type RecurringCandidate struct {
+	ID               uuid.UUID                `json:"id"`
+	Merchant         string                   `json:"merchant"`
+	EntryType        string                   `json:"entry_type"` // 'income' or 'expense'
+	Frequency        string                   `json:"frequency"`
+	TypicalAmount    float64                  `json:"typical_amount"`
+	MinAmount        float64                  `json:"min_amount"`
+	MaxAmount        float64                  `json:"max_amount"`
+	Occurrences      int                      `json:"occurrences"`
+	FirstDate        string                   `json:"first_date"`
+	LastDate         string                   `json:"last_date"`
+	NextExpectedDate string                   `json:"next_expected_date"`
+	Confidence       float64                  `json:"confidence"` // 0-100
+	CategoryID       *uuid.UUID               `json:"category_id,omitempty"`
+	ExistingEntryID  *uuid.UUID               `json:"existing_entry_id,omitempty"` // Set when a budget entry already covers this series
+	TransactionIDs   []uuid.UUID              `json:"transaction_ids"`
+	SuggestedEntry   CreateBudgetEntryRequest `json:"suggested_entry"`
+}
+
+// DetectRecurringCandidates scans the last lookbackMonths of transactions for
+// merchants charging (or paying) similar amounts at a steady cadence. Series
+// that have stopped, or that an active budget entry already covers, are left
+// out unless includeCovered is set.
+func DetectRecurringCandidates(ctx context.Context, userID uuid.UUID, lookbackMonths int, includeCovered bool) ([]RecurringCandidate, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := today.AddDate(0, -lookbackMonths, 0).Format("2006-01-02")
+	endDate := today.Format("2006-01-02")
+
+	transactions, err := GetTransactionsByUserID(ctx, userID, nil, nil, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []BudgetEntry
+	activeBudget, err := GetActiveBudget(ctx, userID)
+	if err == nil && activeBudget != nil {
+		entries, err = GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get budget entries: %w", err)
+		}
+	}
+
+	// Group by transaction type and merchant
+	groups := make(map[string][]Transaction)
+	var groupKeys []string
+	for _, t := range transactions {
+		if t.Description == nil {
+			continue
+		}
+		merchant := merchantKey(*t.Description)
+		if merchant == "" {
+			continue
+		}
+		key := t.TransactionType + "|" + merchant
+		if _, exists := groups[key]; !exists {
+			groupKeys = append(groupKeys, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+	sort.Strings(groupKeys)
+
+	candidates := []RecurringCandidate{}
+	for _, key := range groupKeys {
+		parts := strings.SplitN(key, "|", 2)
+		seriesPerFrequency := make(map[string]int)
+		for _, series := range clusterByAmount(groups[key]) {
+			candidate, ok := recurringCandidateFromSeries(parts[1], parts[0], series, today)
+			if !ok {
+				continue
+			}
+
+			// Clusters come cheapest first, so a merchant's second series at
+			// the same frequency is numbered by its place in price order
+			candidate.ID = recurringCandidateID(candidate.EntryType, candidate.Merchant, candidate.Frequency, seriesPerFrequency[candidate.Frequency])
+			seriesPerFrequency[candidate.Frequency]++
+
+			candidate.ExistingEntryID = coveringEntry(candidate, series, entries)
+			if candidate.ExistingEntryID != nil && !includeCovered {
+				continue
+			}
+
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Merchant < candidates[j].Merchant
+	})
+
+	return candidates, nil
+}
+
+// clusterByAmount splits a merchant's transactions into series of similar
+// amounts, so two subscriptions from the same merchant are detected separately
+func clusterByAmount(transactions []Transaction) [][]Transaction {
+	sorted := append([]Transaction(nil), transactions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount < sorted[j].Amount })
+
+	var clusters [][]Transaction
+	for _, t := range sorted {
+		n := len(clusters)
+		if n > 0 {
+			last := clusters[n-1]
+			anchor := last[0].Amount
+			if t.Amount <= anchor*(1+recurringAmountSpread)+0.01 {
+				clusters[n-1] = append(last, t)
+				continue
+			}
+		}
+		clusters = append(clusters, []Transaction{t})
+	}
+
+	for _, cluster := range clusters {
+		sort.Slice(cluster, func(i, j int) bool { return cluster[i].TransactionDate < cluster[j].TransactionDate })
+	}
+
+	return clusters
+}
+
+// recurringCandidateFromSeries checks whether a series of transactions repeats
+// at a known cadence and, if so, describes it as a candidate
+func recurringCandidateFromSeries(merchant, entryType string, series []Transaction, today time.Time) (RecurringCandidate, bool) {
+	if len(series) < 2 {
+		return RecurringCandidate{}, false
+	}
+
+	dates := make([]time.Time, 0, len(series))
+	for _, t := range series {
+		date, err := time.Parse("2006-01-02", t.TransactionDate)
+		if err != nil {
+			return RecurringCandidate{}, false
+		}
+		// Two charges on the same day are one occurrence for cadence purposes
+		if len(dates) > 0 && date.Equal(dates[len(dates)-1]) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	if len(dates) < 2 {
+		return RecurringCandidate{}, false
+	}
+
+	intervals := make([]float64, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		intervals[i-1] = dates[i].Sub(dates[i-1]).Hours() / 24
+	}
+	medianInterval := medianOf(intervals)
+
+	var cadence *recurringCadence
+	for i := range recurringCadences {
+		c := recurringCadences[i]
+		if math.Abs(medianInterval-c.Days) <= c.Tolerance {
+			cadence = &c
+			break
+		}
+	}
+	if cadence == nil || len(dates) < cadence.MinCount {
+		return RecurringCandidate{}, false
+	}
+
+	regular := 0
+	for _, interval := range intervals {
+		if math.Abs(interval-cadence.Days) <= cadence.Tolerance {
+			regular++
+		}
+	}
+	regularity := float64(regular) / float64(len(intervals))
+	if regularity < minRecurringRegularity {
+		return RecurringCandidate{}, false
+	}
+
+	last := dates[len(dates)-1]
+	next := nextRecurringDate(cadence.Frequency, dates)
+
+	// A series that has missed more than one expected charge has probably ended
+	if today.Sub(next).Hours()/24 > cadence.Days+cadence.Tolerance {
+		return RecurringCandidate{}, false
+	}
+	for next.Before(today) {
+		next = advanceRecurringDate(cadence.Frequency, next, dates)
+	}
+
+	amounts := make([]float64, len(series))
+	categoryCounts := make(map[uuid.UUID]int)
+	transactionIDs := make([]uuid.UUID, len(series))
+	for i, t := range series {
+		amounts[i] = t.Amount
+		transactionIDs[i] = t.ID
+		if t.CategoryID != nil {
+			categoryCounts[*t.CategoryID]++
+		}
+	}
+	typical := math.Round(medianOf(amounts)*100) / 100
+	minAmount, maxAmount := amounts[0], amounts[0]
+	for _, amount := range amounts {
+		minAmount = math.Min(minAmount, amount)
+		maxAmount = math.Max(maxAmount, amount)
+	}
+
+	// Confidence grows with regularity, amount stability and number of occurrences
+	amountStability := 1.0
+	if typical > 0 {
+		amountStability = 1 - math.Min((maxAmount-minAmount)/typical, 1)*0.5
+	}
+	history := math.Min(float64(len(dates))/float64(cadence.MinCount+2), 1)
+	confidence := math.Round(regularity*amountStability*(0.6+0.4*history)*1000) / 10
+
+	var categoryID *uuid.UUID
+	bestCount := 0
+	for id, count := range categoryCounts {
+		if count > bestCount || (count == bestCount && categoryID != nil && id.String() < categoryID.String()) {
+			id := id
+			categoryID = &id
+			bestCount = count
+		}
+	}
+
+	candidate := RecurringCandidate{
+		Merchant:         merchant,
+		EntryType:        entryType,
+		Frequency:        cadence.Frequency,
+		TypicalAmount:    typical,
+		MinAmount:        minAmount,
+		MaxAmount:        maxAmount,
+		Occurrences:      len(dates),
+		FirstDate:        dates[0].Format("2006-01-02"),
+		LastDate:         last.Format("2006-01-02"),
+		NextExpectedDate: next.Format("2006-01-02"),
+		Confidence:       confidence,
+		CategoryID:       categoryID,
+		TransactionIDs:   transactionIDs,
+	}
+	candidate.SuggestedEntry = suggestedEntryForCandidate(candidate, amounts, dates)
+
+	return candidate, true
+}
+
+// nextRecurringDate returns the date after the last occurrence in a series
+func nextRecurringDate(frequency string, dates []time.Time) time.Time {
+	return advanceRecurringDate(frequency, dates[len(dates)-1], dates)
+}
+
+// advanceRecurringDate moves one cadence step forward from date. Monthly and
+// annual series keep their usual day of month rather than drifting.
+func advanceRecurringDate(frequency string, date time.Time, dates []time.Time) time.Time {
+	switch frequency {
+	case "weekly":
+		return date.AddDate(0, 0, 7)
+	case "fortnightly":
+		return date.AddDate(0, 0, 14)
+	case "monthly":
+		day := typicalDayOfMonth(dates)
+		next := time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		lastDay := next.AddDate(0, 1, -1).Day()
+		if day > lastDay {
+			day = lastDay
+		}
+		return time.Date(next.Year(), next.Month(), day, 0, 0, 0, 0, time.UTC)
+	default:
+		return date.AddDate(1, 0, 0)
+	}
+}
+
+// typicalDayOfMonth returns the most common day of month in a series
+func typicalDayOfMonth(dates []time.Time) int {
+	days := make([]float64, len(dates))
+	for i, date := range dates {
+		days[i] = float64(date.Day())
+	}
+	return int(medianOf(days))
+}
+
+// suggestedEntryForCandidate pre-fills a budget entry for a candidate, with
+// matching rules learned from the series' descriptions and amounts
+func suggestedEntryForCandidate(candidate RecurringCandidate, amounts []float64, dates []time.Time) CreateBudgetEntryRequest {
+	req := CreateBudgetEntryRequest{
+		CategoryID: candidate.CategoryID,
+		Name:       merchantDisplayName(candidate.Merchant),
+		Amount:     candidate.TypicalAmount,
+		EntryType:  candidate.EntryType,
+		Frequency:  candidate.Frequency,
+		StartDate:  candidate.NextExpectedDate,
+		MatchingRules: map[string]interface{}{
+			"description_contains": []string{candidate.Merchant},
+			"amount_tolerance":     deriveAmountTolerance(candidate.TypicalAmount, amounts),
+		},
+	}
+
+	switch candidate.Frequency {
+	case "weekly", "fortnightly":
+		next, _ := time.Parse("2006-01-02", candidate.NextExpectedDate)
+		dayOfWeek := int(next.Weekday())
+		req.DayOfWeek = &dayOfWeek
+	case "monthly":
+		day := typicalDayOfMonth(dates)
+		req.DayOfMonth = &day
+	}
+
+	return req
+}
+
+// recurringCandidateID identifies a series by its type, merchant and
+// frequency, none of which change as charges arrive or age out of the
+// lookback window. ordinal tells apart a merchant's series at the same
+// frequency, such as two subscription tiers.
+func recurringCandidateID(entryType, merchant, frequency string, ordinal int) uuid.UUID {
+	key := fmt.Sprintf("%s|%s|%s", entryType, merchant, frequency)
+	if ordinal > 0 {
+		key += fmt.Sprintf("|%d", ordinal)
+	}
+	return uuid.NewSHA1(recurringCandidateNamespace, []byte(key))
+}
+
+// merchantDisplayName turns a merchant key into a readable entry name
+func merchantDisplayName(merchant string) string {
+	words := strings.Fields(merchant)
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(first)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// coveringEntry returns the active budget entry that already accounts for a
+// series: either most of its transactions are linked to that entry, or the
+// entry's description rules match the merchant with a similar amount
+func coveringEntry(candidate RecurringCandidate, series []Transaction, entries []BudgetEntry) *uuid.UUID {
+	linkCounts := make(map[uuid.UUID]int)
+	for _, t := range series {
+		if t.BudgetEntryID != nil {
+			linkCounts[*t.BudgetEntryID]++
+		}
+	}
+	for id, count := range linkCounts {
+		if count*2 >= len(series) {
+			id := id
+			return &id
+		}
+	}
+
+	for _, entry := range entries {
+		if !entry.IsActive || entry.EntryType != candidate.EntryType {
+			continue
+		}
+		if math.Abs(entry.Amount-candidate.TypicalAmount) > candidate.TypicalAmount*recurringAmountSpread+0.01 {
+			continue
+		}
+
+		patterns, _ := entry.MatchingRules["description_contains"].([]interface{})
+		for _, p := range patterns {
+			pattern, ok := p.(string)
+			if ok && pattern != "" && strings.Contains(candidate.Merchant, normalizeDescription(pattern)) {
+				id := entry.ID
+				return &id
+			}
+		}
+		if strings.Contains(normalizeDescription(entry.Name), candidate.Merchant) {
+			id := entry.ID
+			return &id
+		}
+	}
+
+	return nil
+}
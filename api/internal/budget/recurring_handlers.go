@@ -0,0 +1,149 @@
+package budget
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CreateEntryFromCandidateRequest optionally overrides the suggested entry's fields
+type CreateEntryFromCandidateRequest struct {
+	BudgetID   *uuid.UUID `json:"budget_id,omitempty"` // Defaults to the active budget
+	Name       *string    `json:"name,omitempty"`
+	Amount     *float64   `json:"amount,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	StartDate  *string    `json:"start_date,omitempty"`
+}
+
+// GetRecurringCandidatesHandler returns repeating charges that may need a budget entry
+func GetRecurringCandidatesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	lookbackMonths := c.QueryInt("lookback_months", 18)
+	if lookbackMonths < 1 || lookbackMonths > 60 {
+		lookbackMonths = 18
+	}
+
+	candidates, err := DetectRecurringCandidates(c.Context(), userID, lookbackMonths, c.QueryBool("include_covered", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to detect recurring charges",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"candidates": candidates,
+	})
+}
+
+// CreateEntryFromCandidateHandler creates a budget entry, with matching rules,
+// from a detected recurring series
+func CreateEntryFromCandidateHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	candidateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid candidate ID",
+		})
+	}
+
+	var req CreateEntryFromCandidateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	// Re-detect rather than trusting client-supplied series details
+	lookbackMonths := c.QueryInt("lookback_months", 18)
+	if lookbackMonths < 1 || lookbackMonths > 60 {
+		lookbackMonths = 18
+	}
+	candidates, err := DetectRecurringCandidates(c.Context(), userID, lookbackMonths, true)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to detect recurring charges",
+		})
+	}
+
+	var candidate *RecurringCandidate
+	for i := range candidates {
+		if candidates[i].ID == candidateID {
+			candidate = &candidates[i]
+			break
+		}
+	}
+	if candidate == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Recurring candidate not found",
+		})
+	}
+	if candidate.ExistingEntryID != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":             "A budget entry already covers this recurring charge",
+			"existing_entry_id": candidate.ExistingEntryID,
+		})
+	}
+
+	budgetID := req.BudgetID
+	if budgetID == nil {
+		activeBudget, err := GetActiveBudget(c.Context(), userID)
+		if err != nil || activeBudget == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "No active budget; specify budget_id",
+			})
+		}
+		budgetID = &activeBudget.ID
+	}
+
+	entryReq := candidate.SuggestedEntry
+	if req.Name != nil && *req.Name != "" {
+		entryReq.Name = *req.Name
+	}
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Amount must be greater than zero",
+			})
+		}
+		entryReq.Amount = *req.Amount
+	}
+	if req.CategoryID != nil {
+		entryReq.CategoryID = req.CategoryID
+	}
+	if req.StartDate != nil && *req.StartDate != "" {
+		entryReq.StartDate = *req.StartDate
+	}
+
+	entry, err := CreateBudgetEntry(c.Context(), *budgetID, userID, entryReq)
+	if err != nil {
+		if err.Error() == "budget not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Budget not found",
+			})
+		}
+		log.Printf("Error creating budget entry from recurring candidate %s: %v", candidateID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create budget entry",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"entry":     entry,
+		"candidate": candidate,
+	})
+}
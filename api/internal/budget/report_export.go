@@ -0,0 +1,374 @@
+package budget
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/auth"
+	"github.com/brendenbissett/help-me-budget/api/internal/export"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// exportFormat reads ?format= for handlers that can return a file. An empty
+// format (or "json") means the usual JSON response; ok is false for
+// unsupported formats.
+func exportFormat(c *fiber.Ctx) (format string, ok bool) {
+	format = strings.ToLower(c.Query("format"))
+	if format == "" || format == "json" {
+		return "", true
+	}
+	return format, export.IsSupported(format)
+}
+
+// invalidExportFormat is the response for an unsupported ?format=
+func invalidExportFormat(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": "Invalid format (use json, csv, xlsx or pdf)",
+	})
+}
+
+// sendExport adds the user and generation time to the report's metadata and
+// sends it as a file download named after the report and today's date
+func sendExport(c *fiber.Ctx, userID uuid.UUID, format, name string, report *export.Report) error {
+	now := time.Now()
+
+	metadata := []export.Field{}
+	if user, err := auth.GetUserByID(c.Context(), userID); err == nil {
+		label := user.Email
+		if user.Name != "" {
+			label = fmt.Sprintf("%s <%s>", user.Name, user.Email)
+		}
+		metadata = append(metadata, export.Field{Label: "User", Value: label})
+	}
+	metadata = append(metadata, report.Metadata...)
+	metadata = append(metadata, export.Field{Label: "Generated", Value: now.Format("2006-01-02 15:04 MST")})
+	report.Metadata = metadata
+
+	if report.Currency == "" {
+		report.Currency = userCurrency(c.Context(), userID, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, report); err != nil {
+		log.Printf("Error exporting %s as %s for user %s: %v", name, format, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export report",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, now.Format("2006-01-02"), format))
+	return c.Send(buf.Bytes())
+}
+
+// userCurrency returns the currency of the given account, or of the user's
+// first active account, defaulting to USD
+func userCurrency(ctx context.Context, userID uuid.UUID, accountID *uuid.UUID) string {
+	accounts, err := GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return "USD"
+	}
+	return accountsCurrency(accounts, accountID)
+}
+
+func accountsCurrency(accounts []Account, accountID *uuid.UUID) string {
+	for _, account := range accounts {
+		if accountID != nil && account.ID == *accountID && account.Currency != "" {
+			return account.Currency
+		}
+	}
+	for _, account := range accounts {
+		if account.IsActive && account.Currency != "" {
+			return account.Currency
+		}
+	}
+	return "USD"
+}
+
+// dateRangeField describes a report's date range for the metadata block
+func dateRangeField(startDate, endDate string) export.Field {
+	switch {
+	case startDate == "" && endDate == "":
+		return export.Field{Label: "Date range", Value: "All dates"}
+	case startDate == "":
+		return export.Field{Label: "Date range", Value: "Up to " + endDate}
+	case endDate == "":
+		return export.Field{Label: "Date range", Value: "From " + startDate}
+	}
+	return export.Field{Label: "Date range", Value: startDate + " to " + endDate}
+}
+
+// ============================================================================
+// Report Tables
+// ============================================================================
+
+func spendingTrendsExport(trends []SpendingTrend, startDate, endDate string) *export.Report {
+	report := &export.Report{
+		Title:    "Spending Trends",
+		Metadata: []export.Field{dateRangeField(startDate, endDate)},
+		Columns: []export.Column{
+			{Header: "Month", Type: export.Text},
+			{Header: "Category", Type: export.Text},
+			{Header: "Amount", Type: export.Currency},
+		},
+	}
+
+	total := 0.0
+	for _, trend := range trends {
+		report.Rows = append(report.Rows, []interface{}{trend.Month, trend.Category, trend.Amount})
+		total += trend.Amount
+	}
+	report.Totals = []interface{}{"Total", nil, total}
+
+	return report
+}
+
+func budgetVarianceExport(variance *BudgetVarianceReport) *export.Report {
+	report := &export.Report{
+		Title: "Budget Variance",
+		Metadata: []export.Field{
+			{Label: "Period", Value: variance.Period.Type},
+			dateRangeField(variance.Period.StartDate, variance.Period.EndDate),
+			{Label: "Year to date", Value: variance.YTDStartDate + " to " + variance.YTDEndDate},
+		},
+		Columns: []export.Column{
+			{Header: "Entry", Type: export.Text},
+			{Header: "Type", Type: export.Text},
+			{Header: "Category", Type: export.Text},
+			{Header: "Occurrences", Type: export.Number},
+			{Header: "Budgeted", Type: export.Currency},
+			{Header: "Actual", Type: export.Currency},
+			{Header: "Variance", Type: export.Currency},
+			{Header: "Variance %", Type: export.Percent},
+			{Header: "YTD Budgeted", Type: export.Currency},
+			{Header: "YTD Actual", Type: export.Currency},
+			{Header: "YTD Variance", Type: export.Currency},
+		},
+	}
+
+	for _, entry := range variance.Entries {
+		report.Rows = append(report.Rows, []interface{}{
+			entry.EntryName, entry.EntryType, entry.Category, entry.Occurrences,
+			entry.Budgeted, entry.Actual, entry.Variance, entry.VariancePct,
+			entry.YTDBudgeted, entry.YTDActual, entry.YTDVariance,
+		})
+	}
+	report.Totals = []interface{}{
+		"Total", nil, nil, nil,
+		variance.Budgeted, variance.Actual, variance.Variance, nil,
+		variance.YTDBudgeted, variance.YTDActual, variance.YTDVariance,
+	}
+
+	return report
+}
+
+func topExpensesExport(expenses []TopExpense, startDate, endDate string) *export.Report {
+	report := &export.Report{
+		Title:    "Top Expenses",
+		Metadata: []export.Field{dateRangeField(startDate, endDate)},
+		Columns:  topExpenseColumns(),
+	}
+
+	count, total, percentage := 0, 0.0, 0.0
+	for _, expense := range expenses {
+		report.Rows = append(report.Rows, []interface{}{expense.CategoryName, expense.Count, expense.TotalAmount, expense.Percentage})
+		count += expense.Count
+		total += expense.TotalAmount
+		percentage += expense.Percentage
+	}
+	report.Totals = []interface{}{"Total", count, total, percentage}
+
+	return report
+}
+
+// expenseRollupExport flattens the category tree, indenting subcategories
+// under their parents. Totals only count top-level rows since parents
+// already include their descendants.
+func expenseRollupExport(rollup []CategoryRollup, startDate, endDate string) *export.Report {
+	report := &export.Report{
+		Title:    "Top Expenses",
+		Metadata: []export.Field{dateRangeField(startDate, endDate)},
+		Columns:  topExpenseColumns(),
+	}
+
+	var addRows func(nodes []CategoryRollup, level int)
+	addRows = func(nodes []CategoryRollup, level int) {
+		for _, node := range nodes {
+			name := strings.Repeat("  ", level) + node.CategoryName
+			report.Rows = append(report.Rows, []interface{}{name, node.Count, node.TotalAmount, node.Percentage})
+			addRows(node.Children, level+1)
+		}
+	}
+	addRows(rollup, 0)
+
+	count, total, percentage := 0, 0.0, 0.0
+	for _, node := range rollup {
+		count += node.Count
+		total += node.TotalAmount
+		percentage += node.Percentage
+	}
+	report.Totals = []interface{}{"Total", count, total, percentage}
+
+	return report
+}
+
+func topExpenseColumns() []export.Column {
+	return []export.Column{
+		{Header: "Category", Type: export.Text},
+		{Header: "Transactions", Type: export.Number},
+		{Header: "Amount", Type: export.Currency},
+		{Header: "% of Total", Type: export.Percent},
+	}
+}
+
+func cashFlowProjectionExport(projection *CashFlowProjectionReport, currency string) *export.Report {
+	report := &export.Report{
+		Title:    "Cash Flow Projection",
+		Currency: currency,
+		Columns: []export.Column{
+			{Header: "Date", Type: export.Text},
+			{Header: "Income", Type: export.Currency},
+			{Header: "Expenses", Type: export.Currency},
+			{Header: "Balance", Type: export.Currency},
+		},
+	}
+
+	income, expenses := 0.0, 0.0
+	for _, day := range projection.Projections {
+		report.Rows = append(report.Rows, []interface{}{day.Date, day.ProjectedIncome, day.ProjectedExpenses, day.ProjectedBalance})
+		income += day.ProjectedIncome
+		expenses += day.ProjectedExpenses
+	}
+
+	endingBalance := projection.StartingBalance
+	if n := len(projection.Projections); n > 0 {
+		endingBalance = projection.Projections[n-1].ProjectedBalance
+		report.Metadata = append(report.Metadata, dateRangeField(projection.Projections[0].Date, projection.Projections[n-1].Date))
+	}
+	report.Metadata = append(report.Metadata, projectionFields(projection.StartingBalance, projection.BalanceSource, projection.LowestBalance, projection.LowestBalanceDate, len(projection.Overdue), currency)...)
+	report.Totals = []interface{}{"Total", income, expenses, endingBalance}
+
+	return report
+}
+
+func budgetCashFlowExport(budget *Budget, projection *CashFlowProjection, currency string) *export.Report {
+	report := &export.Report{
+		Title:    "Cash Flow Projection",
+		Currency: currency,
+		Metadata: append([]export.Field{
+			{Label: "Budget", Value: budget.Name},
+			dateRangeField(projection.StartDate, projection.EndDate),
+		}, projectionFields(projection.StartingBalance, projection.BalanceSource, projection.LowestBalance, projection.LowestBalanceDate, len(projection.Overdue), currency)...),
+		Columns: []export.Column{
+			{Header: "Date", Type: export.Text},
+			{Header: "Income", Type: export.Currency},
+			{Header: "Expenses", Type: export.Currency},
+			{Header: "Net", Type: export.Currency},
+			{Header: "Balance", Type: export.Currency},
+		},
+	}
+
+	for _, day := range projection.DailyProjections {
+		report.Rows = append(report.Rows, []interface{}{day.Date, day.DailyIncome, day.DailyExpenses, day.DailyNet, day.Balance})
+	}
+	report.Totals = []interface{}{"Total", projection.TotalIncome, projection.TotalExpenses, projection.NetCashFlow, projection.EndingBalance}
+
+	return report
+}
+
+// projectionFields describes where a projection started and how low it goes
+func projectionFields(startingBalance float64, balanceSource string, lowestBalance float64, lowestBalanceDate string, overdue int, currency string) []export.Field {
+	return []export.Field{
+		{Label: "Starting balance", Value: fmt.Sprintf("%s (%s)", export.FormatCurrency(startingBalance, currency), balanceSource)},
+		{Label: "Lowest balance", Value: fmt.Sprintf("%s on %s", export.FormatCurrency(lowestBalance, currency), lowestBalanceDate)},
+		{Label: "Overdue items", Value: fmt.Sprintf("%d", overdue)},
+	}
+}
+
+// transactionsExport lists transactions with income and expenses in separate
+// columns so each can be totalled
+func transactionsExport(ctx context.Context, userID uuid.UUID, transactions []Transaction, accountID *uuid.UUID, startDate, endDate *string) (*export.Report, error) {
+	accounts, err := GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := GetCategoriesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountNames := make(map[uuid.UUID]string, len(accounts))
+	for _, account := range accounts {
+		accountNames[account.ID] = account.Name
+	}
+	categoryNames := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	start, end := "", ""
+	if startDate != nil {
+		start = *startDate
+	}
+	if endDate != nil {
+		end = *endDate
+	}
+
+	report := &export.Report{
+		Title:    "Transactions",
+		Currency: accountsCurrency(accounts, accountID),
+		Metadata: []export.Field{dateRangeField(start, end)},
+		Columns: []export.Column{
+			{Header: "Date", Type: export.Text},
+			{Header: "Description", Type: export.Text},
+			{Header: "Account", Type: export.Text},
+			{Header: "Category", Type: export.Text},
+			{Header: "Tags", Type: export.Text},
+			{Header: "Income", Type: export.Currency},
+			{Header: "Expense", Type: export.Currency},
+			{Header: "Match", Type: export.Text},
+		},
+	}
+	if accountID != nil {
+		report.Metadata = append(report.Metadata, export.Field{Label: "Account", Value: accountNames[*accountID]})
+	}
+
+	income, expenses := 0.0, 0.0
+	for _, transaction := range transactions {
+		description := ""
+		if transaction.Description != nil {
+			description = *transaction.Description
+		}
+		category := "Uncategorized"
+		if transaction.CategoryID != nil {
+			category = categoryNames[*transaction.CategoryID]
+		}
+
+		tagNames := make([]string, len(transaction.Tags))
+		for i, tag := range transaction.Tags {
+			tagNames[i] = tag.Name
+		}
+
+		var incomeCell, expenseCell interface{}
+		if transaction.TransactionType == "income" {
+			incomeCell = transaction.Amount
+			income += transaction.Amount
+		} else {
+			expenseCell = transaction.Amount
+			expenses += transaction.Amount
+		}
+
+		report.Rows = append(report.Rows, []interface{}{
+			transaction.TransactionDate, description, accountNames[transaction.AccountID], category,
+			strings.Join(tagNames, ", "), incomeCell, expenseCell, transaction.MatchConfidence,
+		})
+	}
+	report.Totals = []interface{}{fmt.Sprintf("Total (%d)", len(transactions)), nil, nil, nil, nil, income, expenses, nil}
+
+	return report, nil
+}
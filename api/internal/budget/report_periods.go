@@ -0,0 +1,143 @@
+package budget
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReportPeriod is the date window a report covers
+type ReportPeriod struct {
+	Type      string `json:"type"`       // 'week', 'month', 'quarter', 'year', 'custom'
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD
+}
+
+// Start returns the first day of the period
+func (p ReportPeriod) Start() time.Time {
+	t, _ := time.Parse("2006-01-02", p.StartDate)
+	return t
+}
+
+// End returns the last day of the period
+func (p ReportPeriod) End() time.Time {
+	t, _ := time.Parse("2006-01-02", p.EndDate)
+	return t
+}
+
+// resolveReportPeriod builds the period of the given type that contains anchor.
+// Weeks run Monday to Sunday and quarters follow the calendar year. Custom
+// periods use startDate and endDate (YYYY-MM-DD) as given.
+func resolveReportPeriod(periodType string, anchor time.Time, startDate, endDate string) (ReportPeriod, error) {
+	anchor = time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, time.UTC)
+
+	var start, end time.Time
+	switch periodType {
+	case "week":
+		offset := (int(anchor.Weekday()) + 6) % 7 // Days since Monday
+		start = anchor.AddDate(0, 0, -offset)
+		end = start.AddDate(0, 0, 6)
+
+	case "month":
+		start = time.Date(anchor.Year(), anchor.Month(), 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 1, -1)
+
+	case "quarter":
+		firstMonth := time.Month((int(anchor.Month())-1)/3*3 + 1)
+		start = time.Date(anchor.Year(), firstMonth, 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 3, -1)
+
+	case "year":
+		start = time.Date(anchor.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(1, 0, -1)
+
+	case "custom":
+		var err error
+		start, err = time.Parse("2006-01-02", startDate)
+		if err != nil {
+			return ReportPeriod{}, fmt.Errorf("invalid start_date format")
+		}
+		end, err = time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return ReportPeriod{}, fmt.Errorf("invalid end_date format")
+		}
+		if end.Before(start) {
+			return ReportPeriod{}, fmt.Errorf("end_date must not be before start_date")
+		}
+
+	default:
+		return ReportPeriod{}, fmt.Errorf("invalid period: must be week, month, quarter, year or custom")
+	}
+
+	return ReportPeriod{
+		Type:      periodType,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	}, nil
+}
+
+// entryOccursOnDate reports whether an entry falls due on date. Unlike
+// shouldEntryOccurOnDate, a monthly entry on the 29th-31st still falls due on
+// the last day of shorter months, and an annual Feb 29 entry falls due on
+// Feb 28 in non-leap years.
+func entryOccursOnDate(entry BudgetEntry, date time.Time) bool {
+	if shouldEntryOccurOnDate(entry, date) {
+		return true
+	}
+
+	startDate, err := time.Parse("2006-01-02", entry.StartDate)
+	if err != nil || date.Before(startDate) {
+		return false
+	}
+	if entry.EndDate != nil {
+		endDate, err := time.Parse("2006-01-02", *entry.EndDate)
+		if err == nil && date.After(endDate) {
+			return false
+		}
+	}
+
+	lastDay := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if date.Day() != lastDay {
+		return false
+	}
+
+	switch entry.Frequency {
+	case "monthly":
+		day := startDate.Day()
+		if entry.DayOfMonth != nil {
+			day = *entry.DayOfMonth
+		}
+		return day > lastDay
+
+	case "annually":
+		return startDate.Month() == time.February && startDate.Day() == 29 && date.Month() == time.February
+
+	default:
+		return false
+	}
+}
+
+// countOccurrences counts how many times an entry falls due within [start, end]
+func countOccurrences(entry BudgetEntry, start, end time.Time) int {
+	entryStart, err := time.Parse("2006-01-02", entry.StartDate)
+	if err != nil {
+		return 0
+	}
+	if start.Before(entryStart) {
+		start = entryStart
+	}
+	if entry.EndDate != nil {
+		entryEnd, err := time.Parse("2006-01-02", *entry.EndDate)
+		if err == nil && end.After(entryEnd) {
+			end = entryEnd
+		}
+	}
+
+	count := 0
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if entryOccursOnDate(entry, date) {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,446 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// minBaselineSamples is how many past observations a baseline needs before it's trusted
+const minBaselineSamples = 4
+
+// defaultAnomalyThreshold is the robust z-score beyond which a value is flagged
+const defaultAnomalyThreshold = 3.5
+
+// madScale converts a median absolute deviation to a standard-deviation equivalent
+const madScale = 1.4826
+
+// Anomaly is a transaction or month that falls outside its usual range
+type Anomaly struct {
+	Type          string     `json:"type"`  // 'transaction' or 'category_month'
+	Scope         string     `json:"scope"` // 'merchant' or 'category' baseline
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Date          string     `json:"date,omitempty"`  // YYYY-MM-DD for transactions
+	Month         string     `json:"month,omitempty"` // YYYY-MM for category months
+	CategoryID    string     `json:"category_id"`
+	Category      string     `json:"category"`
+	Merchant      string     `json:"merchant,omitempty"`
+	Description   *string    `json:"description,omitempty"`
+	Amount        float64    `json:"amount"`
+	Expected      float64    `json:"expected"` // Baseline (median, seasonally adjusted for months)
+	ExpectedLow   float64    `json:"expected_low"`
+	ExpectedHigh  float64    `json:"expected_high"`
+	Score         float64    `json:"score"`    // Robust z-score; positive = above usual
+	Severity      string     `json:"severity"` // 'medium' or 'high'
+	Reason        string     `json:"reason"`
+}
+
+// AnomalyReport lists anomalies found in a date range
+type AnomalyReport struct {
+	StartDate         string    `json:"start_date"`
+	EndDate           string    `json:"end_date"`
+	BaselineStartDate string    `json:"baseline_start_date"`
+	Threshold         float64   `json:"threshold"`
+	Anomalies         []Anomaly `json:"anomalies"`
+}
+
+// baseline summarizes past observations with outlier-resistant statistics
+type baseline struct {
+	Median float64
+	MAD    float64
+	Count  int
+}
+
+// anomalyTransaction is the subset of a transaction anomaly detection needs
+type anomalyTransaction struct {
+	ID              uuid.UUID
+	CategoryID      *uuid.UUID
+	Amount          float64
+	Description     *string
+	TransactionDate time.Time
+}
+
+// GetAnomaliesHandler flags unusual transactions and category months
+func GetAnomaliesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	// Default to checking the last 90 days against the 12 months before them
+	now := time.Now()
+	startDate := c.Query("start_date", now.AddDate(0, 0, -90).Format("2006-01-02"))
+	endDate := c.Query("end_date", now.Format("2006-01-02"))
+
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid start_date format (use YYYY-MM-DD)",
+		})
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil || end.Before(start) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid end_date (use YYYY-MM-DD, not before start_date)",
+		})
+	}
+
+	lookbackMonths := c.QueryInt("lookback_months", 12)
+	if lookbackMonths < 3 || lookbackMonths > 36 {
+		lookbackMonths = 12
+	}
+
+	threshold := c.QueryFloat("threshold", defaultAnomalyThreshold)
+	if threshold <= 0 {
+		threshold = defaultAnomalyThreshold
+	}
+
+	report, err := DetectAnomalies(c.Context(), userID, start, end, lookbackMonths, threshold)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to detect anomalies",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// DetectAnomalies compares expenses in [start, end] against baselines built
+// from the lookbackMonths before start. Transactions are checked against
+// their merchant's usual amount (or their category's, for merchants without
+// enough history), and each category's monthly total is checked against its
+// usual monthly spend, adjusted for seasonality when a year of history exists.
+func DetectAnomalies(ctx context.Context, userID uuid.UUID, start, end time.Time, lookbackMonths int, threshold float64) (*AnomalyReport, error) {
+	baselineStart := start.AddDate(0, -lookbackMonths, 0)
+
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	transactions, err := getAnomalyTransactions(ctx, userID, baselineStart, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AnomalyReport{
+		StartDate:         start.Format("2006-01-02"),
+		EndDate:           end.Format("2006-01-02"),
+		BaselineStartDate: baselineStart.Format("2006-01-02"),
+		Threshold:         threshold,
+		Anomalies:         []Anomaly{},
+	}
+
+	// Split history from the window being checked
+	var history, current []anomalyTransaction
+	for _, t := range transactions {
+		if t.TransactionDate.Before(start) {
+			history = append(history, t)
+		} else {
+			current = append(current, t)
+		}
+	}
+
+	report.Anomalies = append(report.Anomalies, transactionAnomalies(history, current, tree, threshold)...)
+	report.Anomalies = append(report.Anomalies, categoryMonthAnomalies(transactions, start, end, lookbackMonths, tree, threshold)...)
+
+	sort.Slice(report.Anomalies, func(i, j int) bool {
+		return math.Abs(report.Anomalies[i].Score) > math.Abs(report.Anomalies[j].Score)
+	})
+
+	return report, nil
+}
+
+// transactionAnomalies flags current transactions far above their merchant's
+// (or category's) usual amount. Only unusually large charges are flagged;
+// cheaper-than-usual purchases aren't worth an alert.
+func transactionAnomalies(history, current []anomalyTransaction, tree *categoryTree, threshold float64) []Anomaly {
+	merchantAmounts := make(map[string][]float64)
+	categoryAmounts := make(map[string][]float64)
+	for _, t := range history {
+		if merchant := anomalyMerchant(t); merchant != "" {
+			merchantAmounts[merchant] = append(merchantAmounts[merchant], t.Amount)
+		}
+		key := categoryKey(t.CategoryID)
+		categoryAmounts[key] = append(categoryAmounts[key], t.Amount)
+	}
+
+	var anomalies []Anomaly
+	for _, t := range current {
+		merchant := anomalyMerchant(t)
+		scope := "merchant"
+		b, ok := newBaseline(merchantAmounts[merchant])
+		if merchant == "" || !ok {
+			scope = "category"
+			b, ok = newBaseline(categoryAmounts[categoryKey(t.CategoryID)])
+			if !ok {
+				continue
+			}
+		}
+
+		score := b.score(t.Amount)
+		if score < threshold {
+			continue
+		}
+
+		low, high := b.expectedRange(threshold)
+		id := t.ID
+		anomaly := Anomaly{
+			Type:          "transaction",
+			Scope:         scope,
+			TransactionID: &id,
+			Date:          t.TransactionDate.Format("2006-01-02"),
+			CategoryID:    categoryKey(t.CategoryID),
+			Category:      tree.name(t.CategoryID),
+			Merchant:      merchant,
+			Description:   t.Description,
+			Amount:        t.Amount,
+			Expected:      b.Median,
+			ExpectedLow:   low,
+			ExpectedHigh:  high,
+			Score:         score,
+			Severity:      anomalySeverity(score, threshold),
+		}
+		if scope == "merchant" {
+			anomaly.Reason = fmt.Sprintf("%.2f is %.1fx the usual %.2f charged by %s (usually %.2f-%.2f over %d charges)",
+				t.Amount, ratio(t.Amount, b.Median), b.Median, merchant, low, high, b.Count)
+		} else {
+			anomaly.Reason = fmt.Sprintf("%.2f is unusually large for %s, where transactions are usually %.2f-%.2f",
+				t.Amount, anomaly.Category, low, high)
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies
+}
+
+// categoryMonthAnomalies flags months in [start, end] where a category's total
+// spend is far above or below its usual monthly total
+func categoryMonthAnomalies(transactions []anomalyTransaction, start, end time.Time, lookbackMonths int, tree *categoryTree, threshold float64) []Anomaly {
+	firstMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	baselineFirst := firstMonth.AddDate(0, -lookbackMonths, 0)
+	lastMonth := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// Monthly totals per category; months with no spending count as zero once a category has history
+	totals := make(map[string]map[string]float64)
+	categoryIDs := make(map[string]*uuid.UUID)
+	for _, t := range transactions {
+		key := categoryKey(t.CategoryID)
+		if totals[key] == nil {
+			totals[key] = make(map[string]float64)
+			categoryIDs[key] = t.CategoryID
+		}
+		totals[key][t.TransactionDate.Format("2006-01")] += t.Amount
+	}
+
+	var anomalies []Anomaly
+	for key, monthly := range totals {
+		for month := firstMonth; !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+			// Partial months (the current one) would look artificially low
+			monthEnd := month.AddDate(0, 1, -1)
+			if monthEnd.After(end) {
+				continue
+			}
+
+			var past []float64
+			var pastMonths []time.Time
+			for m := baselineFirst; m.Before(month); m = m.AddDate(0, 1, 0) {
+				past = append(past, monthly[m.Format("2006-01")])
+				pastMonths = append(pastMonths, m)
+			}
+			b, ok := newBaseline(trimLeadingZeros(past))
+			if !ok {
+				continue
+			}
+
+			expected := b.Median
+			seasonal := false
+			if index, ok := seasonalIndex(monthly, month, pastMonths, b.Median); ok {
+				expected = b.Median * index
+				seasonal = true
+			}
+
+			actual := monthly[month.Format("2006-01")]
+			adjusted := baseline{Median: expected, MAD: b.MAD, Count: b.Count}
+			score := adjusted.score(actual)
+			if math.Abs(score) < threshold {
+				continue
+			}
+
+			low, high := adjusted.expectedRange(threshold)
+			categoryName := tree.name(categoryIDs[key])
+			direction := "above"
+			if score < 0 {
+				direction = "below"
+			}
+			reason := fmt.Sprintf("%s spending of %.2f in %s is %s the usual %.2f-%.2f",
+				categoryName, actual, month.Format("January 2006"), direction, low, high)
+			if seasonal {
+				reason += fmt.Sprintf(" (adjusted for %s seasonality)", month.Format("January"))
+			}
+
+			anomalies = append(anomalies, Anomaly{
+				Type:         "category_month",
+				Scope:        "category",
+				Month:        month.Format("2006-01"),
+				CategoryID:   key,
+				Category:     categoryName,
+				Amount:       actual,
+				Expected:     expected,
+				ExpectedLow:  low,
+				ExpectedHigh: high,
+				Score:        score,
+				Severity:     anomalySeverity(math.Abs(score), threshold),
+				Reason:       reason,
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// seasonalIndex compares the same month last year with last year's typical
+// month, so a category that always spikes in December isn't flagged for it
+func seasonalIndex(monthly map[string]float64, month time.Time, pastMonths []time.Time, median float64) (float64, bool) {
+	if len(pastMonths) < 12 || median <= 0 {
+		return 0, false
+	}
+
+	lastYear := monthly[month.AddDate(-1, 0, 0).Format("2006-01")]
+	var yearBefore []float64
+	for _, m := range pastMonths[len(pastMonths)-12:] {
+		yearBefore = append(yearBefore, monthly[m.Format("2006-01")])
+	}
+	typical := medianOf(yearBefore)
+	if typical <= 0 || lastYear <= 0 {
+		return 0, false
+	}
+
+	return lastYear / typical, true
+}
+
+// newBaseline computes median and MAD, or reports false with too few samples
+func newBaseline(values []float64) (baseline, bool) {
+	if len(values) < minBaselineSamples {
+		return baseline{}, false
+	}
+
+	median := medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+
+	return baseline{Median: median, MAD: medianOf(deviations), Count: len(values)}, true
+}
+
+// spread returns the baseline's standard-deviation equivalent. When most
+// values are identical (MAD of zero), a small fraction of the median is used
+// so a fixed-price subscription still flags a genuine price jump.
+func (b baseline) spread() float64 {
+	spread := b.MAD * madScale
+	floor := math.Max(math.Abs(b.Median)*0.05, 1.0)
+	return math.Max(spread, floor)
+}
+
+// score returns how many spreads a value lies from the median
+func (b baseline) score(value float64) float64 {
+	return (value - b.Median) / b.spread()
+}
+
+// expectedRange returns the range of values that wouldn't be flagged
+func (b baseline) expectedRange(threshold float64) (float64, float64) {
+	low := math.Max(b.Median-threshold*b.spread(), 0)
+	high := b.Median + threshold*b.spread()
+	return math.Round(low*100) / 100, math.Round(high*100) / 100
+}
+
+// anomalySeverity grades a score relative to the flagging threshold
+func anomalySeverity(score, threshold float64) string {
+	if score >= threshold*2 {
+		return "high"
+	}
+	return "medium"
+}
+
+// anomalyMerchant groups a transaction by its merchant words
+func anomalyMerchant(t anomalyTransaction) string {
+	if t.Description == nil {
+		return ""
+	}
+	return merchantKey(*t.Description)
+}
+
+// trimLeadingZeros drops the empty months before a category's first spend
+func trimLeadingZeros(values []float64) []float64 {
+	for i, v := range values {
+		if v != 0 {
+			return values[i:]
+		}
+	}
+	return nil
+}
+
+// medianOf returns the median of values without modifying them
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// ratio returns a/b, or 0 when b is zero
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// getAnomalyTransactions retrieves a user's expenses within a date range
+func getAnomalyTransactions(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]anomalyTransaction, error) {
+	query := `
+		SELECT id, category_id, amount, description, transaction_date
+		FROM budget.transactions
+		WHERE user_id = $1
+			AND transaction_type = 'expense'
+			AND transaction_date >= $2
+			AND transaction_date <= $3
+		ORDER BY transaction_date ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions for anomaly detection: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []anomalyTransaction
+	for rows.Next() {
+		var t anomalyTransaction
+		if err := rows.Scan(&t.ID, &t.CategoryID, &t.Amount, &t.Description, &t.TransactionDate); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,122 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CategoryPathItem is one step in a category's ancestry
+type CategoryPathItem struct {
+	CategoryID   uuid.UUID `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+}
+
+// CategoryDrillDown is a category's spending broken down one level: its
+// subcategories with subtotals, and the transactions that make up the total
+type CategoryDrillDown struct {
+	Category     Category           `json:"category"`
+	Path         []CategoryPathItem `json:"path"` // From top-level ancestor down to this category
+	StartDate    string             `json:"start_date"`
+	EndDate      string             `json:"end_date"`
+	TotalAmount  float64            `json:"total_amount"`  // Including all subcategories
+	DirectAmount float64            `json:"direct_amount"` // Categorized directly on this category
+	Children     []CategoryRollup   `json:"children"`
+	Transactions []Transaction      `json:"transactions"`
+}
+
+// GetCategoryDrillDownHandler returns a category's subcategories and transactions for a date range
+func GetCategoryDrillDownHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	categoryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	// Default to current month if not provided
+	now := time.Now()
+	startDate := c.Query("start_date", time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02"))
+	endDate := c.Query("end_date", now.Format("2006-01-02"))
+
+	drillDown, err := GetCategoryDrillDown(c.Context(), userID, categoryID, startDate, endDate)
+	if err != nil {
+		if err.Error() == "category not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Category not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get category drill-down",
+		})
+	}
+
+	return c.JSON(drillDown)
+}
+
+// GetCategoryDrillDown collects a category's transactions, including those in
+// its subcategories at any depth, and rolls them up one level
+func GetCategoryDrillDown(ctx context.Context, userID, categoryID uuid.UUID, startDate, endDate string) (*CategoryDrillDown, error) {
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	category, ok := tree.byID[categoryID]
+	if !ok {
+		return nil, fmt.Errorf("category not found")
+	}
+
+	transactions, err := GetTransactionsByUserID(ctx, userID, nil, nil, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	drillDown := &CategoryDrillDown{
+		Category:     category,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Transactions: []Transaction{},
+	}
+	for _, id := range tree.ancestors(categoryID) {
+		drillDown.Path = append(drillDown.Path, CategoryPathItem{CategoryID: id, CategoryName: tree.name(&id)})
+	}
+
+	amountsByCategory := make(map[uuid.UUID]*categoryAmount)
+	for _, t := range transactions {
+		if t.TransactionType != category.CategoryType || t.CategoryID == nil || !tree.isWithin(*t.CategoryID, categoryID) {
+			continue
+		}
+
+		drillDown.Transactions = append(drillDown.Transactions, t)
+		drillDown.TotalAmount += t.Amount
+		if *t.CategoryID == categoryID {
+			drillDown.DirectAmount += t.Amount
+		}
+
+		if _, exists := amountsByCategory[*t.CategoryID]; !exists {
+			amountsByCategory[*t.CategoryID] = &categoryAmount{CategoryID: t.CategoryID}
+		}
+		amountsByCategory[*t.CategoryID].Amount += t.Amount
+		amountsByCategory[*t.CategoryID].Count++
+	}
+
+	amounts := make([]categoryAmount, 0, len(amountsByCategory))
+	for _, amount := range amountsByCategory {
+		amounts = append(amounts, *amount)
+	}
+	childDepth := tree.depth(categoryID) + 1
+	drillDown.Children = tree.buildCategoryRollup(amounts, CategoryRollupOptions{Depth: &childDepth, ParentID: &categoryID})
+
+	return drillDown, nil
+}
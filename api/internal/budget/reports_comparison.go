@@ -0,0 +1,386 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultComparisonContributors is how many transactions are listed per change
+const defaultComparisonContributors = 3
+
+// ComparisonRange is one side of a period comparison
+type ComparisonRange struct {
+	StartDate string  `json:"start_date"` // YYYY-MM-DD
+	EndDate   string  `json:"end_date"`   // YYYY-MM-DD
+	Total     float64 `json:"total"`
+}
+
+// ContributingTransaction is a transaction that helps explain a category's change
+type ContributingTransaction struct {
+	TransactionID   uuid.UUID `json:"transaction_id"`
+	Range           string    `json:"range"` // 'base' or 'compare'
+	TransactionDate string    `json:"transaction_date"`
+	Description     *string   `json:"description,omitempty"`
+	Amount          float64   `json:"amount"`
+	Merchant        string    `json:"merchant"`
+	MerchantDelta   float64   `json:"merchant_delta"` // Change in the merchant's total within the category
+}
+
+// CategoryComparison compares one category's totals across the two ranges
+type CategoryComparison struct {
+	CategoryID       string                    `json:"category_id"`
+	Category         string                    `json:"category"`
+	ParentCategoryID *string                   `json:"parent_category_id,omitempty"`
+	BaseTotal        float64                   `json:"base_total"`
+	CompareTotal     float64                   `json:"compare_total"`
+	Delta            float64                   `json:"delta"`     // compare - base
+	DeltaPct         *float64                  `json:"delta_pct"` // nil when the base total is zero
+	Contributors     []ContributingTransaction `json:"contributors"`
+}
+
+// ComparisonReport compares spending (or income) between two date ranges
+type ComparisonReport struct {
+	TransactionType  string               `json:"transaction_type"`
+	Base             ComparisonRange      `json:"base"`
+	Compare          ComparisonRange      `json:"compare"`
+	Delta            float64              `json:"delta"`
+	DeltaPct         *float64             `json:"delta_pct"`
+	Categories       []CategoryComparison `json:"categories"`
+	ParentCategories []CategoryComparison `json:"parent_categories"` // Totals rolled up to top-level categories
+}
+
+// comparisonTransaction is the subset of a transaction the comparison needs
+type comparisonTransaction struct {
+	ID              uuid.UUID
+	CategoryID      *uuid.UUID
+	Amount          float64
+	Description     *string
+	TransactionDate string
+	inCompare       bool
+}
+
+// GetComparisonReportHandler compares category totals between two date ranges.
+// Ranges are given explicitly with base_start_date/base_end_date and
+// compare_start_date/compare_end_date, or derived from ?period=&date= with
+// ?compare_to=previous_period|previous_year.
+func GetComparisonReportHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionType := c.Query("type", "expense")
+	if transactionType != "expense" && transactionType != "income" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "type must be 'expense' or 'income'",
+		})
+	}
+
+	var base, compare ReportPeriod
+	var err error
+	if c.Query("period") != "" {
+		anchor := time.Now()
+		if date := c.Query("date"); date != "" {
+			anchor, err = time.Parse("2006-01-02", date)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid date format (use YYYY-MM-DD)",
+				})
+			}
+		}
+
+		compare, err = resolveReportPeriod(c.Query("period"), anchor, c.Query("start_date"), c.Query("end_date"))
+		if err == nil {
+			base, err = previousReportPeriod(compare, c.Query("compare_to", "previous_period"))
+		}
+	} else {
+		base, err = resolveReportPeriod("custom", time.Time{}, c.Query("base_start_date"), c.Query("base_end_date"))
+		if err == nil {
+			compare, err = resolveReportPeriod("custom", time.Time{}, c.Query("compare_start_date"), c.Query("compare_end_date"))
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	limit := defaultComparisonContributors
+	if limitParam := c.QueryInt("contributors", defaultComparisonContributors); limitParam > 0 && limitParam <= 20 {
+		limit = limitParam
+	}
+
+	report, err := GetComparisonReport(c.Context(), userID, transactionType, base, compare, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get comparison report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// previousReportPeriod returns the period to compare against: the one
+// immediately before it, or the same dates one year earlier
+func previousReportPeriod(period ReportPeriod, compareTo string) (ReportPeriod, error) {
+	start, end := period.Start(), period.End()
+
+	var prevStart, prevEnd time.Time
+	switch compareTo {
+	case "previous_year":
+		prevStart = start.AddDate(-1, 0, 0)
+		prevEnd = end.AddDate(-1, 0, 0)
+
+	case "previous_period":
+		switch period.Type {
+		case "month":
+			prevStart = start.AddDate(0, -1, 0)
+			prevEnd = start.AddDate(0, 0, -1)
+		case "quarter":
+			prevStart = start.AddDate(0, -3, 0)
+			prevEnd = start.AddDate(0, 0, -1)
+		case "year":
+			prevStart = start.AddDate(-1, 0, 0)
+			prevEnd = start.AddDate(0, 0, -1)
+		default:
+			// Weeks and custom ranges: the same number of days immediately before
+			days := int(end.Sub(start).Hours()/24) + 1
+			prevStart = start.AddDate(0, 0, -days)
+			prevEnd = start.AddDate(0, 0, -1)
+		}
+
+	default:
+		return ReportPeriod{}, fmt.Errorf("invalid compare_to: must be previous_period or previous_year")
+	}
+
+	return ReportPeriod{
+		Type:      period.Type,
+		StartDate: prevStart.Format("2006-01-02"),
+		EndDate:   prevEnd.Format("2006-01-02"),
+	}, nil
+}
+
+// GetComparisonReport totals transactions of the given type per category in
+// both ranges, rolls them up to top-level categories, and lists the
+// transactions from the merchants that moved each category the most
+func GetComparisonReport(ctx context.Context, userID uuid.UUID, transactionType string, base, compare ReportPeriod, contributors int) (*ComparisonReport, error) {
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	baseTransactions, err := getComparisonTransactions(ctx, userID, transactionType, base)
+	if err != nil {
+		return nil, err
+	}
+	compareTransactions, err := getComparisonTransactions(ctx, userID, transactionType, compare)
+	if err != nil {
+		return nil, err
+	}
+	for i := range compareTransactions {
+		compareTransactions[i].inCompare = true
+	}
+	all := append(baseTransactions, compareTransactions...)
+
+	report := &ComparisonReport{
+		TransactionType: transactionType,
+		Base:            ComparisonRange{StartDate: base.StartDate, EndDate: base.EndDate},
+		Compare:         ComparisonRange{StartDate: compare.StartDate, EndDate: compare.EndDate},
+	}
+
+	// Group by leaf category and by top-level category
+	byCategory := make(map[string][]comparisonTransaction)
+	byParent := make(map[string][]comparisonTransaction)
+	for _, t := range all {
+		if t.inCompare {
+			report.Compare.Total += t.Amount
+		} else {
+			report.Base.Total += t.Amount
+		}
+
+		key := "uncategorized"
+		parentKey := "uncategorized"
+		if t.CategoryID != nil {
+			key = t.CategoryID.String()
+			parentKey = tree.root(*t.CategoryID).String()
+		}
+		byCategory[key] = append(byCategory[key], t)
+		byParent[parentKey] = append(byParent[parentKey], t)
+	}
+
+	report.Delta = report.Compare.Total - report.Base.Total
+	report.DeltaPct = deltaPct(report.Base.Total, report.Delta)
+
+	report.Categories = buildCategoryComparisons(byCategory, tree, contributors, true)
+	report.ParentCategories = buildCategoryComparisons(byParent, tree, contributors, false)
+
+	return report, nil
+}
+
+// buildCategoryComparisons turns grouped transactions into comparisons, ordered
+// by the size of the change
+func buildCategoryComparisons(groups map[string][]comparisonTransaction, tree *categoryTree, contributors int, withParent bool) []CategoryComparison {
+	comparisons := []CategoryComparison{}
+	for key, transactions := range groups {
+		comparison := CategoryComparison{
+			CategoryID: key,
+			Category:   "Uncategorized",
+		}
+		if id, err := uuid.Parse(key); err == nil {
+			comparison.Category = tree.name(&id)
+			if withParent {
+				if category, ok := tree.byID[id]; ok && category.ParentCategoryID != nil {
+					parentID := category.ParentCategoryID.String()
+					comparison.ParentCategoryID = &parentID
+				}
+			}
+		}
+
+		for _, t := range transactions {
+			if t.inCompare {
+				comparison.CompareTotal += t.Amount
+			} else {
+				comparison.BaseTotal += t.Amount
+			}
+		}
+		comparison.Delta = comparison.CompareTotal - comparison.BaseTotal
+		comparison.DeltaPct = deltaPct(comparison.BaseTotal, comparison.Delta)
+		comparison.Contributors = topContributors(transactions, comparison.Delta, contributors)
+
+		comparisons = append(comparisons, comparison)
+	}
+
+	sort.Slice(comparisons, func(i, j int) bool {
+		di, dj := math.Abs(comparisons[i].Delta), math.Abs(comparisons[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		return comparisons[i].Category < comparisons[j].Category
+	})
+
+	return comparisons
+}
+
+// topContributors finds the transactions that explain a change. Transactions
+// are grouped by merchant; for an increase, the largest compare-range
+// transactions from the merchants that grew the most are returned, and for a
+// decrease, the largest base-range transactions from the merchants that shrank.
+func topContributors(transactions []comparisonTransaction, delta float64, limit int) []ContributingTransaction {
+	contributors := []ContributingTransaction{}
+	if delta == 0 || limit <= 0 {
+		return contributors
+	}
+	increase := delta > 0
+
+	merchantDelta := make(map[string]float64)
+	for _, t := range transactions {
+		merchant := comparisonMerchant(t)
+		if t.inCompare {
+			merchantDelta[merchant] += t.Amount
+		} else {
+			merchantDelta[merchant] -= t.Amount
+		}
+	}
+
+	var candidates []comparisonTransaction
+	for _, t := range transactions {
+		d := merchantDelta[comparisonMerchant(t)]
+		// Only the side that grew explains an increase, and vice versa
+		if t.inCompare == increase && ((increase && d > 0) || (!increase && d < 0)) {
+			candidates = append(candidates, t)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		di := math.Abs(merchantDelta[comparisonMerchant(candidates[i])])
+		dj := math.Abs(merchantDelta[comparisonMerchant(candidates[j])])
+		if di != dj {
+			return di > dj
+		}
+		return candidates[i].Amount > candidates[j].Amount
+	})
+
+	for _, t := range candidates {
+		if len(contributors) == limit {
+			break
+		}
+		rangeName := "base"
+		if t.inCompare {
+			rangeName = "compare"
+		}
+		merchant := comparisonMerchant(t)
+		contributors = append(contributors, ContributingTransaction{
+			TransactionID:   t.ID,
+			Range:           rangeName,
+			TransactionDate: t.TransactionDate,
+			Description:     t.Description,
+			Amount:          t.Amount,
+			Merchant:        merchant,
+			MerchantDelta:   merchantDelta[merchant],
+		})
+	}
+
+	return contributors
+}
+
+// comparisonMerchant groups a transaction by its merchant words
+func comparisonMerchant(t comparisonTransaction) string {
+	if t.Description == nil {
+		return ""
+	}
+	if key := merchantKey(*t.Description); key != "" {
+		return key
+	}
+	return normalizeDescription(*t.Description)
+}
+
+// getComparisonTransactions retrieves a user's transactions of one type within a period
+func getComparisonTransactions(ctx context.Context, userID uuid.UUID, transactionType string, period ReportPeriod) ([]comparisonTransaction, error) {
+	query := `
+		SELECT id, category_id, amount, description, transaction_date::text
+		FROM budget.transactions
+		WHERE user_id = $1
+			AND transaction_type = $2
+			AND transaction_date >= $3
+			AND transaction_date <= $4
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, transactionType, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions for comparison: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []comparisonTransaction
+	for rows.Next() {
+		var t comparisonTransaction
+		if err := rows.Scan(&t.ID, &t.CategoryID, &t.Amount, &t.Description, &t.TransactionDate); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// deltaPct returns delta as a percentage of base, or nil when base is zero
+func deltaPct(base, delta float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	pct := (delta / base) * 100
+	return &pct
+}
@@ -0,0 +1,460 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultForecastSimulations is how many simulated futures a forecast runs
+const defaultForecastSimulations = 1000
+
+// maxForecastSimulations caps the simulation count to keep requests fast
+const maxForecastSimulations = 5000
+
+// minTimingSamples is how many past payments an entry needs before its own
+// timing history is used instead of defaultTimingJitterDays
+const minTimingSamples = 3
+
+// defaultTimingJitterDays is how many days either side of the due date an entry
+// without enough history may land
+const defaultTimingJitterDays = 2
+
+// maxTimingOffsetDays ignores linked transactions further than this from a due date
+const maxTimingOffsetDays = 10
+
+// ForecastOptions controls a Monte Carlo cash flow forecast
+type ForecastOptions struct {
+	ProjectionOptions
+	Simulations    int
+	Threshold      float64 // Balance the forecast reports the risk of dropping below
+	LookbackMonths int     // History used for variable spending
+	Seed           int64
+}
+
+// ForecastDay is the spread of simulated balances at the end of a day
+type ForecastDay struct {
+	Date             string  `json:"date"`
+	P10              float64 `json:"p10"`
+	P50              float64 `json:"p50"`
+	P90              float64 `json:"p90"`
+	ProbabilityBelow float64 `json:"probability_below"` // Share of simulations below the threshold on this day (0-1)
+}
+
+// ForecastBand is the 10th, 50th and 90th percentile of a simulated value
+type ForecastBand struct {
+	P10 float64 `json:"p10"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+}
+
+// ForecastCategory is a category's variable spending as used by the forecast
+type ForecastCategory struct {
+	CategoryID    string  `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	AverageDaily  float64 `json:"average_daily"`
+	ExpectedTotal float64 `json:"expected_total"` // Average spend over the forecast period
+}
+
+// ForecastEntryTiming describes how an entry's due dates are jittered
+type ForecastEntryTiming struct {
+	EntryID      uuid.UUID `json:"entry_id"`
+	EntryName    string    `json:"entry_name"`
+	Samples      int       `json:"samples"`       // Past payments matched to a due date
+	EarliestDays int       `json:"earliest_days"` // Earliest payment relative to the due date (negative = early)
+	LatestDays   int       `json:"latest_days"`   // Latest payment relative to the due date
+	UsesDefault  bool      `json:"uses_default"`  // True when there wasn't enough history
+}
+
+// CashFlowForecast is the result of simulating the coming days many times
+type CashFlowForecast struct {
+	StartDate                 string                `json:"start_date"`
+	EndDate                   string                `json:"end_date"`
+	StartingBalance           float64               `json:"starting_balance"`
+	BalanceSource             string                `json:"balance_source"` // 'accounts', 'account' or 'manual'
+	AccountID                 *uuid.UUID            `json:"account_id,omitempty"`
+	HistoryStartDate          string                `json:"history_start_date"`
+	HistoryEndDate            string                `json:"history_end_date"`
+	Simulations               int                   `json:"simulations"`
+	Seed                      int64                 `json:"seed"`
+	Threshold                 float64               `json:"threshold"`
+	ProbabilityBelowThreshold float64               `json:"probability_below_threshold"` // Share of simulations that drop below the threshold at any point (0-1)
+	LowestBalance             ForecastBand          `json:"lowest_balance"`
+	EndingBalance             ForecastBand          `json:"ending_balance"`
+	Days                      []ForecastDay         `json:"days"`
+	Categories                []ForecastCategory    `json:"categories"`
+	Entries                   []ForecastEntryTiming `json:"entries"`
+}
+
+// variableCategory holds a category's historical daily spend, zero days included
+type variableCategory struct {
+	CategoryID *uuid.UUID
+	Daily      []float64
+}
+
+// GetCashFlowForecastHandler simulates the coming days to give a range of
+// likely balances rather than a single line
+func GetCashFlowForecastHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	projectionOpts, err := parseProjectionOptions(c.Query("days"), c.Query("account_id"), c.Query("starting_balance"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	opts := ForecastOptions{
+		ProjectionOptions: projectionOpts,
+		Simulations:       c.QueryInt("simulations", defaultForecastSimulations),
+		Threshold:         c.QueryFloat("threshold", 0),
+		LookbackMonths:    c.QueryInt("lookback_months", 6),
+		Seed:              int64(c.QueryInt("seed", 0)),
+	}
+	if opts.Simulations < 100 || opts.Simulations > maxForecastSimulations {
+		opts.Simulations = defaultForecastSimulations
+	}
+	if opts.LookbackMonths < 1 || opts.LookbackMonths > 24 {
+		opts.LookbackMonths = 6
+	}
+	if opts.Seed == 0 {
+		// Kept within 53 bits so JavaScript clients can send it back unchanged
+		opts.Seed = time.Now().UnixNano() & (1<<53 - 1)
+	}
+
+	forecast, err := ForecastCashFlow(c.Context(), userID, opts)
+	if err != nil {
+		if err.Error() == "account not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Account not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to forecast cash flow",
+		})
+	}
+
+	return c.JSON(forecast)
+}
+
+// ForecastCashFlow runs a Monte Carlo simulation of the active budget. Each
+// simulation starts from the same point as GetCashFlowProjection, lands every
+// scheduled occurrence a few days early or late based on when that entry has
+// actually been paid, and adds variable spending drawn from each category's
+// historical daily spend (transactions not linked to a budget entry).
+func ForecastCashFlow(ctx context.Context, userID uuid.UUID, opts ForecastOptions) (*CashFlowForecast, error) {
+	entries, err := getActiveBudgetEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := runCashFlowProjection(ctx, userID, entries, opts.ProjectionOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	now := time.Now()
+	historyEnd := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	historyStart := historyEnd.AddDate(0, -opts.LookbackMonths, 1)
+
+	categories, historyStart, err := loadVariableSpending(ctx, userID, opts.AccountID, historyStart, historyEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := entryTimingOffsets(entries, run.linked)
+
+	forecast := &CashFlowForecast{
+		StartingBalance:  run.startingBalance,
+		BalanceSource:    run.balanceSource,
+		AccountID:        run.accountID,
+		HistoryStartDate: historyStart.Format("2006-01-02"),
+		HistoryEndDate:   historyEnd.Format("2006-01-02"),
+		Simulations:      opts.Simulations,
+		Seed:             opts.Seed,
+		Threshold:        opts.Threshold,
+		Days:             []ForecastDay{},
+		Categories:       []ForecastCategory{},
+		Entries:          []ForecastEntryTiming{},
+	}
+	if len(run.days) > 0 {
+		forecast.StartDate = run.days[0].date
+		forecast.EndDate = run.days[len(run.days)-1].date
+	}
+
+	simulateForecast(forecast, run, categories, offsets, rand.New(rand.NewSource(opts.Seed)))
+
+	for _, category := range categories {
+		total := 0.0
+		for _, amount := range category.Daily {
+			total += amount
+		}
+		average := total / float64(len(category.Daily))
+		forecast.Categories = append(forecast.Categories, ForecastCategory{
+			CategoryID:    categoryKey(category.CategoryID),
+			CategoryName:  tree.name(category.CategoryID),
+			AverageDaily:  average,
+			ExpectedTotal: average * float64(len(run.days)),
+		})
+	}
+	sort.Slice(forecast.Categories, func(i, j int) bool {
+		return forecast.Categories[i].ExpectedTotal > forecast.Categories[j].ExpectedTotal
+	})
+
+	for _, entry := range entries {
+		if !entry.IsActive {
+			continue
+		}
+		timing := ForecastEntryTiming{
+			EntryID:      entry.ID,
+			EntryName:    entry.Name,
+			Samples:      len(offsets[entry.ID]),
+			EarliestDays: -defaultTimingJitterDays,
+			LatestDays:   defaultTimingJitterDays,
+			UsesDefault:  len(offsets[entry.ID]) < minTimingSamples,
+		}
+		if !timing.UsesDefault {
+			timing.EarliestDays, timing.LatestDays = offsets[entry.ID][0], offsets[entry.ID][0]
+			for _, offset := range offsets[entry.ID] {
+				timing.EarliestDays = min(timing.EarliestDays, offset)
+				timing.LatestDays = max(timing.LatestDays, offset)
+			}
+		}
+		forecast.Entries = append(forecast.Entries, timing)
+	}
+
+	return forecast, nil
+}
+
+// simulateForecast fills in the forecast's daily bands and risk figures by
+// replaying the projection run opts.Simulations times
+func simulateForecast(forecast *CashFlowForecast, run *cashFlowRun, categories []variableCategory, offsets map[uuid.UUID][]int, rng *rand.Rand) {
+	days := len(run.days)
+	if days == 0 {
+		return
+	}
+
+	balances := make([][]float64, days) // day -> balance in each simulation
+	for i := range balances {
+		balances[i] = make([]float64, forecast.Simulations)
+	}
+	below := make([]int, days)
+	lowest := make([]float64, forecast.Simulations)
+	breaches := 0
+	delta := make([]float64, days)
+
+	for s := 0; s < forecast.Simulations; s++ {
+		for i := range delta {
+			delta[i] = 0
+		}
+
+		for i, day := range run.days {
+			for _, occurrence := range day.occurrences {
+				target := i
+				// Overdue occurrences are already late; assume they go out today
+				if occurrence.Status != "overdue" {
+					target += sampleTimingOffset(offsets[occurrence.EntryID], rng)
+				}
+				if target < 0 {
+					target = 0
+				}
+				if target >= days {
+					continue // Slipped past the end of the forecast
+				}
+
+				if occurrence.EntryType == "income" {
+					delta[target] += occurrence.Amount
+				} else {
+					delta[target] -= occurrence.Amount
+				}
+			}
+
+			for _, category := range categories {
+				delta[i] -= category.Daily[rng.Intn(len(category.Daily))]
+			}
+		}
+
+		balance := run.startingBalance
+		lowest[s] = math.Inf(1)
+		breached := false
+		for i := range delta {
+			balance += delta[i]
+			balances[i][s] = balance
+			lowest[s] = math.Min(lowest[s], balance)
+			if balance < forecast.Threshold {
+				below[i]++
+				breached = true
+			}
+		}
+		if breached {
+			breaches++
+		}
+	}
+
+	simulations := float64(forecast.Simulations)
+	for i, day := range run.days {
+		sort.Float64s(balances[i])
+		forecast.Days = append(forecast.Days, ForecastDay{
+			Date:             day.date,
+			P10:              percentileOf(balances[i], 10),
+			P50:              percentileOf(balances[i], 50),
+			P90:              percentileOf(balances[i], 90),
+			ProbabilityBelow: float64(below[i]) / simulations,
+		})
+	}
+
+	sort.Float64s(lowest)
+	forecast.LowestBalance = ForecastBand{
+		P10: percentileOf(lowest, 10),
+		P50: percentileOf(lowest, 50),
+		P90: percentileOf(lowest, 90),
+	}
+	last := balances[days-1]
+	forecast.EndingBalance = ForecastBand{
+		P10: percentileOf(last, 10),
+		P50: percentileOf(last, 50),
+		P90: percentileOf(last, 90),
+	}
+	forecast.ProbabilityBelowThreshold = float64(breaches) / simulations
+}
+
+// sampleTimingOffset picks how many days from its due date an occurrence lands
+func sampleTimingOffset(offsets []int, rng *rand.Rand) int {
+	if len(offsets) >= minTimingSamples {
+		return offsets[rng.Intn(len(offsets))]
+	}
+	return rng.Intn(2*defaultTimingJitterDays+1) - defaultTimingJitterDays
+}
+
+// entryTimingOffsets measures how many days from the nearest due date each
+// linked transaction was paid (negative = early), per budget entry
+func entryTimingOffsets(entries []BudgetEntry, linked []Transaction) map[uuid.UUID][]int {
+	byID := make(map[uuid.UUID]BudgetEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+
+	offsets := make(map[uuid.UUID][]int)
+	for _, t := range linked {
+		if t.BudgetEntryID == nil {
+			continue
+		}
+		entry, ok := byID[*t.BudgetEntryID]
+		if !ok || entry.Frequency == "daily" {
+			continue // Daily entries are due every day, so there's no offset to measure
+		}
+		date, err := time.Parse("2006-01-02", t.TransactionDate)
+		if err != nil {
+			continue
+		}
+
+		for d := 0; d <= maxTimingOffsetDays; d++ {
+			if entryOccursOnDate(entry, date.AddDate(0, 0, -d)) {
+				offsets[entry.ID] = append(offsets[entry.ID], d)
+				break
+			}
+			if d > 0 && entryOccursOnDate(entry, date.AddDate(0, 0, d)) {
+				offsets[entry.ID] = append(offsets[entry.ID], -d)
+				break
+			}
+		}
+	}
+
+	return offsets
+}
+
+// loadVariableSpending builds each category's daily spend over [start, end]
+// from expenses not linked to a budget entry, since linked ones are already
+// scheduled. The window is shortened to begin at the first such expense so a
+// short history isn't padded with empty days. Returns the window start used.
+func loadVariableSpending(ctx context.Context, userID uuid.UUID, accountID *uuid.UUID, start, end time.Time) ([]variableCategory, time.Time, error) {
+	startDate := start.Format("2006-01-02")
+	endDate := end.Format("2006-01-02")
+	transactions, err := GetTransactionsByUserID(ctx, userID, accountID, nil, &startDate, &endDate)
+	if err != nil {
+		return nil, start, fmt.Errorf("failed to load transactions: %w", err)
+	}
+
+	type spend struct {
+		categoryID *uuid.UUID
+		date       time.Time
+		amount     float64
+	}
+	var expenses []spend
+	first := end.AddDate(0, 0, 1)
+	for _, t := range transactions {
+		if t.TransactionType != "expense" || t.BudgetEntryID != nil {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", t.TransactionDate)
+		if err != nil {
+			continue
+		}
+		expenses = append(expenses, spend{categoryID: t.CategoryID, date: date, amount: t.Amount})
+		if date.Before(first) {
+			first = date
+		}
+	}
+	if len(expenses) == 0 {
+		return []variableCategory{}, start, nil
+	}
+	if first.After(start) {
+		start = first
+	}
+
+	days := int(end.Sub(start).Hours()/24) + 1
+	byCategory := make(map[string]*variableCategory)
+	var order []string
+	for _, e := range expenses {
+		key := categoryKey(e.categoryID)
+		if byCategory[key] == nil {
+			byCategory[key] = &variableCategory{
+				CategoryID: e.categoryID,
+				Daily:      make([]float64, days),
+			}
+			order = append(order, key)
+		}
+		index := int(e.date.Sub(start).Hours() / 24)
+		if index >= 0 && index < days {
+			byCategory[key].Daily[index] += e.amount
+		}
+	}
+
+	categories := make([]variableCategory, 0, len(order))
+	for _, key := range order {
+		categories = append(categories, *byCategory[key])
+	}
+
+	return categories, start, nil
+}
+
+// percentileOf returns the pth percentile of already-sorted values,
+// interpolating between neighbouring values
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
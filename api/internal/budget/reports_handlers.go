@@ -3,6 +3,7 @@ package budget
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/brendenbissett/help-me-budget/api/internal/database"
@@ -16,21 +17,45 @@ import (
 
 // SpendingTrend represents spending for a category over time
 type SpendingTrend struct {
-	Month      string  `json:"month"`       // YYYY-MM format
-	CategoryID string  `json:"category_id"`
-	Category   string  `json:"category"`
-	Amount     float64 `json:"amount"`
+	Month            string  `json:"month"`       // YYYY-MM format
+	CategoryID       string  `json:"category_id"`
+	Category         string  `json:"category"`
+	ParentCategoryID *string `json:"parent_category_id,omitempty"`
+	Depth            int     `json:"depth"` // 0 = top-level category
+	Amount           float64 `json:"amount"`
 }
 
-// BudgetVariance shows budget vs actual for each entry
+// BudgetVariance shows budget vs actual for each entry. Budgeted is the entry
+// amount multiplied by the number of times the entry falls due in the period.
 type BudgetVariance struct {
 	EntryID     uuid.UUID `json:"entry_id"`
 	EntryName   string    `json:"entry_name"`
+	EntryType   string    `json:"entry_type"` // 'income' or 'expense'
+	Frequency   string    `json:"frequency"`
 	Category    string    `json:"category"`
+	Occurrences int       `json:"occurrences"` // Times the entry falls due in the period
 	Budgeted    float64   `json:"budgeted"`
 	Actual      float64   `json:"actual"`
 	Variance    float64   `json:"variance"` // positive = under budget, negative = over budget
 	VariancePct float64   `json:"variance_pct"`
+	YTDBudgeted float64   `json:"ytd_budgeted"`
+	YTDActual   float64   `json:"ytd_actual"`
+	YTDVariance float64   `json:"ytd_variance"`
+}
+
+// BudgetVarianceReport is the budget vs actual comparison for a period,
+// with year-to-date cumulative figures
+type BudgetVarianceReport struct {
+	Period       ReportPeriod     `json:"period"`
+	YTDStartDate string           `json:"ytd_start_date"`
+	YTDEndDate   string           `json:"ytd_end_date"`
+	Entries      []BudgetVariance `json:"entries"`
+	Budgeted     float64          `json:"budgeted"`
+	Actual       float64          `json:"actual"`
+	Variance     float64          `json:"variance"`
+	YTDBudgeted  float64          `json:"ytd_budgeted"`
+	YTDActual    float64          `json:"ytd_actual"`
+	YTDVariance  float64          `json:"ytd_variance"`
 }
 
 // DailyCashFlowProjection represents projected balance for a single day
@@ -41,13 +66,26 @@ type DailyCashFlowProjection struct {
 	ProjectedBalance  float64 `json:"projected_balance"`
 }
 
+// CashFlowProjectionReport is the active budget's projected balance, day by day
+type CashFlowProjectionReport struct {
+	StartingBalance   float64                   `json:"starting_balance"`
+	BalanceSource     string                    `json:"balance_source"` // 'accounts', 'account' or 'manual'
+	AccountID         *uuid.UUID                `json:"account_id,omitempty"`
+	LowestBalance     float64                   `json:"lowest_balance"`
+	LowestBalanceDate string                    `json:"lowest_balance_date"`
+	Overdue           []ProjectedOccurrence     `json:"overdue"` // Unpaid occurrences from earlier this month, applied on the first day
+	Projections       []DailyCashFlowProjection `json:"projections"`
+}
+
 // TopExpense represents a high-spending category
 type TopExpense struct {
-	CategoryID   string  `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	TotalAmount  float64 `json:"total_amount"`
-	Percentage   float64 `json:"percentage"` // Percentage of total expenses
-	Count        int     `json:"count"`      // Number of transactions
+	CategoryID       string  `json:"category_id"`
+	CategoryName     string  `json:"category_name"`
+	ParentCategoryID *string `json:"parent_category_id,omitempty"`
+	Depth            int     `json:"depth"` // 0 = top-level category
+	TotalAmount      float64 `json:"total_amount"`
+	Percentage       float64 `json:"percentage"` // Percentage of total expenses
+	Count            int     `json:"count"`      // Number of transactions
 }
 
 // ============================================================================
@@ -63,6 +101,11 @@ func GetSpendingTrendsHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
+	}
+
 	// Get optional date range from query params
 	startDate := c.Query("start_date") // YYYY-MM-DD
 	endDate := c.Query("end_date")     // YYYY-MM-DD
@@ -75,13 +118,26 @@ func GetSpendingTrendsHandler(c *fiber.Ctx) error {
 		endDate = time.Now().Format("2006-01-02")
 	}
 
-	trends, err := GetSpendingTrends(c.Context(), userID, startDate, endDate)
+	// Optional hierarchy grouping: ?depth=0 rolls up to top-level categories,
+	// ?parent_id= drills into one category's children
+	opts, err := parseCategoryRollupOptions(c.Query("depth"), c.Query("parent_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	trends, err := GetSpendingTrends(c.Context(), userID, startDate, endDate, opts)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get spending trends",
 		})
 	}
 
+	if format != "" {
+		return sendExport(c, userID, format, "spending-trends", spendingTrendsExport(trends, startDate, endDate))
+	}
+
 	return c.JSON(trends)
 }
 
@@ -94,19 +150,52 @@ func GetBudgetVarianceHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get optional month from query params (defaults to current month)
-	month := c.Query("month") // YYYY-MM format
-	if month == "" {
-		month = time.Now().Format("2006-01")
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
+	}
+
+	// Period defaults to the current month. ?month=YYYY-MM is still accepted as
+	// shorthand for period=month anchored in that month.
+	periodType := c.Query("period", "month") // week, month, quarter, year, custom
+	anchor := time.Now()
+	if month := c.Query("month"); month != "" {
+		parsed, err := time.Parse("2006-01", month)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid month format (use YYYY-MM)",
+			})
+		}
+		periodType = "month"
+		anchor = parsed
+	} else if date := c.Query("date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date format (use YYYY-MM-DD)",
+			})
+		}
+		anchor = parsed
+	}
+
+	period, err := resolveReportPeriod(periodType, anchor, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	variance, err := GetBudgetVariance(c.Context(), userID, month)
+	variance, err := GetBudgetVariance(c.Context(), userID, period)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get budget variance",
 		})
 	}
 
+	if format != "" {
+		return sendExport(c, userID, format, "budget-variance", budgetVarianceExport(variance))
+	}
+
 	return c.JSON(variance)
 }
 
@@ -119,26 +208,36 @@ func GetCashFlowProjectionHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get optional parameters
-	daysStr := c.Query("days", "90")               // Default 90 days
-	startingBalanceStr := c.Query("starting_balance", "0")
-
-	var days int
-	var startingBalance float64
-	fmt.Sscanf(daysStr, "%d", &days)
-	fmt.Sscanf(startingBalanceStr, "%f", &startingBalance)
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
+	}
 
-	if days <= 0 || days > 365 {
-		days = 90
+	// Starts from the user's account balances unless starting_balance is given
+	opts, err := parseProjectionOptions(c.Query("days"), c.Query("account_id"), c.Query("starting_balance"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	projection, err := GetCashFlowProjection(c.Context(), userID, days, startingBalance)
+	projection, err := GetCashFlowProjection(c.Context(), userID, opts)
 	if err != nil {
+		if err.Error() == "account not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Account not found",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get cash flow projection",
 		})
 	}
 
+	if format != "" {
+		currency := userCurrency(c.Context(), userID, opts.AccountID)
+		return sendExport(c, userID, format, "cash-flow-projection", cashFlowProjectionExport(projection, currency))
+	}
+
 	return c.JSON(projection)
 }
 
@@ -151,6 +250,11 @@ func GetTopExpensesHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
+	}
+
 	// Get optional date range and limit
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
@@ -171,13 +275,38 @@ func GetTopExpensesHandler(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	topExpenses, err := GetTopExpenses(c.Context(), userID, startDate, endDate, limit)
+	opts, err := parseCategoryRollupOptions(c.Query("depth"), c.Query("parent_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// ?nested=true returns the category tree with subtotals instead of a flat list
+	if c.QueryBool("nested", false) {
+		rollup, err := GetExpenseRollup(c.Context(), userID, startDate, endDate, opts)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get top expenses",
+			})
+		}
+		if format != "" {
+			return sendExport(c, userID, format, "top-expenses", expenseRollupExport(rollup, startDate, endDate))
+		}
+		return c.JSON(rollup)
+	}
+
+	topExpenses, err := GetTopExpenses(c.Context(), userID, startDate, endDate, limit, opts)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get top expenses",
 		})
 	}
 
+	if format != "" {
+		return sendExport(c, userID, format, "top-expenses", topExpensesExport(topExpenses, startDate, endDate))
+	}
+
 	return c.JSON(topExpenses)
 }
 
@@ -185,23 +314,26 @@ func GetTopExpensesHandler(c *fiber.Ctx) error {
 // Repository Functions
 // ============================================================================
 
-// GetSpendingTrends calculates spending by category over time
-func GetSpendingTrends(ctx context.Context, userID uuid.UUID, startDate, endDate string) ([]SpendingTrend, error) {
+// GetSpendingTrends calculates spending by category over time. Categories are
+// grouped according to opts, so subcategory spending can be rolled up into
+// its parents at any depth.
+func GetSpendingTrends(ctx context.Context, userID uuid.UUID, startDate, endDate string, opts CategoryRollupOptions) ([]SpendingTrend, error) {
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
 	query := `
 		SELECT
 			TO_CHAR(t.transaction_date::date, 'YYYY-MM') as month,
-			COALESCE(t.category_id::text, 'uncategorized') as category_id,
-			COALESCE(c.name, 'Uncategorized') as category,
+			t.category_id,
 			SUM(t.amount) as amount
 		FROM budget.transactions t
-		LEFT JOIN budget.categories c ON t.category_id = c.id
 		WHERE t.user_id = $1
 			AND t.transaction_type = 'expense'
 			AND t.transaction_date >= $2
 			AND t.transaction_date <= $3
-			AND t.deleted_at IS NULL
-		GROUP BY month, category_id, category
-		ORDER BY month DESC, amount DESC
+		GROUP BY month, t.category_id
 	`
 
 	rows, err := database.DB.Query(ctx, query, userID, startDate, endDate)
@@ -210,24 +342,83 @@ func GetSpendingTrends(ctx context.Context, userID uuid.UUID, startDate, endDate
 	}
 	defer rows.Close()
 
-	var trends []SpendingTrend
+	grouped := make(map[string]*SpendingTrend)
 	for rows.Next() {
-		var trend SpendingTrend
-		if err := rows.Scan(&trend.Month, &trend.CategoryID, &trend.Category, &trend.Amount); err != nil {
+		var month string
+		var categoryID *uuid.UUID
+		var amount float64
+		if err := rows.Scan(&month, &categoryID, &amount); err != nil {
 			return nil, fmt.Errorf("failed to scan spending trend: %w", err)
 		}
-		trends = append(trends, trend)
+
+		group, ok := tree.groupFor(categoryID, opts)
+		if !ok {
+			continue
+		}
+
+		key := month + "|" + categoryKey(group)
+		trend, exists := grouped[key]
+		if !exists {
+			trend = &SpendingTrend{
+				Month:      month,
+				CategoryID: categoryKey(group),
+				Category:   tree.name(group),
+			}
+			if group != nil {
+				trend.ParentCategoryID = uuidString(tree.parent(*group))
+				trend.Depth = tree.depth(*group)
+			}
+			grouped[key] = trend
+		}
+		trend.Amount += amount
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating spending trends: %w", err)
 	}
 
+	trends := make([]SpendingTrend, 0, len(grouped))
+	for _, trend := range grouped {
+		trends = append(trends, *trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Month != trends[j].Month {
+			return trends[i].Month > trends[j].Month
+		}
+		return trends[i].Amount > trends[j].Amount
+	})
+
 	return trends, nil
 }
 
-// GetBudgetVariance compares budget vs actual for a given month
-func GetBudgetVariance(ctx context.Context, userID uuid.UUID, month string) ([]BudgetVariance, error) {
+// GetBudgetVariance compares each active budget entry's expected amount for
+// the period against the transactions linked to it. Expected amounts come from
+// how many times the entry actually falls due in the period, so a weekly entry
+// is budgeted 4-5 times in a month and an annual entry only in the period
+// containing its due date. Year-to-date figures run from January 1 of the
+// period's final year to the period end, capped at today.
+func GetBudgetVariance(ctx context.Context, userID uuid.UUID, period ReportPeriod) (*BudgetVarianceReport, error) {
+	periodStart := period.Start()
+	periodEnd := period.End()
+
+	ytdStart := time.Date(periodEnd.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	ytdEnd := periodEnd
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if ytdEnd.After(today) && !today.Before(ytdStart) {
+		ytdEnd = today
+	}
+
+	report := &BudgetVarianceReport{
+		Period:       period,
+		YTDStartDate: ytdStart.Format("2006-01-02"),
+		YTDEndDate:   ytdEnd.Format("2006-01-02"),
+		Entries:      []BudgetVariance{},
+	}
+
 	// Get active budget
 	activeBudget, err := GetActiveBudget(ctx, userID)
 	if err != nil || activeBudget == nil {
-		return []BudgetVariance{}, nil // No active budget
+		return report, nil // No active budget
 	}
 
 	// Get budget entries
@@ -236,48 +427,79 @@ func GetBudgetVariance(ctx context.Context, userID uuid.UUID, month string) ([]B
 		return nil, fmt.Errorf("failed to get budget entries: %w", err)
 	}
 
-	// Parse month (YYYY-MM)
-	startOfMonth, err := time.Parse("2006-01", month)
-	if err != nil {
-		return nil, fmt.Errorf("invalid month format: %w", err)
-	}
-	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Second)
+	categoryNames := make(map[uuid.UUID]string)
 
 	// Calculate variance for each entry
-	var variances []BudgetVariance
 	for _, entry := range entries {
-		// Get actual spending for this entry
-		actual, err := getActualForEntry(ctx, userID, entry.ID, startOfMonth, endOfMonth)
+		if !entry.IsActive {
+			continue
+		}
+
+		occurrences := countOccurrences(entry, periodStart, periodEnd)
+		actual, err := getActualForEntry(ctx, userID, entry.ID, periodStart, periodEnd)
 		if err != nil {
-			continue // Skip on error
+			return nil, fmt.Errorf("failed to get actual for entry %s: %w", entry.ID, err)
+		}
+
+		// Entries that aren't due and have nothing linked don't belong in this period
+		if occurrences == 0 && actual == 0 {
+			continue
+		}
+
+		ytdBudgeted := entry.Amount * float64(countOccurrences(entry, ytdStart, ytdEnd))
+		ytdActual, err := getActualForEntry(ctx, userID, entry.ID, ytdStart, ytdEnd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get year-to-date actual for entry %s: %w", entry.ID, err)
 		}
 
 		categoryName := "Uncategorized"
 		if entry.CategoryID != nil {
-			category, _ := GetCategoryByID(ctx, *entry.CategoryID, userID)
-			if category != nil {
-				categoryName = category.Name
+			name, cached := categoryNames[*entry.CategoryID]
+			if !cached {
+				category, _ := GetCategoryByID(ctx, *entry.CategoryID, userID)
+				if category != nil {
+					name = category.Name
+				}
+				categoryNames[*entry.CategoryID] = name
+			}
+			if name != "" {
+				categoryName = name
 			}
 		}
 
-		variance := entry.Amount - actual
+		budgeted := entry.Amount * float64(occurrences)
+		variance := budgeted - actual
 		variancePct := 0.0
-		if entry.Amount > 0 {
-			variancePct = (variance / entry.Amount) * 100
+		if budgeted > 0 {
+			variancePct = (variance / budgeted) * 100
 		}
 
-		variances = append(variances, BudgetVariance{
+		report.Entries = append(report.Entries, BudgetVariance{
 			EntryID:     entry.ID,
 			EntryName:   entry.Name,
+			EntryType:   entry.EntryType,
+			Frequency:   entry.Frequency,
 			Category:    categoryName,
-			Budgeted:    entry.Amount,
+			Occurrences: occurrences,
+			Budgeted:    budgeted,
 			Actual:      actual,
 			Variance:    variance,
 			VariancePct: variancePct,
+			YTDBudgeted: ytdBudgeted,
+			YTDActual:   ytdActual,
+			YTDVariance: ytdBudgeted - ytdActual,
 		})
+
+		report.Budgeted += budgeted
+		report.Actual += actual
+		report.YTDBudgeted += ytdBudgeted
+		report.YTDActual += ytdActual
 	}
 
-	return variances, nil
+	report.Variance = report.Budgeted - report.Actual
+	report.YTDVariance = report.YTDBudgeted - report.YTDActual
+
+	return report, nil
 }
 
 // getActualForEntry gets total actual spending/income for a budget entry in a date range
@@ -289,7 +511,6 @@ func getActualForEntry(ctx context.Context, userID, entryID uuid.UUID, startDate
 			AND budget_entry_id = $2
 			AND transaction_date >= $3
 			AND transaction_date <= $4
-			AND deleted_at IS NULL
 	`
 
 	var total float64
@@ -301,161 +522,177 @@ func getActualForEntry(ctx context.Context, userID, entryID uuid.UUID, startDate
 	return total, nil
 }
 
-// GetCashFlowProjection projects future balance based on budget entries
-func GetCashFlowProjection(ctx context.Context, userID uuid.UUID, days int, startingBalance float64) ([]DailyCashFlowProjection, error) {
-	// Get active budget
-	activeBudget, err := GetActiveBudget(ctx, userID)
-	if err != nil || activeBudget == nil {
-		return []DailyCashFlowProjection{}, nil
+// GetCashFlowProjection projects future balance based on the active budget's
+// entries, starting from the user's account balances
+func GetCashFlowProjection(ctx context.Context, userID uuid.UUID, opts ProjectionOptions) (*CashFlowProjectionReport, error) {
+	entries, err := getActiveBudgetEntries(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get budget entries
-	entries, err := GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+	run, err := runCashFlowProjection(ctx, userID, entries, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get budget entries: %w", err)
+		return nil, err
 	}
 
-	// Project daily balance
-	projections := []DailyCashFlowProjection{}
-	currentBalance := startingBalance
-	today := time.Now()
-
-	for i := 0; i < days; i++ {
-		date := today.AddDate(0, 0, i)
-		dailyIncome := 0.0
-		dailyExpenses := 0.0
-
-		// Calculate expected income/expenses for this day based on budget entries
-		for _, entry := range entries {
-			if shouldOccurOnDate(entry, date) {
-				if entry.EntryType == "income" {
-					dailyIncome += entry.Amount
-				} else {
-					dailyExpenses += entry.Amount
-				}
-			}
-		}
-
-		currentBalance += dailyIncome - dailyExpenses
+	report := &CashFlowProjectionReport{
+		StartingBalance:   run.startingBalance,
+		BalanceSource:     run.balanceSource,
+		AccountID:         run.accountID,
+		LowestBalance:     run.lowestBalance,
+		LowestBalanceDate: run.lowestBalanceDate,
+		Overdue:           run.overdue,
+		Projections:       make([]DailyCashFlowProjection, 0, len(run.days)),
+	}
 
-		projections = append(projections, DailyCashFlowProjection{
-			Date:              date.Format("2006-01-02"),
-			ProjectedIncome:   dailyIncome,
-			ProjectedExpenses: dailyExpenses,
-			ProjectedBalance:  currentBalance,
+	for _, day := range run.days {
+		report.Projections = append(report.Projections, DailyCashFlowProjection{
+			Date:              day.date,
+			ProjectedIncome:   day.income,
+			ProjectedExpenses: day.expenses,
+			ProjectedBalance:  day.balance,
 		})
 	}
 
-	return projections, nil
+	return report, nil
 }
 
-// shouldOccurOnDate checks if a budget entry should occur on a given date
-func shouldOccurOnDate(entry BudgetEntry, date time.Time) bool {
-	startDate, err := time.Parse("2006-01-02", entry.StartDate)
-	if err != nil || date.Before(startDate) {
-		return false
+// getActiveBudgetEntries returns the active budget's entries. Without an active
+// budget there is nothing scheduled, so projections keep the balance flat.
+func getActiveBudgetEntries(ctx context.Context, userID uuid.UUID) ([]BudgetEntry, error) {
+	activeBudget, err := GetActiveBudget(ctx, userID)
+	if err != nil || activeBudget == nil {
+		return []BudgetEntry{}, nil
 	}
 
-	// Check end date if set
-	if entry.EndDate != nil {
-		endDate, err := time.Parse("2006-01-02", *entry.EndDate)
-		if err == nil && date.After(endDate) {
-			return false
-		}
+	entries, err := GetBudgetEntriesByBudgetID(ctx, activeBudget.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget entries: %w", err)
 	}
 
-	switch entry.Frequency {
-	case "once_off":
-		return date.Format("2006-01-02") == entry.StartDate
+	return entries, nil
+}
 
-	case "daily":
-		return true
+// GetTopExpenses returns the highest spending categories, grouped according to opts
+func GetTopExpenses(ctx context.Context, userID uuid.UUID, startDate, endDate string, limit int, opts CategoryRollupOptions) ([]TopExpense, error) {
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
 
-	case "weekly":
-		if entry.DayOfWeek != nil {
-			return int(date.Weekday()) == *entry.DayOfWeek
-		}
-		return false
+	amounts, err := getExpenseAmountsByCategory(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
 
-	case "fortnightly":
-		daysSince := date.Sub(startDate).Hours() / 24
-		weeksSince := int(daysSince / 7)
-		if weeksSince%2 == 0 && entry.DayOfWeek != nil {
-			return int(date.Weekday()) == *entry.DayOfWeek
+	grouped := make(map[string]*TopExpense)
+	totalExpenses := 0.0
+	for _, a := range amounts {
+		group, ok := tree.groupFor(a.CategoryID, opts)
+		if !ok {
+			continue
+		}
+		totalExpenses += a.Amount
+
+		key := categoryKey(group)
+		expense, exists := grouped[key]
+		if !exists {
+			expense = &TopExpense{
+				CategoryID:   key,
+				CategoryName: tree.name(group),
+			}
+			if group != nil {
+				expense.ParentCategoryID = uuidString(tree.parent(*group))
+				expense.Depth = tree.depth(*group)
+			}
+			grouped[key] = expense
 		}
-		return false
+		expense.TotalAmount += a.Amount
+		expense.Count += a.Count
+	}
 
-	case "monthly":
-		if entry.DayOfMonth != nil {
-			return date.Day() == *entry.DayOfMonth
+	topExpenses := make([]TopExpense, 0, len(grouped))
+	for _, expense := range grouped {
+		// Calculate percentage
+		if totalExpenses > 0 {
+			expense.Percentage = (expense.TotalAmount / totalExpenses) * 100
 		}
-		return false
+		topExpenses = append(topExpenses, *expense)
+	}
+	sort.Slice(topExpenses, func(i, j int) bool {
+		return topExpenses[i].TotalAmount > topExpenses[j].TotalAmount
+	})
+
+	if len(topExpenses) > limit {
+		topExpenses = topExpenses[:limit]
+	}
 
-	case "annually":
-		return date.Month() == startDate.Month() && date.Day() == startDate.Day()
+	return topExpenses, nil
+}
 
-	default:
-		return false
+// GetExpenseRollup returns expenses in a date range as a category tree with
+// subtotals at every level
+func GetExpenseRollup(ctx context.Context, userID uuid.UUID, startDate, endDate string, opts CategoryRollupOptions) ([]CategoryRollup, error) {
+	tree, err := loadCategoryTree(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	amounts, err := getExpenseAmountsByCategory(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
+
+	return tree.buildCategoryRollup(amounts, opts), nil
 }
 
-// GetTopExpenses returns the highest spending categories
-func GetTopExpenses(ctx context.Context, userID uuid.UUID, startDate, endDate string, limit int) ([]TopExpense, error) {
-	// First get total expenses for percentage calculation
-	var totalExpenses float64
-	totalQuery := `
-		SELECT COALESCE(SUM(amount), 0)
+// getExpenseAmountsByCategory totals expenses per leaf category in a date range
+func getExpenseAmountsByCategory(ctx context.Context, userID uuid.UUID, startDate, endDate string) ([]categoryAmount, error) {
+	query := `
+		SELECT category_id, SUM(amount) as total_amount, COUNT(*) as count
 		FROM budget.transactions
 		WHERE user_id = $1
 			AND transaction_type = 'expense'
 			AND transaction_date >= $2
 			AND transaction_date <= $3
-			AND deleted_at IS NULL
+		GROUP BY category_id
 	`
-	err := database.DB.QueryRow(ctx, totalQuery, userID, startDate, endDate).Scan(&totalExpenses)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get total expenses: %w", err)
-	}
 
-	// Get top expenses by category
-	query := `
-		SELECT
-			COALESCE(t.category_id::text, 'uncategorized') as category_id,
-			COALESCE(c.name, 'Uncategorized') as category_name,
-			SUM(t.amount) as total_amount,
-			COUNT(*) as count
-		FROM budget.transactions t
-		LEFT JOIN budget.categories c ON t.category_id = c.id
-		WHERE t.user_id = $1
-			AND t.transaction_type = 'expense'
-			AND t.transaction_date >= $2
-			AND t.transaction_date <= $3
-			AND t.deleted_at IS NULL
-		GROUP BY category_id, category_name
-		ORDER BY total_amount DESC
-		LIMIT $4
-	`
-
-	rows, err := database.DB.Query(ctx, query, userID, startDate, endDate, limit)
+	rows, err := database.DB.Query(ctx, query, userID, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query top expenses: %w", err)
+		return nil, fmt.Errorf("failed to query expenses by category: %w", err)
 	}
 	defer rows.Close()
 
-	var topExpenses []TopExpense
+	var amounts []categoryAmount
 	for rows.Next() {
-		var expense TopExpense
-		if err := rows.Scan(&expense.CategoryID, &expense.CategoryName, &expense.TotalAmount, &expense.Count); err != nil {
-			return nil, fmt.Errorf("failed to scan top expense: %w", err)
+		var a categoryAmount
+		if err := rows.Scan(&a.CategoryID, &a.Amount, &a.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan category expenses: %w", err)
 		}
+		amounts = append(amounts, a)
+	}
 
-		// Calculate percentage
-		if totalExpenses > 0 {
-			expense.Percentage = (expense.TotalAmount / totalExpenses) * 100
-		}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating category expenses: %w", err)
+	}
+
+	return amounts, nil
+}
 
-		topExpenses = append(topExpenses, expense)
+// categoryKey returns a category ID as a string, or "uncategorized" for nil
+func categoryKey(categoryID *uuid.UUID) string {
+	if categoryID == nil {
+		return "uncategorized"
 	}
+	return categoryID.String()
+}
 
-	return topExpenses, nil
+// uuidString converts an optional UUID to an optional string
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	str := id.String()
+	return &str
 }
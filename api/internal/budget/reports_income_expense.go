@@ -0,0 +1,326 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// rollingWindows are the month counts rolling averages are reported for
+var rollingWindows = []int{3, 6, 12}
+
+// savingsTrendThreshold is how many percentage points the savings rate must
+// move between consecutive quarters before the trend counts as a change
+const savingsTrendThreshold = 1.0
+
+// IncomeExpenseAverage is an average over the trailing N months
+type IncomeExpenseAverage struct {
+	Months      int     `json:"months"`
+	Income      float64 `json:"income"`
+	Expenses    float64 `json:"expenses"`
+	Net         float64 `json:"net"`
+	SavingsRate float64 `json:"savings_rate"` // Percentage of income kept, from the window's totals
+	Complete    bool    `json:"complete"`     // False when fewer than N months of history exist
+}
+
+// IncomeExpenseMonth is a single month of income vs expenses
+type IncomeExpenseMonth struct {
+	Month       string                 `json:"month"` // YYYY-MM format
+	Income      float64                `json:"income"`
+	Expenses    float64                `json:"expenses"`
+	Net         float64                `json:"net"`
+	SavingsRate float64                `json:"savings_rate"` // (income - expenses) / income * 100
+	Rolling     []IncomeExpenseAverage `json:"rolling"`
+}
+
+// IncomeCategoryTotal is income received in one category over the range
+type IncomeCategoryTotal struct {
+	CategoryID string  `json:"category_id"`
+	Category   string  `json:"category"`
+	Amount     float64 `json:"amount"`
+	Percentage float64 `json:"percentage"` // Percentage of total income
+	Count      int     `json:"count"`      // Number of transactions
+}
+
+// IncomeExpenseReport shows income, expenses and savings rate month by month
+type IncomeExpenseReport struct {
+	StartMonth       string                `json:"start_month"`
+	EndMonth         string                `json:"end_month"`
+	Months           []IncomeExpenseMonth  `json:"months"`
+	TotalIncome      float64               `json:"total_income"`
+	TotalExpenses    float64               `json:"total_expenses"`
+	TotalNet         float64               `json:"total_net"`
+	SavingsRate      float64               `json:"savings_rate"`
+	SavingsTrend     string                `json:"savings_trend"` // 'improving', 'declining', 'stable', 'insufficient_data'
+	IncomeByCategory []IncomeCategoryTotal `json:"income_by_category"`
+}
+
+// GetIncomeExpenseReportHandler returns monthly income vs expenses with savings rate
+func GetIncomeExpenseReportHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	// Range is given in months (YYYY-MM); defaults to the last 12 months
+	now := time.Now()
+	endMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	startMonth := endMonth.AddDate(0, -11, 0)
+
+	if end := c.Query("end_month"); end != "" {
+		parsed, err := time.Parse("2006-01", end)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid end_month format (use YYYY-MM)",
+			})
+		}
+		endMonth = parsed
+		startMonth = endMonth.AddDate(0, -11, 0)
+	}
+	if start := c.Query("start_month"); start != "" {
+		parsed, err := time.Parse("2006-01", start)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid start_month format (use YYYY-MM)",
+			})
+		}
+		startMonth = parsed
+	}
+
+	if startMonth.After(endMonth) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "start_month must not be after end_month",
+		})
+	}
+	if startMonth.AddDate(10, 0, 0).Before(endMonth) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Range cannot exceed 10 years",
+		})
+	}
+
+	report, err := GetIncomeExpenseReport(c.Context(), userID, startMonth, endMonth)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get income vs expense report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetIncomeExpenseReport builds the income vs expense report for the months
+// from startMonth to endMonth inclusive. Rolling averages look back before
+// startMonth so the first months in the range have full windows.
+func GetIncomeExpenseReport(ctx context.Context, userID uuid.UUID, startMonth, endMonth time.Time) (*IncomeExpenseReport, error) {
+	maxWindow := rollingWindows[len(rollingWindows)-1]
+	historyStart := startMonth.AddDate(0, -(maxWindow - 1), 0)
+	rangeEnd := endMonth.AddDate(0, 1, -1)
+
+	totals, err := getMonthlyIncomeExpenseTotals(ctx, userID, historyStart, rangeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Windows that reach back before the user's first transaction are flagged incomplete
+	firstActivity, err := getFirstTransactionMonth(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a contiguous series of months, filling gaps with zero
+	var series []IncomeExpenseMonth
+	for month := historyStart; !month.After(endMonth); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		m := totals[key]
+		m.Month = key
+		m.Net = m.Income - m.Expenses
+		m.SavingsRate = savingsRate(m.Income, m.Expenses)
+		series = append(series, m)
+	}
+
+	report := &IncomeExpenseReport{
+		StartMonth: startMonth.Format("2006-01"),
+		EndMonth:   endMonth.Format("2006-01"),
+		Months:     []IncomeExpenseMonth{},
+	}
+
+	offset := maxWindow - 1 // Index of startMonth within series
+	for i := offset; i < len(series); i++ {
+		month := series[i]
+		monthStart, _ := time.Parse("2006-01", month.Month)
+
+		for _, window := range rollingWindows {
+			var income, expenses float64
+			for _, m := range series[i-window+1 : i+1] {
+				income += m.Income
+				expenses += m.Expenses
+			}
+			windowStart := monthStart.AddDate(0, -(window - 1), 0)
+			month.Rolling = append(month.Rolling, IncomeExpenseAverage{
+				Months:      window,
+				Income:      income / float64(window),
+				Expenses:    expenses / float64(window),
+				Net:         (income - expenses) / float64(window),
+				SavingsRate: savingsRate(income, expenses),
+				Complete:    firstActivity != nil && !windowStart.Before(*firstActivity),
+			})
+		}
+
+		report.Months = append(report.Months, month)
+		report.TotalIncome += month.Income
+		report.TotalExpenses += month.Expenses
+	}
+
+	report.TotalNet = report.TotalIncome - report.TotalExpenses
+	report.SavingsRate = savingsRate(report.TotalIncome, report.TotalExpenses)
+	report.SavingsTrend = savingsTrend(series)
+
+	report.IncomeByCategory, err = getIncomeByCategory(ctx, userID, startMonth, rangeEnd, report.TotalIncome)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// getMonthlyIncomeExpenseTotals sums income and expenses per month (YYYY-MM)
+func getMonthlyIncomeExpenseTotals(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (map[string]IncomeExpenseMonth, error) {
+	query := `
+		SELECT
+			TO_CHAR(transaction_date, 'YYYY-MM') as month,
+			COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'income'), 0) as income,
+			COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'expense'), 0) as expenses
+		FROM budget.transactions
+		WHERE user_id = $1
+			AND transaction_date >= $2
+			AND transaction_date <= $3
+		GROUP BY month
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query monthly income and expenses: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]IncomeExpenseMonth)
+	for rows.Next() {
+		var m IncomeExpenseMonth
+		if err := rows.Scan(&m.Month, &m.Income, &m.Expenses); err != nil {
+			return nil, fmt.Errorf("failed to scan monthly totals: %w", err)
+		}
+		totals[m.Month] = m
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating monthly totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// getFirstTransactionMonth returns the first day of the month of the user's
+// earliest transaction, or nil if they have none
+func getFirstTransactionMonth(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	var first *time.Time
+	err := database.DB.QueryRow(ctx, `
+		SELECT DATE_TRUNC('month', MIN(transaction_date))::date
+		FROM budget.transactions
+		WHERE user_id = $1
+	`, userID).Scan(&first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query first transaction: %w", err)
+	}
+
+	return first, nil
+}
+
+// getIncomeByCategory totals income per category over a date range
+func getIncomeByCategory(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time, totalIncome float64) ([]IncomeCategoryTotal, error) {
+	query := `
+		SELECT
+			COALESCE(t.category_id::text, 'uncategorized') as category_id,
+			COALESCE(c.name, 'Uncategorized') as category,
+			SUM(t.amount) as amount,
+			COUNT(*) as count
+		FROM budget.transactions t
+		LEFT JOIN budget.categories c ON t.category_id = c.id
+		WHERE t.user_id = $1
+			AND t.transaction_type = 'income'
+			AND t.transaction_date >= $2
+			AND t.transaction_date <= $3
+		GROUP BY t.category_id, c.name
+		ORDER BY amount DESC
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query income by category: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []IncomeCategoryTotal{}
+	for rows.Next() {
+		var total IncomeCategoryTotal
+		if err := rows.Scan(&total.CategoryID, &total.Category, &total.Amount, &total.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan income category: %w", err)
+		}
+		if totalIncome > 0 {
+			total.Percentage = (total.Amount / totalIncome) * 100
+		}
+		categories = append(categories, total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating income categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// savingsRate returns the percentage of income not spent, or 0 with no income
+func savingsRate(income, expenses float64) float64 {
+	if income <= 0 {
+		return 0
+	}
+	return ((income - expenses) / income) * 100
+}
+
+// savingsTrend compares the savings rate of the last three months with the
+// three months before them
+func savingsTrend(series []IncomeExpenseMonth) string {
+	if len(series) < 6 {
+		return "insufficient_data"
+	}
+
+	rate := func(months []IncomeExpenseMonth) (float64, bool) {
+		var income, expenses float64
+		for _, m := range months {
+			income += m.Income
+			expenses += m.Expenses
+		}
+		return savingsRate(income, expenses), income > 0
+	}
+
+	n := len(series)
+	recent, recentOK := rate(series[n-3:])
+	previous, previousOK := rate(series[n-6 : n-3])
+	if !recentOK || !previousOK {
+		return "insufficient_data"
+	}
+
+	switch {
+	case recent-previous > savingsTrendThreshold:
+		return "improving"
+	case previous-recent > savingsTrendThreshold:
+		return "declining"
+	default:
+		return "stable"
+	}
+}
@@ -0,0 +1,261 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/brendenbissett/help-me-budget/api/internal/export"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TagSpending is the total of one tag's transactions in a date range, split by category
+type TagSpending struct {
+	TagID       uuid.UUID          `json:"tag_id"`
+	TagName     string             `json:"tag_name"`
+	Color       *string            `json:"color,omitempty"`
+	TotalAmount float64            `json:"total_amount"`
+	Percentage  float64            `json:"percentage"` // Of all transactions of the report's type; tags overlap, so these can add up past 100
+	Count       int                `json:"count"`
+	Categories  []TagCategoryTotal `json:"categories"` // Largest first
+}
+
+// TagCategoryTotal is the part of a tag's total in one category
+type TagCategoryTotal struct {
+	CategoryID   *uuid.UUID `json:"category_id,omitempty"`
+	CategoryName string     `json:"category_name"`
+	TotalAmount  float64    `json:"total_amount"`
+	Count        int        `json:"count"`
+}
+
+// TagSpendingReport lists spending per tag, alongside how much wasn't tagged at all
+type TagSpendingReport struct {
+	StartDate       string        `json:"start_date"`
+	EndDate         string        `json:"end_date"`
+	TransactionType string        `json:"transaction_type"` // 'expense' or 'income'
+	TotalAmount     float64       `json:"total_amount"`     // All transactions of the type, tagged or not
+	UntaggedAmount  float64       `json:"untagged_amount"`
+	Tags            []TagSpending `json:"tags"` // Largest first
+}
+
+// GetTagSpendingHandler returns spending per tag for a date range
+// (supports ?start_date=&end_date=&type=expense|income&format=csv|xlsx|pdf)
+func GetTagSpendingHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
+	}
+
+	// Default to current month if not provided
+	now := time.Now()
+	startDate := c.Query("start_date", time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02"))
+	endDate := c.Query("end_date", now.Format("2006-01-02"))
+
+	transactionType := c.Query("type", "expense")
+	if transactionType != "expense" && transactionType != "income" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Type must be 'expense' or 'income'",
+		})
+	}
+
+	report, err := GetTagSpending(c.Context(), userID, startDate, endDate, transactionType)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get tag spending",
+		})
+	}
+
+	if format != "" {
+		return sendExport(c, userID, format, "tag-spending", tagSpendingExport(report))
+	}
+
+	return c.JSON(report)
+}
+
+// GetTagSpending totals each tag's transactions of one type in a date range.
+// A transaction with several tags counts towards each of them.
+func GetTagSpending(ctx context.Context, userID uuid.UUID, startDate, endDate, transactionType string) (*TagSpendingReport, error) {
+	report := &TagSpendingReport{
+		StartDate:       startDate,
+		EndDate:         endDate,
+		TransactionType: transactionType,
+		Tags:            []TagSpending{},
+	}
+
+	total, untagged, err := getTagSpendingTotals(ctx, userID, startDate, endDate, transactionType)
+	if err != nil {
+		return nil, err
+	}
+	report.TotalAmount = total
+	report.UntaggedAmount = untagged
+
+	categories, err := GetCategoriesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	categoryNames := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	rows, err := getTagCategoryTotals(ctx, userID, startDate, endDate, transactionType)
+	if err != nil {
+		return nil, err
+	}
+
+	byTag := make(map[uuid.UUID]*TagSpending)
+	order := []uuid.UUID{}
+	for _, row := range rows {
+		spending, ok := byTag[row.tag.ID]
+		if !ok {
+			spending = &TagSpending{
+				TagID:      row.tag.ID,
+				TagName:    row.tag.Name,
+				Color:      row.tag.Color,
+				Categories: []TagCategoryTotal{},
+			}
+			byTag[row.tag.ID] = spending
+			order = append(order, row.tag.ID)
+		}
+
+		name := "Uncategorized"
+		if row.categoryID != nil {
+			name = categoryNames[*row.categoryID]
+		}
+		spending.Categories = append(spending.Categories, TagCategoryTotal{
+			CategoryID:   row.categoryID,
+			CategoryName: name,
+			TotalAmount:  row.amount,
+			Count:        row.count,
+		})
+		spending.TotalAmount += row.amount
+		spending.Count += row.count
+	}
+
+	for _, tagID := range order {
+		spending := byTag[tagID]
+		if report.TotalAmount > 0 {
+			spending.Percentage = (spending.TotalAmount / report.TotalAmount) * 100
+		}
+		sort.SliceStable(spending.Categories, func(i, j int) bool {
+			return spending.Categories[i].TotalAmount > spending.Categories[j].TotalAmount
+		})
+		report.Tags = append(report.Tags, *spending)
+	}
+	sort.SliceStable(report.Tags, func(i, j int) bool {
+		return report.Tags[i].TotalAmount > report.Tags[j].TotalAmount
+	})
+
+	return report, nil
+}
+
+// tagCategoryTotal is one tag's total in one category
+type tagCategoryTotal struct {
+	tag        Tag
+	categoryID *uuid.UUID
+	amount     float64
+	count      int
+}
+
+// getTagCategoryTotals sums tagged transactions of one type by tag and category
+func getTagCategoryTotals(ctx context.Context, userID uuid.UUID, startDate, endDate, transactionType string) ([]tagCategoryTotal, error) {
+	query := `
+		SELECT tg.id, tg.name, tg.color, t.category_id, SUM(t.amount) as total_amount, COUNT(*) as count
+		FROM budget.transaction_tags tt
+		JOIN budget.tags tg ON tg.id = tt.tag_id
+		JOIN budget.transactions t ON t.id = tt.transaction_id
+		WHERE tg.user_id = $1
+			AND t.user_id = $1
+			AND t.transaction_type = $4
+			AND t.transaction_date >= $2
+			AND t.transaction_date <= $3
+		GROUP BY tg.id, tg.name, tg.color, t.category_id
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, startDate, endDate, transactionType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag spending: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []tagCategoryTotal
+	for rows.Next() {
+		var total tagCategoryTotal
+		if err := rows.Scan(&total.tag.ID, &total.tag.Name, &total.tag.Color, &total.categoryID, &total.amount, &total.count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag spending: %w", err)
+		}
+		totals = append(totals, total)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag spending: %w", err)
+	}
+
+	return totals, nil
+}
+
+// getTagSpendingTotals sums all transactions of one type in a date range, and
+// those without any tag
+func getTagSpendingTotals(ctx context.Context, userID uuid.UUID, startDate, endDate, transactionType string) (total float64, untagged float64, err error) {
+	query := `
+		SELECT COALESCE(SUM(t.amount), 0),
+		       COALESCE(SUM(t.amount) FILTER (
+		           WHERE NOT EXISTS (SELECT 1 FROM budget.transaction_tags tt WHERE tt.transaction_id = t.id)
+		       ), 0)
+		FROM budget.transactions t
+		WHERE t.user_id = $1
+			AND t.transaction_type = $4
+			AND t.transaction_date >= $2
+			AND t.transaction_date <= $3
+	`
+
+	err = database.DB.QueryRow(ctx, query, userID, startDate, endDate, transactionType).Scan(&total, &untagged)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query spending totals: %w", err)
+	}
+
+	return total, untagged, nil
+}
+
+// tagSpendingExport lists each tag with its categories indented beneath it.
+// There's no totals row since tags overlap.
+func tagSpendingExport(report *TagSpendingReport) *export.Report {
+	title := "Spending by Tag"
+	if report.TransactionType == "income" {
+		title = "Income by Tag"
+	}
+
+	result := &export.Report{
+		Title:    title,
+		Metadata: []export.Field{dateRangeField(report.StartDate, report.EndDate)},
+		Columns: []export.Column{
+			{Header: "Tag", Type: export.Text},
+			{Header: "Transactions", Type: export.Number},
+			{Header: "Amount", Type: export.Currency},
+			{Header: "% of Total", Type: export.Percent},
+		},
+	}
+
+	for _, tag := range report.Tags {
+		result.Rows = append(result.Rows, []interface{}{tag.TagName, tag.Count, tag.TotalAmount, tag.Percentage})
+		for _, category := range tag.Categories {
+			result.Rows = append(result.Rows, []interface{}{"  " + category.CategoryName, category.Count, category.TotalAmount, nil})
+		}
+	}
+	result.Metadata = append(result.Metadata,
+		export.Field{Label: "Total", Value: fmt.Sprintf("%.2f", report.TotalAmount)},
+		export.Field{Label: "Untagged", Value: fmt.Sprintf("%.2f", report.UntaggedAmount)},
+	)
+
+	return result
+}
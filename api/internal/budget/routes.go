@@ -24,6 +24,13 @@ func SetupBudgetRoutes(app *fiber.App) {
 	categories.Delete("/:id", DeleteCategoryHandler)          // Delete category (soft delete)
 	categories.Post("/seed", SeedDefaultCategoriesHandler)    // Seed default categories for new user
 
+	// Tag management routes
+	tags := app.Group("/api/tags")
+	tags.Get("/", GetTagsHandler)                             // List tags with transaction counts
+	tags.Post("/", CreateTagHandler)                          // Create tag (names are lowercased, spaces become hyphens)
+	tags.Put("/:id", UpdateTagHandler)                        // Rename or recolor tag
+	tags.Delete("/:id", DeleteTagHandler)                     // Delete tag and remove it from all transactions
+
 	// Budget management routes
 	budgets := app.Group("/api/budgets")
 	budgets.Get("/", GetBudgetsHandler)                       // List all budgets
@@ -33,7 +40,7 @@ func SetupBudgetRoutes(app *fiber.App) {
 	budgets.Put("/:id", UpdateBudgetHandler)                  // Update budget
 	budgets.Delete("/:id", DeleteBudgetHandler)               // Delete budget (soft delete)
 	budgets.Get("/:id/summary", GetBudgetSummaryHandler)      // Get budget summary (income/expense totals, health)
-	budgets.Get("/:id/projection", ProjectCashFlowHandler)    // Project cash flow (supports ?days=90&starting_balance=1000)
+	budgets.Get("/:id/projection", ProjectCashFlowHandler)    // Project cash flow from account balances (supports ?days=90&account_id=uuid&starting_balance=1000&format=csv|xlsx|pdf)
 
 	// Budget entry routes (nested under budgets)
 	budgets.Get("/:id/entries", GetBudgetEntriesHandler)      // List all entries for a budget
@@ -43,7 +50,7 @@ func SetupBudgetRoutes(app *fiber.App) {
 
 	// Transaction management routes
 	transactions := app.Group("/api/transactions")
-	transactions.Get("/", GetTransactionsHandler)                      // List all transactions (supports filters: ?account_id=&category_id=&start_date=&end_date=)
+	transactions.Get("/", GetTransactionsHandler)                      // List all transactions (supports filters: ?account_id=&category_id=&start_date=&end_date=&tags=a,b&tag_match=any|all, ?format=csv|xlsx|pdf)
 	transactions.Get("/unmatched", GetUnmatchedTransactionsHandler)    // Get unmatched transactions
 	transactions.Get("/:id", GetTransactionHandler)                    // Get specific transaction
 	transactions.Post("/", CreateTransactionHandler)                   // Create new transaction
@@ -51,27 +58,96 @@ func SetupBudgetRoutes(app *fiber.App) {
 	transactions.Delete("/:id", DeleteTransactionHandler)              // Delete transaction
 	transactions.Post("/:id/categorize", CategorizeTransactionHandler) // Assign category to transaction
 	transactions.Post("/:id/link", LinkTransactionHandler)             // Link transaction to budget entry
+	transactions.Post("/:id/unlink", UnlinkTransactionHandler)         // Remove budget entry link (undoable via matching batch)
+	transactions.Put("/:id/tags", SetTransactionTagsHandler)           // Replace tags by name (creates missing tags; [] removes all)
+	transactions.Post("/bulk-tag", BulkTagTransactionsHandler)         // Add tags to many transactions
+	transactions.Post("/bulk-untag", BulkUntagTransactionsHandler)     // Remove tags from many transactions
+	transactions.Get("/:id/attachments", GetAttachmentsHandler)        // List attachments with signed download URLs (valid 15 minutes)
+	transactions.Post("/:id/attachments", UploadAttachmentHandler)     // Upload a receipt or document (multipart "file"; JPEG, PNG, GIF, WebP or PDF up to 10 MB)
+	transactions.Delete("/:id/attachments/:attachmentId", DeleteAttachmentHandler) // Delete attachment and its stored file
+	transactions.Post("/:id/attachments/:attachmentId/extract", ExtractAttachmentReceiptHandler) // Propose a transaction from an attached receipt (nothing is saved)
+
+	// Attachment download route (signed URLs only, used when storage is local)
+	app.Get("/api/attachments/:id/download", DownloadAttachmentHandler) // Stream the file (requires ?expires=&signature= from a download URL)
+
+	// Receipt extraction routes (OCR; returns proposed fields with confidence for the user to confirm)
+	app.Post("/api/receipts/extract", ExtractReceiptHandler) // Propose a transaction and line-item splits from a receipt (multipart "file"; optional account_id)
 
 	// Dashboard routes
 	dashboard := app.Group("/api/dashboard")
 	dashboard.Get("/summary", GetDashboardSummaryHandler)                // Get comprehensive dashboard overview
 	dashboard.Get("/recent-activity", GetRecentActivityHandler)          // Get recent transactions (supports ?limit=20)
-	dashboard.Get("/spending-by-category", GetSpendingByCategoryHandler) // Get spending breakdown (supports ?start_date=&end_date=)
+	dashboard.Get("/spending-by-category", GetSpendingByCategoryHandler) // Get spending breakdown (supports ?start_date=&end_date=&depth=&parent_id=)
 
 	// Matching/automation routes
 	matching := app.Group("/api/matching")
 	matching.Get("/suggestions/:id", GetSuggestedMatchesHandler)           // Get match suggestions for a transaction
 	matching.Post("/auto-match/:id", AutoMatchTransactionHandler)          // Auto-match a single transaction
-	matching.Post("/bulk-auto-match", BulkAutoMatchHandler)                // Auto-match all unmatched transactions
+	matching.Post("/bulk-auto-match", BulkAutoMatchHandler)                // Queue auto-match of all unmatched transactions as a background job (?dry_run=true previews synchronously)
+	matching.Post("/bulk-auto-match/commit", CommitBulkAutoMatchHandler)   // Apply a reviewed subset of dry-run proposals
 	matching.Post("/teach/:id", TeachMatchHandler)                         // Link transaction + create matching rules
+	matching.Get("/batches", GetMatchingBatchesHandler)                    // List recent matching batches (supports ?limit=20)
+	matching.Post("/batches/:id/undo", UndoMatchingBatchHandler)           // Restore transactions touched by a batch
+	matching.Get("/recurring-candidates", GetRecurringCandidatesHandler)   // Detect repeating charges without a budget entry (supports ?lookback_months=18&include_covered=true)
+	matching.Post("/recurring-candidates/:id/create-entry", CreateEntryFromCandidateHandler) // Create a budget entry with matching rules from a candidate
+	matching.Get("/price-changes", GetPriceChangesHandler)                 // Entries whose linked charges have settled at a new amount
+	matching.Post("/price-changes/:entryId/apply", ApplyPriceChangeHandler) // Update an entry to its new price (optional body {"amount"})
 
 	// Budget entry matching rules (nested under budgets)
 	budgets.Post("/:id/entries/:entryId/matching-rules", UpdateBudgetEntryMatchingRulesHandler) // Update matching rules for budget entry
+	budgets.Get("/:id/entries/:entryId/amount-history", GetEntryAmountHistoryHandler)         // Amounts charged against an entry over time
+
+	// What-if scenarios (nested under budgets)
+	budgets.Post("/:id/what-if", EvaluateScenarioHandler)                     // Compare budget vs hypothetical entry additions/removals/amount changes (body may include save_as to keep a draft)
+	budgets.Get("/:id/scenarios", GetScenariosHandler)                        // List saved scenario drafts
+	budgets.Get("/:id/scenarios/:scenarioId", GetScenarioHandler)             // Re-evaluate a saved draft (supports ?days=90&account_id=&starting_balance=)
+	budgets.Delete("/:id/scenarios/:scenarioId", DeleteScenarioHandler)       // Delete a saved draft
 
 	// Reports and analytics routes
 	reports := app.Group("/api/reports")
-	reports.Get("/spending-trends", GetSpendingTrendsHandler)           // Get spending trends by category over time (supports ?start_date=&end_date=)
-	reports.Get("/budget-variance", GetBudgetVarianceHandler)           // Get budget vs actual comparison (supports ?month=YYYY-MM)
-	reports.Get("/cash-flow-projection", GetCashFlowProjectionHandler) // Get projected cash flow (supports ?days=90&starting_balance=1000)
-	reports.Get("/top-expenses", GetTopExpensesHandler)                 // Get top spending categories (supports ?start_date=&end_date=&limit=10)
+	reports.Get("/spending-trends", GetSpendingTrendsHandler)           // Get spending trends by category over time (supports ?start_date=&end_date=&depth=&parent_id=&format=csv|xlsx|pdf)
+	reports.Get("/budget-variance", GetBudgetVarianceHandler)           // Get budget vs actual comparison (supports ?period=week|month|quarter|year|custom, ?date=, ?start_date=&end_date=, ?month=YYYY-MM, ?format=csv|xlsx|pdf)
+	reports.Get("/cash-flow-projection", GetCashFlowProjectionHandler) // Get projected cash flow from account balances (supports ?days=90&account_id=uuid&starting_balance=1000&format=csv|xlsx|pdf)
+	reports.Get("/top-expenses", GetTopExpensesHandler)                 // Get top spending categories (supports ?start_date=&end_date=&limit=10&depth=&parent_id=&nested=true&format=csv|xlsx|pdf)
+	reports.Get("/income-expense", GetIncomeExpenseReportHandler)       // Get monthly income vs expenses and savings rate (supports ?start_month=&end_month=YYYY-MM)
+	reports.Get("/comparison", GetComparisonReportHandler)               // Compare category totals between two ranges (supports ?base_start_date=&base_end_date=&compare_start_date=&compare_end_date= or ?period=&date=&compare_to=previous_period|previous_year, ?type=expense|income)
+	reports.Get("/categories/:id/drill-down", GetCategoryDrillDownHandler) // Drill into a category's subcategories and transactions (supports ?start_date=&end_date=)
+	reports.Get("/tags", GetTagSpendingHandler)                            // Spending per tag with a category breakdown (supports ?start_date=&end_date=&type=expense|income&format=csv|xlsx|pdf)
+	reports.Get("/anomalies", GetAnomaliesHandler)                       // Flag unusual transactions and category months (supports ?start_date=&end_date=&lookback_months=12&threshold=3.5)
+	reports.Get("/cash-flow-forecast", GetCashFlowForecastHandler)       // Simulate balance ranges (P10/P50/P90) and the risk of dropping below a threshold (supports ?days=90&account_id=&starting_balance=&threshold=0&simulations=1000&lookback_months=6&seed=)
+
+	// Notification routes
+	notifications := app.Group("/api/notifications")
+	notifications.Get("/", GetNotificationsHandler)                       // List notifications, newest first, with the unread count (supports ?unread=true&limit=50)
+	notifications.Get("/unread-count", GetUnreadNotificationCountHandler) // Count unread notifications
+	notifications.Post("/read-all", MarkAllNotificationsReadHandler)      // Mark every notification read
+	notifications.Post("/:id/read", MarkNotificationReadHandler)          // Mark a notification read
+	notifications.Post("/:id/unread", MarkNotificationUnreadHandler)      // Mark a notification unread
+
+	// Alert rule routes (rules are evaluated after transaction writes and daily)
+	alertRules := app.Group("/api/alert-rules")
+	alertRules.Get("/", GetAlertRulesHandler)           // List alert rules
+	alertRules.Post("/", CreateAlertRuleHandler)        // Create rule (category_spend, entry_spend, bill_due, bill_overdue, low_balance, large_transaction)
+	alertRules.Put("/:id", UpdateAlertRuleHandler)      // Update rule (type can't change; is_active=false pauses it)
+	alertRules.Delete("/:id", DeleteAlertRuleHandler)   // Delete rule (its notifications are kept)
+	alertRules.Post("/evaluate", EvaluateAlertsHandler) // Evaluate rules now and return any new notifications
+
+	// Email digest routes
+	digests := app.Group("/api/digests")
+	digests.Get("/settings", GetDigestSettingsHandler)    // Get digest schedule and whether email is configured
+	digests.Put("/settings", UpdateDigestSettingsHandler) // Change frequency, day, hour, timezone or opt out (enabled=false)
+	digests.Get("/preview", PreviewDigestHandler)         // Render the digest without sending it (supports ?frequency=weekly|monthly)
+	digests.Post("/test", SendTestDigestHandler)          // Email the digest now
+
+	// Data portability routes
+	app.Get("/api/export", ExportDataHandler)  // Download all budget data as a versioned archive (supports ?format=json|zip)
+	app.Post("/api/import", ImportDataHandler) // Restore an archive into an account with no data yet (JSON or ZIP, as the body or a multipart "file")
+
+	// Calendar feed routes
+	calendar := app.Group("/api/calendar")
+	calendar.Get("/feed", GetCalendarFeedHandler)       // Get the feed token and path, or enabled=false
+	calendar.Post("/feed", CreateCalendarFeedHandler)   // Turn on the feed, or replace its token to revoke old URLs
+	calendar.Put("/feed", UpdateCalendarFeedHandler)    // Change months_ahead (1-12)
+	calendar.Delete("/feed", DeleteCalendarFeedHandler) // Turn off the feed
+	calendar.Get("/:token.ics", GetCalendarICSHandler)  // iCalendar of bills and paydays, authenticated by the token alone
 }
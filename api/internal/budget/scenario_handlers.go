@@ -0,0 +1,231 @@
+package budget
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// EvaluateScenarioHandler compares a budget with a what-if version of it,
+// optionally saving the scenario as a named draft
+func EvaluateScenarioHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid budget ID",
+		})
+	}
+
+	var req ScenarioRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validateScenarioChanges(req.ScenarioChanges); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	opts := ProjectionOptions{
+		Days:            req.Days,
+		AccountID:       req.AccountID,
+		StartingBalance: req.StartingBalance,
+	}
+	if opts.Days < 1 {
+		opts.Days = 90
+	}
+	if opts.Days > 365 {
+		opts.Days = 365
+	}
+
+	comparison, err := EvaluateScenario(c.Context(), userID, budgetID, req.ScenarioChanges, opts)
+	if err != nil {
+		return scenarioError(c, budgetID, err)
+	}
+
+	if req.SaveAs != nil {
+		name := strings.TrimSpace(*req.SaveAs)
+		if name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Scenario name is required",
+			})
+		}
+
+		draft, err := SaveScenario(c.Context(), budgetID, userID, name, req.ScenarioChanges)
+		if err != nil {
+			log.Printf("Error saving scenario for budget %s: %v", budgetID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to save scenario",
+			})
+		}
+		comparison.Draft = draft
+	}
+
+	return c.JSON(comparison)
+}
+
+// GetScenariosHandler lists a budget's saved what-if drafts
+func GetScenariosHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid budget ID",
+		})
+	}
+
+	scenarios, err := GetScenariosByBudgetID(c.Context(), budgetID, userID)
+	if err != nil {
+		log.Printf("Error fetching scenarios for budget %s: %v", budgetID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch scenarios",
+		})
+	}
+
+	if scenarios == nil {
+		scenarios = []BudgetScenario{}
+	}
+
+	return c.JSON(scenarios)
+}
+
+// GetScenarioHandler re-evaluates a saved draft against the budget as it is now
+func GetScenarioHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid budget ID",
+		})
+	}
+
+	scenarioID, err := uuid.Parse(c.Params("scenarioId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid scenario ID",
+		})
+	}
+
+	opts, err := parseProjectionOptions(c.Query("days"), c.Query("account_id"), c.Query("starting_balance"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	draft, err := GetScenarioByID(c.Context(), scenarioID, budgetID, userID)
+	if err != nil {
+		if err.Error() == "scenario not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Scenario not found",
+			})
+		}
+		log.Printf("Error fetching scenario %s: %v", scenarioID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch scenario",
+		})
+	}
+
+	comparison, err := EvaluateScenario(c.Context(), userID, budgetID, draft.Changes, opts)
+	if err != nil {
+		// Entries the draft refers to may have been deleted since it was saved
+		if err.Error() == "scenario entry not found" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Scenario refers to entries that are no longer in this budget",
+				"draft": draft,
+			})
+		}
+		return scenarioError(c, budgetID, err)
+	}
+	comparison.Draft = draft
+
+	return c.JSON(comparison)
+}
+
+// DeleteScenarioHandler deletes a saved what-if draft
+func DeleteScenarioHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	budgetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid budget ID",
+		})
+	}
+
+	scenarioID, err := uuid.Parse(c.Params("scenarioId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid scenario ID",
+		})
+	}
+
+	err = DeleteScenario(c.Context(), scenarioID, budgetID, userID)
+	if err != nil {
+		if err.Error() == "scenario not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Scenario not found",
+			})
+		}
+		log.Printf("Error deleting scenario %s: %v", scenarioID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete scenario",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Scenario deleted successfully",
+	})
+}
+
+// scenarioError maps an EvaluateScenario error to a response
+func scenarioError(c *fiber.Ctx, budgetID uuid.UUID, err error) error {
+	switch err.Error() {
+	case "budget not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Budget not found",
+		})
+	case "account not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Account not found",
+		})
+	case "scenario entry not found":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Entries to remove or change must belong to this budget",
+		})
+	}
+
+	log.Printf("Error evaluating scenario for budget %s: %v", budgetID, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to evaluate scenario",
+	})
+}
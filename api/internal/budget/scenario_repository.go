@@ -0,0 +1,131 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const scenarioColumns = `id, user_id, budget_id, name, changes, created_at, updated_at`
+
+// scanScenario scans a single scenario row
+func scanScenario(row pgx.Row) (*BudgetScenario, error) {
+	var scenario BudgetScenario
+	var changes []byte
+	err := row.Scan(
+		&scenario.ID,
+		&scenario.UserID,
+		&scenario.BudgetID,
+		&scenario.Name,
+		&changes,
+		&scenario.CreatedAt,
+		&scenario.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &scenario.Changes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scenario changes: %w", err)
+	}
+	return &scenario, nil
+}
+
+// SaveScenario saves a what-if draft for a budget, replacing any draft of the same name
+func SaveScenario(ctx context.Context, budgetID uuid.UUID, userID uuid.UUID, name string, changes ScenarioChanges) (*BudgetScenario, error) {
+	// First verify the budget belongs to the user
+	if _, err := GetBudgetByID(ctx, budgetID, userID); err != nil {
+		return nil, err
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal scenario changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO budget.budget_scenarios (user_id, budget_id, name, changes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (budget_id, name) DO UPDATE
+		SET changes = EXCLUDED.changes, updated_at = CURRENT_TIMESTAMP
+		RETURNING ` + scenarioColumns
+
+	scenario, err := scanScenario(database.DB.QueryRow(ctx, query, userID, budgetID, name, changesJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save scenario: %w", err)
+	}
+
+	return scenario, nil
+}
+
+// GetScenariosByBudgetID retrieves all saved drafts for a budget
+func GetScenariosByBudgetID(ctx context.Context, budgetID uuid.UUID, userID uuid.UUID) ([]BudgetScenario, error) {
+	query := `
+		SELECT ` + scenarioColumns + `
+		FROM budget.budget_scenarios
+		WHERE budget_id = $1 AND user_id = $2
+		ORDER BY updated_at DESC
+	`
+
+	rows, err := database.DB.Query(ctx, query, budgetID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scenarios: %w", err)
+	}
+	defer rows.Close()
+
+	var scenarios []BudgetScenario
+	for rows.Next() {
+		scenario, err := scanScenario(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scenario: %w", err)
+		}
+		scenarios = append(scenarios, *scenario)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scenarios: %w", err)
+	}
+
+	return scenarios, nil
+}
+
+// GetScenarioByID retrieves a specific saved draft
+func GetScenarioByID(ctx context.Context, scenarioID uuid.UUID, budgetID uuid.UUID, userID uuid.UUID) (*BudgetScenario, error) {
+	query := `
+		SELECT ` + scenarioColumns + `
+		FROM budget.budget_scenarios
+		WHERE id = $1 AND budget_id = $2 AND user_id = $3
+	`
+
+	scenario, err := scanScenario(database.DB.QueryRow(ctx, query, scenarioID, budgetID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("scenario not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scenario: %w", err)
+	}
+
+	return scenario, nil
+}
+
+// DeleteScenario deletes a saved draft
+func DeleteScenario(ctx context.Context, scenarioID uuid.UUID, budgetID uuid.UUID, userID uuid.UUID) error {
+	query := `
+		DELETE FROM budget.budget_scenarios
+		WHERE id = $1 AND budget_id = $2 AND user_id = $3
+	`
+
+	result, err := database.DB.Exec(ctx, query, scenarioID, budgetID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete scenario: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("scenario not found")
+	}
+
+	return nil
+}
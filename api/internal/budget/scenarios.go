@@ -0,0 +1,213 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScenarioChanges are hypothetical edits layered over a budget's entries
+type ScenarioChanges struct {
+	Add    []CreateBudgetEntryRequest `json:"add"`
+	Remove []uuid.UUID                `json:"remove"` // Entry IDs to leave out
+	Change []ScenarioAmountChange     `json:"change"`
+}
+
+// ScenarioAmountChange sets a new amount for an existing entry
+type ScenarioAmountChange struct {
+	EntryID uuid.UUID `json:"entry_id"`
+	Amount  float64   `json:"amount"`
+}
+
+// ScenarioRequest is the body of a what-if request
+type ScenarioRequest struct {
+	ScenarioChanges
+	Days            int        `json:"days,omitempty"`             // Projection length, default 90
+	AccountID       *uuid.UUID `json:"account_id,omitempty"`       // Project a single account
+	StartingBalance *float64   `json:"starting_balance,omitempty"` // Overrides the account balance
+	SaveAs          *string    `json:"save_as,omitempty"`          // Save the scenario as a named draft
+}
+
+// BudgetScenario is a saved what-if draft
+type BudgetScenario struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	BudgetID  uuid.UUID       `json:"budget_id"`
+	Name      string          `json:"name"`
+	Changes   ScenarioChanges `json:"changes"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ScenarioOutcome is how a set of entries looks on paper and in the bank
+type ScenarioOutcome struct {
+	Summary    *BudgetSummary      `json:"summary"`
+	Health     *BudgetHealthStatus `json:"health"`
+	Projection *CashFlowProjection `json:"projection"`
+}
+
+// ScenarioDifference is the scenario minus the baseline
+type ScenarioDifference struct {
+	MonthlyIncome         float64 `json:"monthly_income"`
+	MonthlyExpenses       float64 `json:"monthly_expenses"`
+	MonthlySurplusDeficit float64 `json:"monthly_surplus_deficit"`
+	AnnualSurplusDeficit  float64 `json:"annual_surplus_deficit"`
+	HealthScore           int     `json:"health_score"`
+	EndingBalance         float64 `json:"ending_balance"`
+	LowestBalance         float64 `json:"lowest_balance"`
+}
+
+// ScenarioComparison shows a budget side by side with a what-if version of it
+type ScenarioComparison struct {
+	BudgetID   uuid.UUID          `json:"budget_id"`
+	Baseline   ScenarioOutcome    `json:"baseline"`
+	Scenario   ScenarioOutcome    `json:"scenario"`
+	Difference ScenarioDifference `json:"difference"`
+	Draft      *BudgetScenario    `json:"draft,omitempty"` // Set when the scenario was saved or loaded
+}
+
+// EvaluateScenario compares a budget with the same budget after changes are
+// applied. Nothing is written; hypothetical entries only exist for the
+// duration of the calculation.
+func EvaluateScenario(ctx context.Context, userID uuid.UUID, budgetID uuid.UUID, changes ScenarioChanges, opts ProjectionOptions) (*ScenarioComparison, error) {
+	entries, err := GetBudgetEntriesByBudgetID(ctx, budgetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scenarioEntries, err := applyScenarioChanges(budgetID, entries, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline, err := evaluateEntries(ctx, userID, budgetID, entries, opts)
+	if err != nil {
+		return nil, err
+	}
+	scenario, err := evaluateEntries(ctx, userID, budgetID, scenarioEntries, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScenarioComparison{
+		BudgetID: budgetID,
+		Baseline: *baseline,
+		Scenario: *scenario,
+		Difference: ScenarioDifference{
+			MonthlyIncome:         scenario.Summary.TotalMonthlyIncome - baseline.Summary.TotalMonthlyIncome,
+			MonthlyExpenses:       scenario.Summary.TotalMonthlyExpenses - baseline.Summary.TotalMonthlyExpenses,
+			MonthlySurplusDeficit: scenario.Summary.MonthlySurplusDeficit - baseline.Summary.MonthlySurplusDeficit,
+			AnnualSurplusDeficit:  scenario.Summary.AnnualSurplusDeficit - baseline.Summary.AnnualSurplusDeficit,
+			HealthScore:           scenario.Health.Score - baseline.Health.Score,
+			EndingBalance:         scenario.Projection.EndingBalance - baseline.Projection.EndingBalance,
+			LowestBalance:         scenario.Projection.LowestBalance - baseline.Projection.LowestBalance,
+		},
+	}, nil
+}
+
+// evaluateEntries summarizes, scores and projects a set of entries
+func evaluateEntries(ctx context.Context, userID uuid.UUID, budgetID uuid.UUID, entries []BudgetEntry, opts ProjectionOptions) (*ScenarioOutcome, error) {
+	summary := summarizeBudgetEntries(budgetID, entries)
+
+	projection, err := projectBudgetEntries(ctx, userID, entries, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScenarioOutcome{
+		Summary:    summary,
+		Health:     GetBudgetHealthStatus(summary),
+		Projection: projection,
+	}, nil
+}
+
+// applyScenarioChanges returns a copy of entries with the changes applied.
+// Amount changes and removals must refer to entries in the budget.
+func applyScenarioChanges(budgetID uuid.UUID, entries []BudgetEntry, changes ScenarioChanges) ([]BudgetEntry, error) {
+	index := make(map[uuid.UUID]int, len(entries))
+	for i, entry := range entries {
+		index[entry.ID] = i
+	}
+
+	amounts := make(map[uuid.UUID]float64)
+	for _, change := range changes.Change {
+		if _, ok := index[change.EntryID]; !ok {
+			return nil, fmt.Errorf("scenario entry not found")
+		}
+		amounts[change.EntryID] = change.Amount
+	}
+
+	removed := make(map[uuid.UUID]bool)
+	for _, entryID := range changes.Remove {
+		if _, ok := index[entryID]; !ok {
+			return nil, fmt.Errorf("scenario entry not found")
+		}
+		removed[entryID] = true
+	}
+
+	result := make([]BudgetEntry, 0, len(entries)+len(changes.Add))
+	for _, entry := range entries {
+		if removed[entry.ID] {
+			continue
+		}
+		if amount, ok := amounts[entry.ID]; ok {
+			entry.Amount = amount
+		}
+		result = append(result, entry)
+	}
+
+	now := time.Now()
+	for _, req := range changes.Add {
+		result = append(result, BudgetEntry{
+			ID:            uuid.New(),
+			BudgetID:      budgetID,
+			CategoryID:    req.CategoryID,
+			Name:          req.Name,
+			Description:   req.Description,
+			Amount:        req.Amount,
+			EntryType:     req.EntryType,
+			Frequency:     req.Frequency,
+			DayOfMonth:    req.DayOfMonth,
+			DayOfWeek:     req.DayOfWeek,
+			StartDate:     req.StartDate,
+			EndDate:       req.EndDate,
+			MatchingRules: req.MatchingRules,
+			IsActive:      true,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+
+	return result, nil
+}
+
+// validateScenarioChanges checks a scenario's edits before it's evaluated or saved
+func validateScenarioChanges(changes ScenarioChanges) error {
+	if len(changes.Add) == 0 && len(changes.Remove) == 0 && len(changes.Change) == 0 {
+		return fmt.Errorf("Scenario must add, remove or change at least one entry")
+	}
+
+	for _, req := range changes.Add {
+		if err := validateBudgetEntryRequest(req); err != nil {
+			return err
+		}
+		if _, err := time.Parse("2006-01-02", req.StartDate); err != nil {
+			return fmt.Errorf("Invalid start_date for added entry %q (use YYYY-MM-DD)", req.Name)
+		}
+		if req.EndDate != nil {
+			if _, err := time.Parse("2006-01-02", *req.EndDate); err != nil {
+				return fmt.Errorf("Invalid end_date for added entry %q (use YYYY-MM-DD)", req.Name)
+			}
+		}
+	}
+
+	for _, change := range changes.Change {
+		if change.Amount <= 0 {
+			return fmt.Errorf("Amount must be greater than zero")
+		}
+	}
+
+	return nil
+}
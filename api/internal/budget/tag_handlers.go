@@ -0,0 +1,317 @@
+package budget
+
+import (
+	"log"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetTagsHandler returns all of a user's tags with how many transactions use each
+func GetTagsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	tags, err := GetTagsByUserID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve tags",
+		})
+	}
+
+	if tags == nil {
+		tags = []Tag{}
+	}
+
+	return c.JSON(fiber.Map{
+		"tags": tags,
+	})
+}
+
+// CreateTagHandler creates a tag
+func CreateTagHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Name = normalizeTagName(req.Name)
+	if err := validateTagName(req.Name); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := validateTagColor(req.Color); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	tag, err := CreateTag(c.Context(), userID, req)
+	if err != nil {
+		if err.Error() == "tag already exists" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A tag with this name already exists",
+			})
+		}
+		log.Printf("Error creating tag for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create tag",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(tag)
+}
+
+// UpdateTagHandler renames or recolors a tag
+func UpdateTagHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	tagID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID",
+		})
+	}
+
+	var req UpdateTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Name != nil {
+		name := normalizeTagName(*req.Name)
+		if err := validateTagName(name); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		req.Name = &name
+	}
+	if err := validateTagColor(req.Color); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	tag, err := UpdateTag(c.Context(), tagID, userID, req)
+	if err != nil {
+		switch err.Error() {
+		case "tag not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tag not found",
+			})
+		case "tag already exists":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "A tag with this name already exists",
+			})
+		}
+		log.Printf("Error updating tag %s: %v", tagID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update tag",
+		})
+	}
+
+	return c.JSON(tag)
+}
+
+// DeleteTagHandler deletes a tag and removes it from every transaction
+func DeleteTagHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	tagID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tag ID",
+		})
+	}
+
+	if err := DeleteTag(c.Context(), tagID, userID); err != nil {
+		if err.Error() == "tag not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tag not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete tag",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// SetTransactionTagsHandler replaces a transaction's tags. Tags are given by
+// name and created if they don't exist; an empty list removes all tags.
+func SetTransactionTagsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	names, err := normalizeTagNames(req.Tags)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	tags, err := SetTransactionTags(c.Context(), transactionID, userID, names)
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		log.Printf("Error setting tags on transaction %s: %v", transactionID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update transaction tags",
+		})
+	}
+
+	events.Publish(c.Context(), userID, events.TransactionUpdated, fiber.Map{
+		"id":   transactionID,
+		"tags": tags,
+	})
+
+	return c.JSON(fiber.Map{
+		"transaction_id": transactionID,
+		"tags":           tags,
+	})
+}
+
+// BulkTagTransactionsHandler adds tags to many transactions, creating tags
+// that don't exist yet
+func BulkTagTransactionsHandler(c *fiber.Ctx) error {
+	return bulkTagHandler(c, true)
+}
+
+// BulkUntagTransactionsHandler removes tags from many transactions
+func BulkUntagTransactionsHandler(c *fiber.Ctx) error {
+	return bulkTagHandler(c, false)
+}
+
+// bulkTagHandler validates a BulkTagRequest and tags or untags its transactions.
+// IDs that aren't the user's transactions are reported back rather than failing
+// the request.
+func bulkTagHandler(c *fiber.Ctx, add bool) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req BulkTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.TransactionIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one transaction ID is required",
+		})
+	}
+	if len(req.TransactionIDs) > maxBulkTagTransactions {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Too many transactions (maximum 1000 per request)",
+		})
+	}
+	if len(req.Tags) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one tag is required",
+		})
+	}
+
+	names, err := normalizeTagNames(req.Tags)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var found []uuid.UUID
+	var changed int
+	if add {
+		found, changed, err = BulkTagTransactions(c.Context(), userID, req.TransactionIDs, names)
+	} else {
+		found, changed, err = BulkUntagTransactions(c.Context(), userID, req.TransactionIDs, names)
+	}
+	if err != nil {
+		log.Printf("Error bulk updating tags for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update transaction tags",
+		})
+	}
+
+	isFound := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		isFound[id] = true
+	}
+	notFound := []uuid.UUID{}
+	for _, id := range req.TransactionIDs {
+		if !isFound[id] {
+			notFound = append(notFound, id)
+		}
+	}
+
+	if changed > 0 {
+		events.Publish(c.Context(), userID, events.TransactionsBulkUpdated, fiber.Map{
+			"transaction_ids": found,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"updated_count": len(found),
+		"changed_count": changed, // Tags actually added or removed; already-applied tags aren't counted
+		"not_found":     notFound,
+		"tags":          names,
+	})
+}
@@ -0,0 +1,417 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const tagColumns = `id, user_id, name, color, created_at, updated_at`
+
+// scanTag scans a single tag row
+func scanTag(row pgx.Row) (*Tag, error) {
+	var tag Tag
+	err := row.Scan(
+		&tag.ID,
+		&tag.UserID,
+		&tag.Name,
+		&tag.Color,
+		&tag.CreatedAt,
+		&tag.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// GetTagsByUserID retrieves a user's tags by name, with how many transactions use each
+func GetTagsByUserID(ctx context.Context, userID uuid.UUID) ([]Tag, error) {
+	query := `
+		SELECT tg.id, tg.user_id, tg.name, tg.color, tg.created_at, tg.updated_at,
+		       (SELECT COUNT(*) FROM budget.transaction_tags tt WHERE tt.tag_id = tg.id)
+		FROM budget.tags tg
+		WHERE tg.user_id = $1
+		ORDER BY tg.name
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var tag Tag
+		var count int
+		err := rows.Scan(
+			&tag.ID,
+			&tag.UserID,
+			&tag.Name,
+			&tag.Color,
+			&tag.CreatedAt,
+			&tag.UpdatedAt,
+			&count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tag.TransactionCount = &count
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetTagByID retrieves a specific tag
+func GetTagByID(ctx context.Context, tagID uuid.UUID, userID uuid.UUID) (*Tag, error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM budget.tags
+		WHERE id = $1 AND user_id = $2
+	`
+
+	tag, err := scanTag(database.DB.QueryRow(ctx, query, tagID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("tag not found")
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// CreateTag creates a tag. The name must already be normalized.
+func CreateTag(ctx context.Context, userID uuid.UUID, req CreateTagRequest) (*Tag, error) {
+	query := `
+		INSERT INTO budget.tags (user_id, name, color)
+		VALUES ($1, $2, $3)
+		RETURNING ` + tagColumns
+
+	tag, err := scanTag(database.DB.QueryRow(ctx, query, userID, req.Name, req.Color))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag already exists")
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// UpdateTag renames or recolors a tag. The name must already be normalized.
+func UpdateTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID, req UpdateTagRequest) (*Tag, error) {
+	query := `
+		UPDATE budget.tags
+		SET name = COALESCE($3, name),
+		    color = COALESCE($4, color)
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + tagColumns
+
+	tag, err := scanTag(database.DB.QueryRow(ctx, query, tagID, userID, req.Name, req.Color))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("tag not found")
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag already exists")
+		}
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// DeleteTag deletes a tag and removes it from every transaction
+func DeleteTag(ctx context.Context, tagID uuid.UUID, userID uuid.UUID) error {
+	result, err := database.DB.Exec(ctx, `
+		DELETE FROM budget.tags WHERE id = $1 AND user_id = $2
+	`, tagID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("tag not found")
+	}
+
+	return nil
+}
+
+// ensureTags creates any of the named tags that don't exist yet and returns
+// the IDs of all of them
+func ensureTags(ctx context.Context, tx pgx.Tx, userID uuid.UUID, names []string) ([]uuid.UUID, error) {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO budget.tags (user_id, name)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING
+	`, userID, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM budget.tags WHERE user_id = $1 AND name = ANY($2)
+	`, userID, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tagIDs []uuid.UUID
+	for rows.Next() {
+		var tagID uuid.UUID
+		if err := rows.Scan(&tagID); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tagIDs, nil
+}
+
+// SetTransactionTags replaces a transaction's tags with the named ones,
+// creating tags that don't exist yet. Names must already be normalized.
+func SetTransactionTags(ctx context.Context, transactionID uuid.UUID, userID uuid.UUID, names []string) ([]Tag, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM budget.transactions WHERE id = $1 AND user_id = $2)
+	`, transactionID, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("transaction not found")
+	}
+
+	tagIDs := []uuid.UUID{}
+	if len(names) > 0 {
+		tagIDs, err = ensureTags(ctx, tx, userID, names)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM budget.transaction_tags
+		WHERE transaction_id = $1 AND NOT (tag_id = ANY($2))
+	`, transactionID, tagIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove transaction tags: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO budget.transaction_tags (transaction_id, tag_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, transactionID, tagIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add transaction tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction tags: %w", err)
+	}
+
+	tags, err := getTagsForTransactions(ctx, userID, []uuid.UUID{transactionID})
+	if err != nil {
+		return nil, err
+	}
+	if tags[transactionID] == nil {
+		return []Tag{}, nil
+	}
+	return tags[transactionID], nil
+}
+
+// BulkTagTransactions adds the named tags to each of the user's transactions
+// in transactionIDs, creating tags that don't exist yet. It returns the IDs
+// that matched a transaction and how many tags were newly applied.
+func BulkTagTransactions(ctx context.Context, userID uuid.UUID, transactionIDs []uuid.UUID, names []string) ([]uuid.UUID, int, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	found, err := getOwnedTransactionIDs(ctx, tx, userID, transactionIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(found) == 0 {
+		return found, 0, nil
+	}
+
+	tagIDs, err := ensureTags(ctx, tx, userID, names)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO budget.transaction_tags (transaction_id, tag_id)
+		SELECT t.id, tg.id
+		FROM unnest($1::uuid[]) AS t(id)
+		CROSS JOIN unnest($2::uuid[]) AS tg(id)
+		ON CONFLICT DO NOTHING
+	`, found, tagIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to tag transactions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction tags: %w", err)
+	}
+
+	return found, int(result.RowsAffected()), nil
+}
+
+// BulkUntagTransactions removes the named tags from each of the user's
+// transactions in transactionIDs. It returns the IDs that matched a
+// transaction and how many tags were removed. Tags themselves are kept.
+func BulkUntagTransactions(ctx context.Context, userID uuid.UUID, transactionIDs []uuid.UUID, names []string) ([]uuid.UUID, int, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	found, err := getOwnedTransactionIDs(ctx, tx, userID, transactionIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result, err := tx.Exec(ctx, `
+		DELETE FROM budget.transaction_tags tt
+		USING budget.tags tg
+		WHERE tt.tag_id = tg.id
+		  AND tg.user_id = $1
+		  AND tg.name = ANY($3)
+		  AND tt.transaction_id = ANY($2)
+	`, userID, found, names)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to untag transactions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction tags: %w", err)
+	}
+
+	return found, int(result.RowsAffected()), nil
+}
+
+// getOwnedTransactionIDs returns the IDs in transactionIDs that belong to the user
+func getOwnedTransactionIDs(ctx context.Context, tx pgx.Tx, userID uuid.UUID, transactionIDs []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM budget.transactions WHERE user_id = $1 AND id = ANY($2)
+	`, userID, transactionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	found := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		found = append(found, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return found, nil
+}
+
+// getTagsForTransactions loads the tags on each of the given transactions,
+// sorted by name. Transactions without tags have no entry in the map.
+func getTagsForTransactions(ctx context.Context, userID uuid.UUID, transactionIDs []uuid.UUID) (map[uuid.UUID][]Tag, error) {
+	tags := make(map[uuid.UUID][]Tag)
+	if len(transactionIDs) == 0 {
+		return tags, nil
+	}
+
+	query := `
+		SELECT tt.transaction_id, tg.id, tg.user_id, tg.name, tg.color, tg.created_at, tg.updated_at
+		FROM budget.transaction_tags tt
+		JOIN budget.tags tg ON tg.id = tt.tag_id
+		WHERE tg.user_id = $1 AND tt.transaction_id = ANY($2)
+		ORDER BY tg.name
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, transactionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID uuid.UUID
+		var tag Tag
+		err := rows.Scan(
+			&transactionID,
+			&tag.ID,
+			&tag.UserID,
+			&tag.Name,
+			&tag.Color,
+			&tag.CreatedAt,
+			&tag.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction tag: %w", err)
+		}
+		tags[transactionID] = append(tags[transactionID], tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// attachTransactionTags fills in the Tags of each transaction
+func attachTransactionTags(ctx context.Context, userID uuid.UUID, transactions []Transaction) error {
+	ids := make([]uuid.UUID, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+
+	tags, err := getTagsForTransactions(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		transactions[i].Tags = tags[transactions[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package budget
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxTagNameLength matches budget.tags.name
+const maxTagNameLength = 50
+
+// maxBulkTagTransactions caps how many transactions one bulk tag request changes
+const maxBulkTagTransactions = 1000
+
+var (
+	tagWhitespace = regexp.MustCompile(`\s+`)
+	tagColor      = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// Tag is a user-defined label that can be applied to any transaction,
+// independent of its category
+type Tag struct {
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Name             string    `json:"name"`
+	Color            *string   `json:"color,omitempty"`
+	TransactionCount *int      `json:"transaction_count,omitempty"` // Only set when listing tags
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// CreateTagRequest represents the request body for creating a tag
+type CreateTagRequest struct {
+	Name  string  `json:"name" validate:"required,min=1,max=50"`
+	Color *string `json:"color,omitempty" validate:"omitempty,len=7"`
+}
+
+// UpdateTagRequest represents the request body for renaming or recoloring a tag
+type UpdateTagRequest struct {
+	Name  *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Color *string `json:"color,omitempty" validate:"omitempty,len=7"`
+}
+
+// BulkTagRequest represents the request body for tagging or untagging many
+// transactions at once. Tags are given by name; tagging creates any that
+// don't exist yet.
+type BulkTagRequest struct {
+	TransactionIDs []uuid.UUID `json:"transaction_ids" validate:"required,min=1"`
+	Tags           []string    `json:"tags" validate:"required,min=1"`
+}
+
+// normalizeTagName lowercases a tag name and replaces runs of whitespace with
+// a hyphen, so "Holiday 2026" and "holiday-2026" are the same tag
+func normalizeTagName(name string) string {
+	return tagWhitespace.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+}
+
+// normalizeTagNames normalizes and de-duplicates tag names, rejecting invalid ones
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeTagName(name)
+		if err := validateTagName(name); err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized, nil
+}
+
+// validateTagName checks a normalized tag name. Commas aren't allowed since
+// tag filters are comma-separated.
+func validateTagName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Tag name is required")
+	}
+	if len([]rune(name)) > maxTagNameLength {
+		return fmt.Errorf("Tag name must be at most %d characters", maxTagNameLength)
+	}
+	if strings.Contains(name, ",") {
+		return fmt.Errorf("Tag name cannot contain commas")
+	}
+	return nil
+}
+
+// validateTagColor requires a hex color like #FF5733
+func validateTagColor(color *string) error {
+	if color != nil && !tagColor.MatchString(*color) {
+		return fmt.Errorf("Color must be a hex color like #FF5733")
+	}
+	return nil
+}
+
+// parseTagFilter reads a comma-separated ?tags= value, ignoring blank entries
+func parseTagFilter(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return normalizeTagNames(names)
+}
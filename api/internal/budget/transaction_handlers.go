@@ -1,6 +1,8 @@
 package budget
 
 import (
+	"github.com/brendenbissett/help-me-budget/api/internal/events"
+	"github.com/brendenbissett/help-me-budget/api/internal/webhooks"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -14,6 +16,11 @@ func GetTransactionsHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	format, ok := exportFormat(c)
+	if !ok {
+		return invalidExportFormat(c)
+	}
+
 	// Parse optional query parameters
 	var accountID *uuid.UUID
 	if accountIDStr := c.Query("account_id"); accountIDStr != "" {
@@ -41,13 +48,50 @@ func GetTransactionsHandler(c *fiber.Ctx) error {
 		endDate = &end
 	}
 
-	transactions, err := GetTransactionsByUserID(c.Context(), userID, accountID, categoryID, startDate, endDate)
+	tags, err := parseTagFilter(c.Query("tags"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	tagMatch := c.Query("tag_match", "any")
+	if tagMatch != "any" && tagMatch != "all" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tag match must be 'any' or 'all'",
+		})
+	}
+
+	transactions, err := GetFilteredTransactions(c.Context(), userID, TransactionFilters{
+		AccountID:    accountID,
+		CategoryID:   categoryID,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Tags:         tags,
+		MatchAllTags: tagMatch == "all",
+	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve transactions",
 		})
 	}
 
+	if err := attachTransactionTags(c.Context(), userID, transactions); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve transactions",
+		})
+	}
+
+	if format != "" {
+		report, err := transactionsExport(c.Context(), userID, transactions, accountID, startDate, endDate)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to export transactions",
+			})
+		}
+		return sendExport(c, userID, format, "transactions", report)
+	}
+
 	return c.JSON(fiber.Map{
 		"transactions": transactions,
 	})
@@ -81,6 +125,14 @@ func GetTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	tags, err := getTagsForTransactions(c.Context(), userID, []uuid.UUID{transaction.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve transaction",
+		})
+	}
+	transaction.Tags = tags[transaction.ID]
+
 	return c.JSON(transaction)
 }
 
@@ -107,6 +159,10 @@ func CreateTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	webhooks.Publish(c.Context(), userID, webhooks.EventTransactionCreated, transaction)
+	events.Publish(c.Context(), userID, events.TransactionCreated, transaction)
+	evaluateAlertsAfterWrite(userID, transaction)
+
 	return c.Status(fiber.StatusCreated).JSON(transaction)
 }
 
@@ -145,6 +201,9 @@ func UpdateTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	events.Publish(c.Context(), userID, events.TransactionUpdated, transaction)
+	evaluateAlertsAfterWrite(userID, transaction)
+
 	return c.JSON(transaction)
 }
 
@@ -164,6 +223,14 @@ func DeleteTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// Attachment records go with the transaction, but their files need removing separately
+	attachments, err := GetAttachmentsByTransactionID(c.Context(), transactionID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete transaction",
+		})
+	}
+
 	err = DeleteTransaction(c.Context(), transactionID, userID)
 	if err != nil {
 		if err.Error() == "transaction not found" {
@@ -176,6 +243,10 @@ func DeleteTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	removeStoredAttachments(c.Context(), attachments)
+
+	events.Publish(c.Context(), userID, events.TransactionDeleted, fiber.Map{"id": transactionID})
+
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
@@ -237,6 +308,9 @@ func CategorizeTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	events.Publish(c.Context(), userID, events.TransactionUpdated, transaction)
+	evaluateAlertsAfterWrite(userID, transaction)
+
 	return c.JSON(transaction)
 }
 
@@ -283,5 +357,55 @@ func LinkTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	webhooks.Publish(c.Context(), userID, webhooks.EventTransactionMatched, transaction)
+	events.Publish(c.Context(), userID, events.TransactionMatched, transaction)
+	evaluateAlertsAfterWrite(userID, transaction)
+
 	return c.JSON(transaction)
 }
+
+// UnlinkTransactionHandler removes a transaction's link to a budget entry
+func UnlinkTransactionHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	transaction, batch, err := UnlinkTransactionMatch(c.Context(), transactionID, userID)
+	if err != nil {
+		if err.Error() == "transaction not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		if err.Error() == "transaction changed since it was read" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Transaction was re-matched while unlinking; reload and try again",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unlink transaction",
+		})
+	}
+
+	events.Publish(c.Context(), userID, events.TransactionUnmatched, transaction)
+
+	var batchID *uuid.UUID
+	if batch != nil {
+		batchID = &batch.ID
+	}
+
+	return c.JSON(fiber.Map{
+		"transaction": transaction,
+		"batch_id":    batchID,
+	})
+}
@@ -12,6 +12,27 @@ import (
 
 // GetTransactionsByUserID retrieves all transactions for a user with optional filters
 func GetTransactionsByUserID(ctx context.Context, userID uuid.UUID, accountID *uuid.UUID, categoryID *uuid.UUID, startDate *string, endDate *string) ([]Transaction, error) {
+	return GetFilteredTransactions(ctx, userID, TransactionFilters{
+		AccountID:  accountID,
+		CategoryID: categoryID,
+		StartDate:  startDate,
+		EndDate:    endDate,
+	})
+}
+
+// TransactionFilters narrows GetFilteredTransactions. Nil and empty fields
+// don't filter.
+type TransactionFilters struct {
+	AccountID    *uuid.UUID
+	CategoryID   *uuid.UUID
+	StartDate    *string
+	EndDate      *string
+	Tags         []string // Tag names; transactions need any of them, or all with MatchAllTags
+	MatchAllTags bool
+}
+
+// GetFilteredTransactions retrieves a user's transactions matching filters, newest first
+func GetFilteredTransactions(ctx context.Context, userID uuid.UUID, filters TransactionFilters) ([]Transaction, error) {
 	query := `
 		SELECT id, user_id, account_id, category_id, budget_entry_id, amount,
 		       transaction_type, description, transaction_date::text, notes,
@@ -23,28 +44,46 @@ func GetTransactionsByUserID(ctx context.Context, userID uuid.UUID, accountID *u
 	argIndex := 2
 
 	// Add optional filters
-	if accountID != nil {
+	if filters.AccountID != nil {
 		query += fmt.Sprintf(" AND account_id = $%d", argIndex)
-		args = append(args, *accountID)
+		args = append(args, *filters.AccountID)
 		argIndex++
 	}
 
-	if categoryID != nil {
+	if filters.CategoryID != nil {
 		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
-		args = append(args, *categoryID)
+		args = append(args, *filters.CategoryID)
 		argIndex++
 	}
 
-	if startDate != nil {
+	if filters.StartDate != nil {
 		query += fmt.Sprintf(" AND transaction_date >= $%d", argIndex)
-		args = append(args, *startDate)
+		args = append(args, *filters.StartDate)
 		argIndex++
 	}
 
-	if endDate != nil {
+	if filters.EndDate != nil {
 		query += fmt.Sprintf(" AND transaction_date <= $%d", argIndex)
-		args = append(args, *endDate)
+		args = append(args, *filters.EndDate)
+		argIndex++
+	}
+
+	if len(filters.Tags) > 0 {
+		tagged := fmt.Sprintf(`
+			SELECT tt.transaction_id
+			FROM budget.transaction_tags tt
+			JOIN budget.tags tg ON tg.id = tt.tag_id
+			WHERE tg.user_id = $1 AND tg.name = ANY($%d)`, argIndex)
+		args = append(args, filters.Tags)
 		argIndex++
+		if filters.MatchAllTags {
+			tagged += fmt.Sprintf(`
+			GROUP BY tt.transaction_id
+			HAVING COUNT(DISTINCT tg.name) = $%d`, argIndex)
+			args = append(args, len(filters.Tags))
+			argIndex++
+		}
+		query += " AND id IN (" + tagged + ")"
 	}
 
 	query += " ORDER BY transaction_date DESC, created_at DESC"
@@ -347,3 +386,99 @@ func LinkTransactionToBudgetEntry(ctx context.Context, transactionID uuid.UUID,
 	}
 	return UpdateTransaction(ctx, transactionID, userID, req)
 }
+
+// GetLinkedTransactions retrieves all transactions that are linked to a budget entry
+func GetLinkedTransactions(ctx context.Context, userID uuid.UUID) ([]Transaction, error) {
+	query := `
+		SELECT id, user_id, account_id, category_id, budget_entry_id, amount,
+		       transaction_type, description, transaction_date::text, notes,
+		       match_confidence, created_at, updated_at
+		FROM budget.transactions
+		WHERE user_id = $1 AND budget_entry_id IS NOT NULL
+		ORDER BY transaction_date ASC, created_at ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query linked transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.AccountID,
+			&t.CategoryID,
+			&t.BudgetEntryID,
+			&t.Amount,
+			&t.TransactionType,
+			&t.Description,
+			&t.TransactionDate,
+			&t.Notes,
+			&t.MatchConfidence,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// GetTransactionsByBudgetEntryID retrieves all transactions linked to a specific budget entry
+func GetTransactionsByBudgetEntryID(ctx context.Context, userID uuid.UUID, budgetEntryID uuid.UUID) ([]Transaction, error) {
+	query := `
+		SELECT id, user_id, account_id, category_id, budget_entry_id, amount,
+		       transaction_type, description, transaction_date::text, notes,
+		       match_confidence, created_at, updated_at
+		FROM budget.transactions
+		WHERE user_id = $1 AND budget_entry_id = $2
+		ORDER BY transaction_date ASC, created_at ASC
+	`
+
+	rows, err := database.DB.Query(ctx, query, userID, budgetEntryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budget entry transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.AccountID,
+			&t.CategoryID,
+			&t.BudgetEntryID,
+			&t.Amount,
+			&t.TransactionType,
+			&t.Description,
+			&t.TransactionDate,
+			&t.Notes,
+			&t.MatchConfidence,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transactions: %w", err)
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+)
+
+// Event types broadcast to a user's open clients
+const (
+	TransactionCreated   = "transaction.created"
+	TransactionUpdated   = "transaction.updated" // Edited or categorized
+	TransactionDeleted   = "transaction.deleted"
+	TransactionMatched   = "transaction.matched"
+	TransactionUnmatched = "transaction.unmatched"
+
+	TransactionsBulkUpdated = "transactions.bulk_updated" // Bulk tagging; data lists the transaction IDs
+
+	AccountCreated = "account.created"
+	AccountUpdated = "account.updated" // Includes balance changes
+	AccountDeleted = "account.deleted"
+
+	MatchingBatchCompleted = "matching.batch_completed" // Bulk auto-match or commit linked transactions
+	MatchingBatchUndone    = "matching.batch_undone"
+
+	DataImported = "data.imported"
+)
+
+// Event is the message sent to clients. Data is usually the changed record;
+// deletions send its ID.
+type Event struct {
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// channel is the Redis pub/sub channel for a user's events. Every API
+// instance subscribes to it for each stream it serves, so a change made
+// through one instance reaches clients connected to any other.
+func channel(userID uuid.UUID) string {
+	return "events:user:" + userID.String()
+}
+
+// Publish broadcasts an event to the user's open event streams. Events are
+// fire-and-forget: nothing is stored for clients that aren't connected, and
+// failures are logged rather than returned so they never fail the write that
+// caused them.
+func Publish(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) {
+	if database.RedisClient == nil {
+		return
+	}
+
+	payload, err := json.Marshal(Event{
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", eventType, err)
+		return
+	}
+
+	if err := database.RedisClient.Publish(ctx, channel(userID), payload).Err(); err != nil {
+		log.Printf("Error publishing %s event for user %s: %v", eventType, userID, err)
+	}
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// heartbeatInterval keeps idle streams open through proxies and lets the
+// server notice clients that have gone away
+const heartbeatInterval = 15 * time.Second
+
+// retryMs is how long browsers wait before reconnecting a dropped stream
+const retryMs = 3000
+
+// getUserIDFromContext extracts user ID from X-User-ID header
+func getUserIDFromContext(c *fiber.Ctx) uuid.UUID {
+	userIDStr := c.Get("X-User-ID")
+	if userIDStr == "" {
+		return uuid.Nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return userID
+}
+
+// StreamEventsHandler streams the user's events as server-sent events until
+// the client disconnects. Each message's event name is the event type and its
+// data is the JSON-encoded Event. A "connected" event is sent first; clients
+// should re-fetch on it, since events published while they were disconnected
+// aren't replayed.
+func StreamEventsHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	if database.RedisClient == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Event stream unavailable",
+		})
+	}
+
+	// The subscription outlives the handler, so it gets its own context
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := database.RedisClient.Subscribe(ctx, channel(userID))
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		cancel()
+		log.Printf("Error subscribing to events for user %s: %v", userID, err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Event stream unavailable",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer sub.Close()
+
+		messages := sub.Channel()
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", retryMs)
+		writeEvent(w, "connected", Event{
+			Type:      "connected",
+			Data:      fiber.Map{"user_id": userID},
+			CreatedAt: time.Now().UTC(),
+		})
+
+		// A failed flush means the client has gone
+		for w.Flush() == nil {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event struct {
+					Type string `json:"type"`
+				}
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil || event.Type == "" {
+					log.Printf("Skipping malformed event for user %s", userID)
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, msg.Payload)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeEvent writes a single server-sent event
+func writeEvent(w *bufio.Writer, eventType string, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", eventType, err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, data)
+}
@@ -0,0 +1,11 @@
+package events
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupEventRoutes configures the real-time event stream endpoint
+func SetupEventRoutes(app *fiber.App) {
+	events := app.Group("/api/events")
+	events.Get("/stream", StreamEventsHandler) // Server-sent events for the user's transaction, account and matching changes
+}
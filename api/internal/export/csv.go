@@ -0,0 +1,68 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// writeCSV writes the metadata as label/value rows, a blank row, then the
+// table. Currency columns carry the currency code in their header.
+func writeCSV(w io.Writer, r *Report) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{r.Title}); err != nil {
+		return err
+	}
+	for _, field := range r.Metadata {
+		if err := writer.Write([]string{field.Label, escapeFormula(field.Value)}); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write([]string{}); err != nil {
+		return err
+	}
+
+	header := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		header[i] = column.Header
+		if column.Type == Currency && r.Currency != "" {
+			header[i] += " (" + r.Currency + ")"
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	rows := r.Rows
+	if r.Totals != nil {
+		rows = append(rows[:len(rows):len(rows)], r.Totals)
+	}
+	for _, row := range rows {
+		record := make([]string, len(r.Columns))
+		for i := range r.Columns {
+			if i < len(row) {
+				record[i] = r.plainValue(i, row[i])
+				if r.Columns[i].Type == Text {
+					record[i] = escapeFormula(record[i])
+				}
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula stops spreadsheet apps treating text such as an imported
+// description of "=HYPERLINK(...)" as a formula, by prefixing values that
+// start with a formula character with an apostrophe
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
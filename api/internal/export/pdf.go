@@ -0,0 +1,273 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page layout in points (A4). Wide tables switch to landscape.
+const (
+	pdfPortraitWidth  = 595.0
+	pdfPortraitHeight = 842.0
+	pdfMargin         = 40.0
+	pdfFontSize       = 9.0
+	pdfLineHeight     = 14.0
+	pdfCellPadding    = 4.0
+)
+
+// helveticaWidths are the Helvetica glyph widths (per 1000 units) for ASCII 32-126
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// pdfTextWidth estimates the width of text in Helvetica at the given size.
+// Bold text runs about 8% wider.
+func pdfTextWidth(text string, size float64, bold bool) float64 {
+	units := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	width := float64(units) * size / 1000
+	if bold {
+		width *= 1.08
+	}
+	return width
+}
+
+// pdfPage accumulates the content stream for one page
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// text draws text with its baseline at (x, y)
+func (p *pdfPage) text(x, y float64, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+// line draws a horizontal rule
+func (p *pdfPage) line(x1, x2, y float64) {
+	fmt.Fprintf(&p.content, "0.6 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", x1, y, x2, y)
+}
+
+// writePDF lays the report out as a paginated table. Numeric columns are
+// right-aligned and the header row repeats on every page.
+func writePDF(w io.Writer, r *Report) error {
+	pageWidth, pageHeight := pdfPortraitWidth, pdfPortraitHeight
+	if len(r.Columns) > 6 {
+		pageWidth, pageHeight = pdfPortraitHeight, pdfPortraitWidth
+	}
+	tableWidth := pageWidth - 2*pdfMargin
+
+	// Format every cell once, then size columns to fit their content
+	cells := make([][]string, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		cells = append(cells, r.displayRow(row))
+	}
+	var totals []string
+	if r.Totals != nil {
+		totals = r.displayRow(r.Totals)
+	}
+
+	widths := make([]float64, len(r.Columns))
+	for i, column := range r.Columns {
+		widths[i] = pdfTextWidth(column.Header, pdfFontSize, true)
+		for _, row := range cells {
+			widths[i] = max(widths[i], pdfTextWidth(row[i], pdfFontSize, false))
+		}
+		if totals != nil {
+			widths[i] = max(widths[i], pdfTextWidth(totals[i], pdfFontSize, true))
+		}
+		widths[i] += 2 * pdfCellPadding
+	}
+	total := 0.0
+	for _, width := range widths {
+		total += width
+	}
+	// Shrink columns proportionally when the table is too wide; text is clipped
+	if total > tableWidth {
+		for i := range widths {
+			widths[i] *= tableWidth / total
+		}
+	}
+
+	var pages []*pdfPage
+	var page *pdfPage
+	y := 0.0
+
+	drawRow := func(values []string, bold bool) {
+		x := pdfMargin
+		for i, column := range r.Columns {
+			value := ""
+			if i < len(values) {
+				value = fitText(values[i], widths[i]-2*pdfCellPadding, bold)
+			}
+			textX := x + pdfCellPadding
+			if column.Type != Text {
+				textX = x + widths[i] - pdfCellPadding - pdfTextWidth(value, pdfFontSize, bold)
+			}
+			page.text(textX, y, pdfFontSize, bold, value)
+			x += widths[i]
+		}
+		y -= pdfLineHeight
+	}
+
+	headers := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		headers[i] = column.Header
+	}
+
+	newPage := func() {
+		page = &pdfPage{}
+		pages = append(pages, page)
+		y = pageHeight - pdfMargin - pdfFontSize
+		drawRow(headers, true)
+		page.line(pdfMargin, pageWidth-pdfMargin, y+pdfLineHeight-3)
+	}
+
+	// The first page starts with the title and metadata
+	page = &pdfPage{}
+	pages = append(pages, page)
+	y = pageHeight - pdfMargin - 16
+	page.text(pdfMargin, y, 16, true, r.Title)
+	y -= 24
+	for _, field := range r.Metadata {
+		page.text(pdfMargin, y, pdfFontSize, true, field.Label+":")
+		page.text(pdfMargin+110, y, pdfFontSize, false, field.Value)
+		y -= pdfLineHeight
+	}
+	y -= pdfLineHeight / 2
+	drawRow(headers, true)
+	page.line(pdfMargin, pageWidth-pdfMargin, y+pdfLineHeight-3)
+
+	bottom := pdfMargin + pdfLineHeight // Leave room for the page number
+	for _, row := range cells {
+		if y < bottom {
+			newPage()
+		}
+		drawRow(row, false)
+	}
+	if totals != nil {
+		if y < bottom+pdfLineHeight {
+			newPage()
+		}
+		page.line(pdfMargin, pageWidth-pdfMargin, y+pdfLineHeight-3)
+		drawRow(totals, true)
+	}
+
+	for i, p := range pages {
+		label := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		p.text(pageWidth-pdfMargin-pdfTextWidth(label, 8, false), pdfMargin/2, 8, false, label)
+	}
+
+	return writePDFDocument(w, pages, pageWidth, pageHeight)
+}
+
+// displayRow formats a row's cells for display
+func (r *Report) displayRow(values []interface{}) []string {
+	row := make([]string, len(r.Columns))
+	for i := range r.Columns {
+		if i < len(values) {
+			row[i] = r.displayValue(i, values[i])
+		}
+	}
+	return row
+}
+
+// fitText trims text with an ellipsis until it fits within width
+func fitText(text string, width float64, bold bool) string {
+	if pdfTextWidth(text, pdfFontSize, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", pdfFontSize, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// writePDFDocument writes the object graph: catalog, page tree, the two
+// standard fonts, then each page with its content stream, followed by the
+// cross-reference table
+func writePDFDocument(w io.Writer, pages []*pdfPage, pageWidth, pageHeight float64) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	addObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are fixed; each page then takes two objects (page, content)
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	addObject("<< /Type /Catalog /Pages 2 0 R >>")
+	addObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	addObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	addObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		addObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// winAnsi maps the non-Latin-1 characters WinAnsiEncoding supports
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfEscape encodes text as a WinAnsi PDF string literal body. Characters
+// the standard fonts can't show become '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 32 && r <= 126:
+			b.WriteByte(byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		default:
+			if code, ok := winAnsi[r]; ok {
+				b.WriteByte(code)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Supported export formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// ColumnType controls how a column's values are formatted
+type ColumnType int
+
+const (
+	Text     ColumnType = iota
+	Currency            // float64 amounts in the report's currency
+	Number              // Counts and other plain numbers
+	Percent             // float64 percentages, e.g. 12.5 for 12.5%
+)
+
+// Column describes one column of a report table
+type Column struct {
+	Header string
+	Type   ColumnType
+}
+
+// Field is a label/value pair shown above the table
+type Field struct {
+	Label string
+	Value string
+}
+
+// Report is a single table with a title and metadata, ready to be written in
+// any supported format. Row and total cells hold a string, float64 or int;
+// nil leaves the cell blank.
+type Report struct {
+	Title    string
+	Currency string // ISO 4217 code, e.g. "USD"
+	Metadata []Field
+	Columns  []Column
+	Rows     [][]interface{}
+	Totals   []interface{} // Optional totals row; the first cell is usually a "Total" label
+}
+
+// IsSupported reports whether format is one of the export formats
+func IsSupported(format string) bool {
+	return format == FormatCSV || format == FormatXLSX || format == FormatPDF
+}
+
+// ContentType returns the MIME type for a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Write renders the report in the given format
+func Write(w io.Writer, format string, r *Report) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, r)
+	case FormatXLSX:
+		return writeXLSX(w, r)
+	case FormatPDF:
+		return writePDF(w, r)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// currencySymbols maps ISO codes to the symbol shown before amounts
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"AUD": "$",
+	"CAD": "$",
+	"NZD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"ZAR": "R",
+}
+
+// currencySymbol returns the symbol for a currency code, or the code itself
+// followed by a space for currencies without a common symbol
+func currencySymbol(currency string) string {
+	if symbol, ok := currencySymbols[strings.ToUpper(currency)]; ok {
+		return symbol
+	}
+	if currency == "" {
+		return "$"
+	}
+	return strings.ToUpper(currency) + " "
+}
+
+// FormatCurrency formats an amount for display, e.g. -1234.5 in USD as "-$1,234.50"
+func FormatCurrency(amount float64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return sign + currencySymbol(currency) + groupThousands(strconv.FormatFloat(amount, 'f', 2, 64))
+}
+
+// groupThousands inserts commas into the integer part of a formatted number
+func groupThousands(s string) string {
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i:]
+	}
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String() + fraction
+}
+
+// number converts a numeric cell value to float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// plainValue formats a cell for machine-readable output (CSV): amounts to two
+// decimal places without symbols or grouping
+func (r *Report) plainValue(col int, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	v, ok := number(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	switch r.Columns[col].Type {
+	case Currency:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case Percent:
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	default:
+		return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	}
+}
+
+// displayValue formats a cell for people to read (PDF)
+func (r *Report) displayValue(col int, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	v, ok := number(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	switch r.Columns[col].Type {
+	case Currency:
+		return FormatCurrency(v, r.Currency)
+	case Percent:
+		return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+	default:
+		return groupThousands(strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64))
+	}
+}
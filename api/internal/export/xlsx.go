@@ -0,0 +1,231 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Cell style indexes into the cellXfs list written by xlsxStyles
+const (
+	styleDefault = iota
+	styleBold
+	styleCurrency
+	styleCurrencyBold
+	styleNumber
+	styleNumberBold
+	stylePercent
+	stylePercentBold
+	styleTitle
+)
+
+// writeXLSX writes the report as a single-sheet workbook. Amounts are stored
+// as numbers with a currency number format so they stay usable in formulas.
+func writeXLSX(w io.Writer, r *Report) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook(r.Title)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles(r.Currency)},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(r)},
+	}
+
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// xlsxSheet renders the worksheet: title, metadata, blank row, header, rows and totals
+func xlsxSheet(r *Report) string {
+	var rows strings.Builder
+	row := 0
+	addRow := func(cells func(row int) string) {
+		row++
+		fmt.Fprintf(&rows, `<row r="%d">%s</row>`, row, cells(row))
+	}
+
+	addRow(func(row int) string {
+		return xlsxTextCell(0, row, r.Title, styleTitle)
+	})
+	for _, field := range r.Metadata {
+		addRow(func(row int) string {
+			return xlsxTextCell(0, row, field.Label, styleBold) + xlsxTextCell(1, row, field.Value, styleDefault)
+		})
+	}
+	addRow(func(int) string { return "" })
+
+	addRow(func(row int) string {
+		var cells strings.Builder
+		for i, column := range r.Columns {
+			cells.WriteString(xlsxTextCell(i, row, column.Header, styleBold))
+		}
+		return cells.String()
+	})
+
+	valueCells := func(values []interface{}, bold bool) func(row int) string {
+		return func(row int) string {
+			var cells strings.Builder
+			for i := range r.Columns {
+				if i >= len(values) || values[i] == nil {
+					continue
+				}
+				if v, ok := number(values[i]); ok {
+					cells.WriteString(xlsxNumberCell(i, row, v, xlsxNumberStyle(r.Columns[i].Type, bold)))
+					continue
+				}
+				style := styleDefault
+				if bold {
+					style = styleBold
+				}
+				cells.WriteString(xlsxTextCell(i, row, fmt.Sprint(values[i]), style))
+			}
+			return cells.String()
+		}
+	}
+
+	for _, values := range r.Rows {
+		addRow(valueCells(values, false))
+	}
+	if r.Totals != nil {
+		addRow(valueCells(r.Totals, true))
+	}
+
+	// Size columns to their widest value so nothing opens as ####
+	var cols strings.Builder
+	for i, column := range r.Columns {
+		width := len(column.Header)
+		for _, row := range append(r.Rows[:len(r.Rows):len(r.Rows)], r.Totals) {
+			if i < len(row) {
+				width = max(width, len(r.displayValue(i, row[i])))
+			}
+		}
+		fmt.Fprintf(&cols, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, min(width+2, 60))
+	}
+
+	return xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<cols>` + cols.String() + `</cols>` +
+		`<sheetData>` + rows.String() + `</sheetData>` +
+		`</worksheet>`
+}
+
+// xlsxNumberStyle picks the cell style for a numeric value in a column
+func xlsxNumberStyle(columnType ColumnType, bold bool) int {
+	style := styleNumber
+	switch columnType {
+	case Currency:
+		style = styleCurrency
+	case Percent:
+		style = stylePercent
+	}
+	if bold {
+		style++
+	}
+	return style
+}
+
+// xlsxCellRef returns the A1-style reference for a zero-based column and one-based row
+func xlsxCellRef(col, row int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name + strconv.Itoa(row)
+}
+
+func xlsxTextCell(col, row int, text string, style int) string {
+	return fmt.Sprintf(`<c r="%s" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`, xlsxCellRef(col, row), style, xmlEscape(text))
+}
+
+func xlsxNumberCell(col, row int, value float64, style int) string {
+	return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, xlsxCellRef(col, row), style, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxStyles defines the fonts and number formats used by the cell styles
+func xlsxStyles(currency string) string {
+	symbol := strings.ReplaceAll(currencySymbol(currency), `"`, "")
+	currencyFormat := xmlEscape(fmt.Sprintf(`"%s"#,##0.00;\-"%s"#,##0.00`, symbol, symbol))
+
+	xf := func(numFmt, font int) string {
+		return fmt.Sprintf(`<xf numFmtId="%d" fontId="%d" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>`, numFmt, font)
+	}
+
+	return xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="2">` +
+		`<numFmt numFmtId="164" formatCode="` + currencyFormat + `"/>` +
+		`<numFmt numFmtId="165" formatCode="0.0&quot;%&quot;"/>` +
+		`</numFmts>` +
+		`<fonts count="3"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="14"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="9">` +
+		xf(0, 0) + xf(0, 1) + // default, bold
+		xf(164, 0) + xf(164, 1) + // currency
+		xf(0, 0) + xf(0, 1) + // number (General)
+		xf(165, 0) + xf(165, 1) + // percent
+		xf(0, 2) + // title
+		`</cellXfs>` +
+		`</styleSheet>`
+}
+
+func xlsxWorkbook(title string) string {
+	// Sheet names are limited to 31 characters and can't contain []:*?/\
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, title)
+	if len([]rune(name)) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	if name == "" {
+		name = "Report"
+	}
+
+	return xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + xmlEscape(name) + `" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
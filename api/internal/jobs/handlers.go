@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// getUserIDFromContext extracts user ID from X-User-ID header
+func getUserIDFromContext(c *fiber.Ctx) uuid.UUID {
+	userIDStr := c.Get("X-User-ID")
+	if userIDStr == "" {
+		return uuid.Nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return userID
+}
+
+// GetJobHandler returns the status, progress and result of a background job
+func GetJobHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid job ID",
+		})
+	}
+
+	job, err := GetJobByID(c.Context(), jobID, userID)
+	if err != nil {
+		if err.Error() == "job not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Job not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve job",
+		})
+	}
+
+	return c.JSON(job)
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// staleJobTimeout is how long a running job may go without finishing before
+// another worker assumes its worker died and reclaims it
+const staleJobTimeout = 15 * time.Minute
+
+const jobColumns = `id, user_id, job_type, status, payload, result, error, progress,
+	progress_message, attempts, max_attempts, run_at, started_at, finished_at,
+	created_at, updated_at`
+
+// scanJob scans a single job row
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	var payload, result []byte
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.JobType,
+		&job.Status,
+		&payload,
+		&result,
+		&job.Error,
+		&job.Progress,
+		&job.ProgressMessage,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	job.Result = result
+	return &job, nil
+}
+
+// Enqueue adds a new job to the queue and wakes an idle worker
+func Enqueue(ctx context.Context, userID uuid.UUID, jobType string, payload interface{}) (*Job, error) {
+	job, err := insertJob(ctx, database.DB, userID, jobType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	wakeWorkers()
+	return job, nil
+}
+
+// EnqueueTx adds a new job as part of tx, so it's only queued if tx commits.
+// Workers pick it up on their next poll.
+func EnqueueTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, jobType string, payload interface{}) (*Job, error) {
+	return insertJob(ctx, tx, userID, jobType, payload)
+}
+
+// rowQuerier is satisfied by both the connection pool and a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertJob inserts a queued job
+func insertJob(ctx context.Context, db rowQuerier, userID uuid.UUID, jobType string, payload interface{}) (*Job, error) {
+	var payloadJSON []byte
+	if payload != nil {
+		var err error
+		payloadJSON, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO budget.jobs (user_id, job_type, payload, max_attempts)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(db.QueryRow(ctx, query, userID, jobType, payloadJSON, maxAttemptsFor(jobType)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetJobByID retrieves a specific job belonging to a user
+func GetJobByID(ctx context.Context, jobID uuid.UUID, userID uuid.UUID) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM budget.jobs WHERE id = $1 AND user_id = $2`
+
+	job, err := scanJob(database.DB.QueryRow(ctx, query, jobID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// FindActiveJob returns a user's queued or running job of the given type, if any
+func FindActiveJob(ctx context.Context, userID uuid.UUID, jobType string) (*Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM budget.jobs
+		WHERE user_id = $1 AND job_type = $2 AND status IN ('queued', 'running')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	job, err := scanJob(database.DB.QueryRow(ctx, query, userID, jobType))
+	if err == pgx.ErrNoRows {
+		return nil, nil // No active job is not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active job: %w", err)
+	}
+
+	return job, nil
+}
+
+// claimNextJob locks the next runnable job for this worker. Jobs whose worker
+// stopped responding are reclaimed once they go stale, if they have attempts
+// left; failStaleJobs fails the rest.
+func claimNextJob(ctx context.Context) (*Job, error) {
+	query := `
+		UPDATE budget.jobs
+		SET status = 'running',
+		    attempts = attempts + 1,
+		    locked_at = CURRENT_TIMESTAMP,
+		    started_at = COALESCE(started_at, CURRENT_TIMESTAMP)
+		WHERE id = (
+			SELECT id FROM budget.jobs
+			WHERE (status = 'queued' AND run_at <= CURRENT_TIMESTAMP)
+			   OR (status = 'running' AND locked_at < $1 AND attempts < max_attempts)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(database.DB.QueryRow(ctx, query, time.Now().Add(-staleJobTimeout)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// failStaleJobs marks stale running jobs that have used all their attempts as
+// failed, so a job that keeps killing or hanging its worker isn't retried forever
+func failStaleJobs(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE budget.jobs
+		SET status = 'failed',
+		    error = 'Job stopped responding on its last attempt',
+		    locked_at = NULL,
+		    finished_at = CURRENT_TIMESTAMP
+		WHERE status = 'running' AND locked_at < $1 AND attempts >= max_attempts
+	`
+
+	result, err := database.DB.Exec(ctx, query, time.Now().Add(-staleJobTimeout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale jobs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// updateJobProgress records how far a running job has got. Nothing is written
+// once the job has been reclaimed by another worker.
+func updateJobProgress(ctx context.Context, job *Job, progress int, message string) error {
+	query := `
+		UPDATE budget.jobs
+		SET progress = $1, progress_message = $2, locked_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'running' AND attempts = $4
+	`
+
+	_, err := database.DB.Exec(ctx, query, progress, message, job.ID, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+
+	return nil
+}
+
+// completeJob marks a job as succeeded and stores its result. Updates are
+// guarded by the attempt that claimed the job, so a worker that finishes after
+// its job went stale and was reclaimed can't overwrite the re-run.
+func completeJob(ctx context.Context, job *Job, result interface{}) error {
+	var resultJSON []byte
+	if result != nil {
+		var err error
+		resultJSON, err = json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job result: %w", err)
+		}
+	}
+
+	query := `
+		UPDATE budget.jobs
+		SET status = 'succeeded', result = $1, error = NULL, progress = 100,
+		    locked_at = NULL, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = 'running' AND attempts = $3
+	`
+
+	tag, err := database.DB.Exec(ctx, query, resultJSON, job.ID, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("job was reclaimed by another worker")
+	}
+
+	return nil
+}
+
+// failJob records a failed attempt. The job is requeued with backoff until it
+// runs out of attempts, or straight away when final is set, after which it is
+// marked failed for good. Like completeJob, it leaves a reclaimed job alone.
+func failJob(ctx context.Context, job *Job, jobErr error, final bool) error {
+	var query string
+	var args []interface{}
+
+	if !final && job.Attempts < job.MaxAttempts {
+		query = `
+			UPDATE budget.jobs
+			SET status = 'queued', error = $1, locked_at = NULL, run_at = $2
+			WHERE id = $3 AND status = 'running' AND attempts = $4
+		`
+		args = []interface{}{jobErr.Error(), time.Now().Add(backoffFor(job.JobType)(job.Attempts)), job.ID, job.Attempts}
+	} else {
+		query = `
+			UPDATE budget.jobs
+			SET status = 'failed', error = $1, locked_at = NULL, finished_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND status = 'running' AND attempts = $3
+		`
+		args = []interface{}{jobErr.Error(), job.ID, job.Attempts}
+	}
+
+	tag, err := database.DB.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to record job failure: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("job was reclaimed by another worker")
+	}
+
+	return nil
+}
+
+// retryBackoff returns how long to wait before the next attempt (10s, 40s, 90s, ...)
+func retryBackoff(attempts int) time.Duration {
+	return time.Duration(attempts*attempts) * 10 * time.Second
+}
@@ -0,0 +1,11 @@
+package jobs
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupJobRoutes configures background job status endpoints
+func SetupJobRoutes(app *fiber.App) {
+	jobs := app.Group("/api/jobs")
+	jobs.Get("/:id", GetJobHandler) // Get job status, progress and result
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Task is periodic work run by the scheduler, typically finding what's due
+// and enqueuing jobs for it. Tasks must be safe to run on several API
+// instances at once.
+type Task func(ctx context.Context) error
+
+// scheduledTask is a task and how often it runs
+type scheduledTask struct {
+	name     string
+	interval time.Duration
+	task     Task
+}
+
+var (
+	scheduleMu sync.Mutex
+	schedule   []scheduledTask
+)
+
+// Schedule registers a task to run every interval once the scheduler starts
+func Schedule(name string, interval time.Duration, task Task) {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+
+	schedule = append(schedule, scheduledTask{name: name, interval: interval, task: task})
+}
+
+// StartScheduler runs each scheduled task immediately and then on its
+// interval until ctx is cancelled
+func StartScheduler(ctx context.Context) {
+	scheduleMu.Lock()
+	tasks := append([]scheduledTask(nil), schedule...)
+	scheduleMu.Unlock()
+
+	for _, task := range tasks {
+		go runScheduledTask(ctx, task)
+	}
+
+	log.Printf("Started scheduler with %d task(s)", len(tasks))
+}
+
+// runScheduledTask runs one task on a ticker
+func runScheduledTask(ctx context.Context, task scheduledTask) {
+	ticker := time.NewTicker(task.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := safeRunTask(ctx, task); err != nil {
+			log.Printf("Scheduled task %s failed: %v", task.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// safeRunTask calls a task with a timeout of one interval, converting a panic
+// into an error
+func safeRunTask(ctx context.Context, task scheduledTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+
+	taskCtx, cancel := context.WithTimeout(ctx, task.interval)
+	defer cancel()
+
+	return task.task(taskCtx)
+}
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often idle workers check the queue for new jobs
+const pollInterval = 2 * time.Second
+
+// jobTimeout bounds how long a single attempt may run
+const jobTimeout = 10 * time.Minute
+
+// ProgressReporter lets a handler report completion (0-100) and a status message
+type ProgressReporter func(progress int, message string)
+
+// Handler performs a job and returns a JSON-serializable result
+type Handler func(ctx context.Context, job *Job, report ProgressReporter) (interface{}, error)
+
+// Backoff returns how long to wait before retrying a job that has failed
+// attempts times
+type Backoff func(attempts int) time.Duration
+
+// registration holds a job type's handler and retry policy
+type registration struct {
+	handler     Handler
+	maxAttempts int
+	backoff     Backoff
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+	wake       = make(chan struct{}, 1)
+)
+
+// Register associates a job type with the handler that runs it. Failed jobs
+// are retried until maxAttempts attempts have been made.
+func Register(jobType string, maxAttempts int, handler Handler) {
+	RegisterWithBackoff(jobType, maxAttempts, retryBackoff, handler)
+}
+
+// RegisterWithBackoff is Register with a custom wait between retries, for
+// jobs such as webhook deliveries that should back off exponentially
+func RegisterWithBackoff(jobType string, maxAttempts int, backoff Backoff, handler Handler) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	registry[jobType] = registration{handler: handler, maxAttempts: maxAttempts, backoff: backoff}
+}
+
+// ExponentialBackoff waits base, then twice as long after each further failure
+func ExponentialBackoff(base time.Duration) Backoff {
+	return func(attempts int) time.Duration {
+		if attempts < 1 {
+			attempts = 1
+		}
+		return base << (attempts - 1)
+	}
+}
+
+// maxAttemptsFor returns the retry limit for a job type
+func maxAttemptsFor(jobType string) int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if reg, ok := registry[jobType]; ok {
+		return reg.maxAttempts
+	}
+	return 3
+}
+
+// backoffFor returns the retry backoff for a job type
+func backoffFor(jobType string) Backoff {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if reg, ok := registry[jobType]; ok && reg.backoff != nil {
+		return reg.backoff
+	}
+	return retryBackoff
+}
+
+// wakeWorkers nudges an idle worker to check the queue immediately
+func wakeWorkers() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartWorkers launches a pool of workers that process queued jobs until ctx is cancelled
+func StartWorkers(ctx context.Context, count int) {
+	if count < 1 {
+		count = 1
+	}
+
+	for i := 0; i < count; i++ {
+		go runWorker(ctx, i+1)
+	}
+
+	log.Printf("Started %d background job workers", count)
+}
+
+// runWorker repeatedly claims and runs jobs, sleeping when the queue is empty
+func runWorker(ctx context.Context, workerID int) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if failed, err := failStaleJobs(ctx); err != nil {
+			log.Printf("Job worker %d: %v", workerID, err)
+		} else if failed > 0 {
+			log.Printf("Job worker %d: failed %d stale job(s) with no attempts left", workerID, failed)
+		}
+
+		job, err := claimNextJob(ctx)
+		if err != nil {
+			log.Printf("Job worker %d: %v", workerID, err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		runJob(ctx, job)
+	}
+}
+
+// runJob executes a claimed job and records its outcome
+func runJob(ctx context.Context, job *Job) {
+	registryMu.RLock()
+	reg, ok := registry[job.JobType]
+	registryMu.RUnlock()
+
+	if !ok {
+		// Retrying won't help
+		if err := failJob(ctx, job, fmt.Errorf("unknown job type: %s", job.JobType), true); err != nil {
+			log.Printf("Error failing job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	// Only write progress to the database when the percentage changes
+	lastProgress := -1
+	report := func(progress int, message string) {
+		if progress < 0 {
+			progress = 0
+		}
+		if progress > 100 {
+			progress = 100
+		}
+		if progress == lastProgress {
+			return
+		}
+		lastProgress = progress
+		if err := updateJobProgress(jobCtx, job, progress, message); err != nil {
+			log.Printf("Error updating progress for job %s: %v", job.ID, err)
+		}
+	}
+
+	result, err := safeRun(jobCtx, reg.handler, job, report)
+	if err != nil {
+		log.Printf("Job %s (%s) attempt %d/%d failed: %v", job.ID, job.JobType, job.Attempts, job.MaxAttempts, err)
+		if err := failJob(ctx, job, err, false); err != nil {
+			log.Printf("Error failing job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := completeJob(ctx, job, result); err != nil {
+		log.Printf("Error completing job %s: %v", job.ID, err)
+	}
+}
+
+// safeRun calls a handler, converting a panic into an error so one bad job can't kill a worker
+func safeRun(ctx context.Context, handler Handler, job *Job, report ProgressReporter) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job, report)
+}
@@ -0,0 +1,215 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the fake server received
+type smtpSession struct {
+	commands []string
+	from     string
+	to       []string
+	data     string
+}
+
+// startFakeSMTP accepts one connection and speaks just enough SMTP for
+// SMTPMailer, without STARTTLS or AUTH, like Mailpit. The session is sent on
+// the returned channel once the client quits.
+func startFakeSMTP(t *testing.T) (host string, port int, sessions <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	done := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		text := textproto.NewConn(conn)
+		var session smtpSession
+		text.PrintfLine("220 localhost fake SMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			session.commands = append(session.commands, line)
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+			switch verb {
+			case "EHLO", "HELO":
+				text.PrintfLine("250-localhost")
+				text.PrintfLine("250 8BITMIME")
+			case "MAIL":
+				session.from = line
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				session.to = append(session.to, line)
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(text.DotReader())
+				if err != nil {
+					return
+				}
+				session.data = string(data)
+				text.PrintfLine("250 OK: queued")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				done <- session
+				return
+			default:
+				text.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return "127.0.0.1", addr.Port, done
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t)
+
+	m := &SMTPMailer{
+		Host: host,
+		Port: port,
+		From: "Help Me Budget <no-reply@example.com>",
+	}
+
+	text := "You spent R1 234,50 this week — 12% under budget.\n" +
+		".A line starting with a dot\n" +
+		strings.Repeat("long line ", 12) + "= end"
+	html := `<p style="color: red">Café spending: <b>R89,00</b></p>`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := m.Send(ctx, Message{
+		To:      "Jamie Doe <jamie@example.org>",
+		Subject: "Your weekly digest — März",
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server never saw QUIT")
+	}
+
+	// Envelope
+	if !strings.HasPrefix(session.from, "MAIL FROM:<no-reply@example.com>") {
+		t.Errorf("MAIL FROM = %q", session.from)
+	}
+	if len(session.to) != 1 || session.to[0] != "RCPT TO:<jamie@example.org>" {
+		t.Errorf("RCPT TO = %q", session.to)
+	}
+	for _, command := range session.commands {
+		if strings.HasPrefix(strings.ToUpper(command), "AUTH") || strings.HasPrefix(strings.ToUpper(command), "STARTTLS") {
+			t.Errorf("unexpected %q without a username or STARTTLS offer", command)
+		}
+	}
+
+	// Headers
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := msg.Header.Get("From"); got != `"Help Me Budget" <no-reply@example.com>` {
+		t.Errorf("From = %q", got)
+	}
+	if got := msg.Header.Get("To"); got != `"Jamie Doe" <jamie@example.org>` {
+		t.Errorf("To = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Your weekly digest — März" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID = %q", msg.Header.Get("Message-ID"))
+	}
+
+	// Body: multipart/alternative with quoted-printable text then HTML
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	}
+	for i, expected := range want {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != expected.contentType {
+			t.Errorf("part %d Content-Type = %q, want %q", i, got, expected.contentType)
+		}
+		if got := part.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("part %d Content-Transfer-Encoding = %q", i, got)
+		}
+
+		raw, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part %d: %v", i, err)
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(raw)))
+		for scanner.Scan() {
+			if len(scanner.Text()) > 76 {
+				t.Errorf("part %d has an encoded line longer than 76 characters: %q", i, scanner.Text())
+			}
+			for _, r := range scanner.Text() {
+				if r > 127 {
+					t.Errorf("part %d has an unencoded non-ASCII line: %q", i, scanner.Text())
+					break
+				}
+			}
+		}
+
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+		if err != nil {
+			t.Fatalf("decode part %d: %v", i, err)
+		}
+		if string(decoded) != expected.content {
+			t.Errorf("part %d decoded to %q, want %q", i, decoded, expected.content)
+		}
+	}
+	if _, err := reader.NextRawPart(); err != io.EOF {
+		t.Errorf("expected exactly two parts, got another (%v)", err)
+	}
+}
+
+func TestSMTPMailerRejectsInvalidRecipient(t *testing.T) {
+	m := &SMTPMailer{Host: "127.0.0.1", Port: 1, From: "no-reply@example.com"}
+
+	err := m.Send(context.Background(), Message{To: "not an address", Subject: "Hi", Text: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient address") {
+		t.Fatalf("Send error = %v, want invalid recipient address", err)
+	}
+}
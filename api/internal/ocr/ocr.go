@@ -0,0 +1,153 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// recognizeTimeout bounds OCR of one document
+const recognizeTimeout = 60 * time.Second
+
+// maxPDFPages is how many pages of a PDF receipt are read
+const maxPDFPages = 3
+
+// minPDFTextLength is how much embedded text a PDF needs before it's used
+// instead of OCR; scanned PDFs have little or none
+const minPDFTextLength = 20
+
+// Engine turns an image or PDF into plain text
+type Engine interface {
+	Recognize(ctx context.Context, data []byte, contentType string) (string, error)
+}
+
+// TesseractEngine runs the tesseract command-line OCR engine. PDFs are read
+// with poppler's pdftotext when they contain text, otherwise rendered to
+// images with pdftoppm first; without those tools only images are supported.
+type TesseractEngine struct {
+	TesseractPath string
+	PdftotextPath string // Optional
+	PdftoppmPath  string // Optional
+	Language      string // e.g. "eng", or "eng+afr" for several
+}
+
+// NewTesseractEngineFromEnv finds tesseract at TESSERACT_PATH or on the PATH,
+// using OCR_LANGUAGE (default eng). It returns an error when tesseract isn't
+// installed.
+func NewTesseractEngineFromEnv() (*TesseractEngine, error) {
+	tesseractPath := os.Getenv("TESSERACT_PATH")
+	if tesseractPath == "" {
+		tesseractPath = "tesseract"
+	}
+	resolved, err := exec.LookPath(tesseractPath)
+	if err != nil {
+		return nil, fmt.Errorf("tesseract not found: %w", err)
+	}
+
+	language := os.Getenv("OCR_LANGUAGE")
+	if language == "" {
+		language = "eng"
+	}
+
+	engine := &TesseractEngine{
+		TesseractPath: resolved,
+		Language:      language,
+	}
+	if path, err := exec.LookPath("pdftotext"); err == nil {
+		engine.PdftotextPath = path
+	}
+	if path, err := exec.LookPath("pdftoppm"); err == nil {
+		engine.PdftoppmPath = path
+	}
+	return engine, nil
+}
+
+// Recognize returns the text of an image or PDF
+func (e *TesseractEngine) Recognize(ctx context.Context, data []byte, contentType string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, recognizeTimeout)
+	defer cancel()
+
+	if contentType == "application/pdf" {
+		return e.recognizePDF(ctx, data)
+	}
+
+	// "stdin stdout" has tesseract read the image from and write text to pipes;
+	// page segmentation mode 4 reads a single column of variable-size text,
+	// which suits receipts
+	cmd := exec.CommandContext(ctx, e.TesseractPath, "stdin", "stdout", "-l", e.Language, "--psm", "4")
+	cmd.Stdin = bytes.NewReader(data)
+	return runCommand(cmd)
+}
+
+// recognizePDF uses a PDF's embedded text when it has some, and otherwise
+// renders the first pages and runs OCR on each
+func (e *TesseractEngine) recognizePDF(ctx context.Context, data []byte) (string, error) {
+	dir, err := os.MkdirTemp("", "receipt-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pdfPath := filepath.Join(dir, "receipt.pdf")
+	if err := os.WriteFile(pdfPath, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	if e.PdftotextPath != "" {
+		cmd := exec.CommandContext(ctx, e.PdftotextPath, "-layout", "-l", fmt.Sprint(maxPDFPages), pdfPath, "-")
+		text, err := runCommand(cmd)
+		if err == nil && len(strings.Join(strings.Fields(text), "")) >= minPDFTextLength {
+			return text, nil
+		}
+	}
+
+	if e.PdftoppmPath == "" {
+		return "", fmt.Errorf("scanned PDFs need pdftoppm (poppler-utils) installed")
+	}
+
+	cmd := exec.CommandContext(ctx, e.PdftoppmPath, "-r", "300", "-png", "-l", fmt.Sprint(maxPDFPages), pdfPath, filepath.Join(dir, "page"))
+	if _, err := runCommand(cmd); err != nil {
+		return "", err
+	}
+
+	pages, err := filepath.Glob(filepath.Join(dir, "page*.png"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(pages)
+
+	var text strings.Builder
+	for _, page := range pages {
+		cmd := exec.CommandContext(ctx, e.TesseractPath, page, "stdout", "-l", e.Language, "--psm", "4")
+		pageText, err := runCommand(cmd)
+		if err != nil {
+			return "", err
+		}
+		text.WriteString(pageText)
+		text.WriteString("\n")
+	}
+	return text.String(), nil
+}
+
+// runCommand returns a command's output, or an error including the end of
+// what it wrote to stderr
+func runCommand(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > 500 {
+			message = message[len(message)-500:]
+		}
+		return "", fmt.Errorf("%s failed: %w: %s", filepath.Base(cmd.Path), err, message)
+	}
+	return stdout.String(), nil
+}
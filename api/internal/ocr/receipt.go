@@ -0,0 +1,528 @@
+package ocr
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Receipt is what could be read from a receipt's text. Every field has a
+// confidence from 0 to 1; fields that couldn't be found are nil.
+type Receipt struct {
+	Merchant   *TextField   `json:"merchant,omitempty"`
+	Date       *TextField   `json:"date,omitempty"` // YYYY-MM-DD
+	Total      *AmountField `json:"total,omitempty"`
+	Subtotal   *AmountField `json:"subtotal,omitempty"`
+	Tax        *AmountField `json:"tax,omitempty"` // Sum of all tax lines
+	LineItems  []LineItem   `json:"line_items"`
+	Confidence float64      `json:"confidence"` // Overall; the total counts for half
+	Text       string       `json:"text"`       // The OCR output the fields were read from
+}
+
+// TextField is a piece of text read from a receipt
+type TextField struct {
+	Value      string  `json:"value"`
+	Confidence float64 `json:"confidence"`
+	Line       int     `json:"line"` // 1-based line of Text
+}
+
+// AmountField is an amount read from a receipt
+type AmountField struct {
+	Value      float64 `json:"value"`
+	Confidence float64 `json:"confidence"`
+	Line       int     `json:"line"`
+}
+
+// LineItem is a purchased item. Discounts printed under an item are already
+// taken off its amount.
+type LineItem struct {
+	Description string   `json:"description"`
+	Quantity    *float64 `json:"quantity,omitempty"`
+	Amount      float64  `json:"amount"`
+	Confidence  float64  `json:"confidence"`
+	Line        int      `json:"line"`
+}
+
+// amountTolerance is how far apart two amounts can be and still agree, to
+// allow for rounding
+const amountTolerance = 0.011
+
+var (
+	// An amount at the end of a line, optionally with a currency symbol, a
+	// trailing minus for discounts and a tax code letter or two
+	lineAmount = regexp.MustCompile(`(-)?\s?(?:[$€£¥]|\bR)?\s?(\d{1,3}(?:[,.]\d{3})+|\d+)[.,](\d{2})(-)?(?:\s*[A-Za-z*]{1,2})?$`)
+
+	// Quantities such as "2 x 1.50" or "3 @" at either end of a description
+	trailingQuantity = regexp.MustCompile(`(?i)\s+(\d+(?:[.,]\d+)?)\s*[x@]\s*(?:[$€£]?\d+[.,]\d{2})?$`)
+	leadingQuantity  = regexp.MustCompile(`(?i)^(\d+(?:[.,]\d+)?)\s*[x@]\s+`)
+
+	isoDate      = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	numericDate  = regexp.MustCompile(`\b(\d{1,2})[-/.](\d{1,2})[-/.](\d{2}|\d{4})\b`)
+	dayMonthDate = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?[ -]([a-z]{3,9})\.?,?[ -](\d{4}|\d{2})\b`)
+	monthDayDate = regexp.MustCompile(`(?i)\b([a-z]{3,9})\.? (\d{1,2})(?:st|nd|rd|th)?,? (\d{4})\b`)
+)
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "sept": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// Keywords are matched against a line's lowercased words
+var (
+	strongTotalKeywords = []string{"grand total", "amount due", "balance due", "total due", "total amount", "amount payable", "total to pay", "to pay", "summe", "gesamt", "zu zahlen", "totaal", "te betalen", "totale"}
+	notTotalKeywords    = []string{"subtotal", "sub total", "sub-total", "total tax", "total vat", "total savings", "total discount", "total items", "total qty", "items"}
+	subtotalKeywords    = []string{"subtotal", "sub total", "sub-total"}
+	taxKeywords         = []string{"tax", "vat", "gst", "hst", "mwst", "ust", "btw", "iva", "tva"}
+	paymentKeywords     = []string{"cash", "change", "card", "visa", "mastercard", "amex", "debit", "credit", "tender", "paid", "payment", "rounding", "balance", "auth", "approval", "saved", "savings", "points", "loyalty", "tip"}
+	headerSkipKeywords  = []string{"receipt", "invoice", "welcome", "tel", "phone", "fax", "www", "http", "@", "vat no", "reg no", "abn", "date", "time", "store", "cashier", "till", "order", "table", "server", "guest", "copy"}
+)
+
+// receiptLine is one non-empty line of OCR text
+type receiptLine struct {
+	number      int // 1-based line in the original text
+	text        string
+	lower       string
+	description string   // Text before the amount
+	amount      *float64 // Amount at the end of the line
+}
+
+// ParseReceipt reads the merchant, date, totals and line items from a
+// receipt's OCR text. today is used to resolve ambiguous dates such as
+// 03/04/2026, preferring the reading that isn't in the future.
+func ParseReceipt(text string, today time.Time) *Receipt {
+	receipt := &Receipt{LineItems: []LineItem{}, Text: text}
+	lines := splitReceiptLines(text)
+
+	receipt.Merchant = findMerchant(lines)
+	receipt.Date = findDate(lines, today)
+	receipt.Subtotal = findKeywordAmount(lines, subtotalKeywords, 0.85)
+	receipt.Tax = findTax(lines)
+	receipt.Total = findTotal(lines)
+	receipt.LineItems = findLineItems(lines, receipt.Merchant)
+
+	crossCheck(receipt)
+
+	for _, field := range []struct {
+		weight     float64
+		confidence float64
+	}{
+		{0.5, amountConfidence(receipt.Total)},
+		{0.25, textConfidence(receipt.Date)},
+		{0.25, textConfidence(receipt.Merchant)},
+	} {
+		receipt.Confidence += field.weight * field.confidence
+	}
+	receipt.Confidence = roundConfidence(receipt.Confidence)
+
+	return receipt
+}
+
+// splitReceiptLines cleans up lines and pulls an amount printed on its own
+// line up onto the line before, as with "TOTAL" followed by "23.45" or an
+// item name followed by "2 @ 1.50   3.00"
+func splitReceiptLines(text string) []receiptLine {
+	var lines []receiptLine
+	for i, raw := range strings.Split(text, "\n") {
+		cleaned := strings.Join(strings.Fields(raw), " ")
+		if cleaned == "" {
+			continue
+		}
+		line := receiptLine{number: i + 1, text: cleaned, lower: strings.ToLower(cleaned), description: cleaned}
+		if match := lineAmount.FindStringSubmatchIndex(cleaned); match != nil {
+			amount := parseAmount(cleaned, match)
+			line.amount = &amount
+			line.description = strings.TrimSpace(cleaned[:match[0]])
+		}
+
+		if previous := len(lines) - 1; previous >= 0 && line.amount != nil && lines[previous].amount == nil &&
+			!hasLetters(line.description, 2) && hasLetters(lines[previous].text, 2) {
+			merged := &lines[previous]
+			merged.amount = line.amount
+			merged.description = strings.TrimSpace(merged.text + " " + line.description)
+			merged.text += " " + line.text
+			merged.lower = strings.ToLower(merged.text)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseAmount reads the amount matched by lineAmount, treating whichever of
+// "," and "." separates the last two digits as the decimal point
+func parseAmount(text string, match []int) float64 {
+	whole := strings.NewReplacer(",", "", ".", "").Replace(text[match[4]:match[5]])
+	value, _ := strconv.ParseFloat(whole+"."+text[match[6]:match[7]], 64)
+	if match[2] >= 0 || match[8] >= 0 {
+		value = -value
+	}
+	return value
+}
+
+func findMerchant(lines []receiptLine) *TextField {
+	for i, line := range lines {
+		if i >= 6 {
+			break
+		}
+		if line.amount != nil || !hasLetters(line.text, 3) || containsAny(line.lower, headerSkipKeywords) {
+			continue
+		}
+		if isMostlyDigits(line.text) || findDateIn(line.text, time.Now()) != nil {
+			continue
+		}
+
+		name := strings.TrimFunc(line.text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		confidence := 0.75 - 0.1*float64(i)
+		if confidence < 0.4 {
+			confidence = 0.4
+		}
+		return &TextField{Value: name, Confidence: confidence, Line: line.number}
+	}
+	return nil
+}
+
+func findDate(lines []receiptLine, today time.Time) *TextField {
+	var best *TextField
+	for _, line := range lines {
+		field := findDateIn(line.text, today)
+		if field == nil {
+			continue
+		}
+		if strings.Contains(line.lower, "date") {
+			field.Confidence = math.Min(field.Confidence+0.05, 1)
+		}
+		field.Line = line.number
+		if best == nil || field.Confidence > best.Confidence {
+			best = field
+		}
+	}
+	return best
+}
+
+// findDateIn reads the first date in a line
+func findDateIn(text string, today time.Time) *TextField {
+	var candidates []time.Time
+	confidence := 0.0
+
+	if m := isoDate.FindStringSubmatch(text); m != nil {
+		candidates, confidence = dateCandidates(atoi(m[1]), atoi(m[2]), atoi(m[3])), 0.9
+	} else if m := dayMonthDate.FindStringSubmatch(text); m != nil && monthNumber(m[2]) != 0 {
+		candidates, confidence = dateCandidates(fullYear(m[3]), int(monthNumber(m[2])), atoi(m[1])), 0.85
+	} else if m := monthDayDate.FindStringSubmatch(text); m != nil && monthNumber(m[1]) != 0 {
+		candidates, confidence = dateCandidates(fullYear(m[3]), int(monthNumber(m[1])), atoi(m[2])), 0.85
+	} else if m := numericDate.FindStringSubmatch(text); m != nil {
+		first, second, year := atoi(m[1]), atoi(m[2]), fullYear(m[3])
+		dayFirst := dateCandidates(year, second, first)
+		monthFirst := dateCandidates(year, first, second)
+		candidates, confidence = append(dayFirst, monthFirst...), 0.85
+		if len(dayFirst) == 1 && len(monthFirst) == 1 && !dayFirst[0].Equal(monthFirst[0]) {
+			confidence = 0.6 // Either order is a valid date
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Prefer a date that isn't in the future, then the most recent
+	tomorrow := today.AddDate(0, 0, 1)
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		bestFuture, candidateFuture := best.After(tomorrow), candidate.After(tomorrow)
+		if (bestFuture && !candidateFuture) || (bestFuture == candidateFuture && candidate.After(best) != bestFuture) {
+			best = candidate
+		}
+	}
+	if best.After(tomorrow) {
+		confidence *= 0.5
+	} else if best.Before(today.AddDate(-2, 0, 0)) {
+		confidence *= 0.7
+	}
+
+	return &TextField{Value: best.Format("2006-01-02"), Confidence: roundConfidence(confidence)}
+}
+
+// dateCandidates returns the date if it exists, e.g. not 31 February
+func dateCandidates(year, month, day int) []time.Time {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return nil
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return nil
+	}
+	return []time.Time{date}
+}
+
+func monthNumber(name string) time.Month {
+	name = strings.ToLower(name)
+	if month, ok := monthNames[name]; ok {
+		return month
+	}
+	if len(name) > 3 {
+		if month, ok := monthNames[name[:3]]; ok {
+			return month
+		}
+	}
+	return 0
+}
+
+func fullYear(year string) int {
+	value := atoi(year)
+	if len(year) == 2 {
+		value += 2000
+	}
+	return value
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+// findKeywordAmount returns the amount on the last line containing one of
+// the keywords
+func findKeywordAmount(lines []receiptLine, keywords []string, confidence float64) *AmountField {
+	var found *AmountField
+	for _, line := range lines {
+		if line.amount != nil && containsAny(line.lower, keywords) {
+			found = &AmountField{Value: *line.amount, Confidence: confidence, Line: line.number}
+		}
+	}
+	return found
+}
+
+// findTax adds up every tax line, since receipts with several tax rates list
+// each separately
+func findTax(lines []receiptLine) *AmountField {
+	var tax *AmountField
+	for _, line := range lines {
+		if line.amount == nil || !containsAny(line.lower, taxKeywords) || containsAny(line.lower, subtotalKeywords) {
+			continue
+		}
+		if containsAny(line.lower, strongTotalKeywords) || (containsWord(line.lower, "total") && !strings.Contains(line.lower, "total tax") && !strings.Contains(line.lower, "total vat")) {
+			continue // e.g. "TOTAL (INCL VAT)"
+		}
+		if tax == nil {
+			tax = &AmountField{Confidence: 0.8, Line: line.number}
+		}
+		tax.Value = roundAmount(tax.Value + *line.amount)
+	}
+	return tax
+}
+
+// findTotal picks the total from lines such as "TOTAL" or "AMOUNT DUE",
+// falling back to the largest amount that isn't a payment line
+func findTotal(lines []receiptLine) *AmountField {
+	var best *AmountField
+	for _, line := range lines {
+		if line.amount == nil || *line.amount <= 0 || containsAny(line.lower, notTotalKeywords) {
+			continue
+		}
+		confidence := 0.0
+		if containsAny(line.lower, strongTotalKeywords) {
+			confidence = 0.9
+		} else if containsWord(line.lower, "total") {
+			confidence = 0.8
+		} else {
+			continue
+		}
+		if best == nil || confidence > best.Confidence || (confidence == best.Confidence && *line.amount > best.Value) {
+			best = &AmountField{Value: *line.amount, Confidence: confidence, Line: line.number}
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	for _, line := range lines {
+		if line.amount == nil || *line.amount <= 0 || containsAny(line.lower, paymentKeywords) {
+			continue
+		}
+		if best == nil || *line.amount > best.Value {
+			best = &AmountField{Value: *line.amount, Confidence: 0.4, Line: line.number}
+		}
+	}
+	return best
+}
+
+// findLineItems reads priced lines between the merchant and the first
+// subtotal, tax or total line. Negative lines are discounts on the item above.
+func findLineItems(lines []receiptLine, merchant *TextField) []LineItem {
+	items := []LineItem{}
+	for _, line := range lines {
+		if merchant != nil && line.number <= merchant.Line {
+			continue
+		}
+		if line.amount != nil && (containsAny(line.lower, subtotalKeywords) || containsAny(line.lower, strongTotalKeywords) ||
+			containsWord(line.lower, "total") || containsAny(line.lower, taxKeywords)) {
+			break
+		}
+		if line.amount == nil || containsAny(line.lower, paymentKeywords) || containsAny(line.lower, headerSkipKeywords) {
+			continue
+		}
+
+		if *line.amount < 0 {
+			if len(items) > 0 {
+				last := &items[len(items)-1]
+				last.Amount = roundAmount(last.Amount + *line.amount)
+			}
+			continue
+		}
+
+		description := line.description
+		var quantity *float64
+		for _, pattern := range []*regexp.Regexp{trailingQuantity, leadingQuantity} {
+			if m := pattern.FindStringSubmatchIndex(description); m != nil {
+				value, err := strconv.ParseFloat(strings.Replace(description[m[2]:m[3]], ",", ".", 1), 64)
+				if err == nil && value > 0 {
+					quantity = &value
+				}
+				description = strings.TrimSpace(description[:m[0]] + description[m[1]:])
+				break
+			}
+		}
+		if !hasLetters(description, 2) {
+			continue
+		}
+
+		items = append(items, LineItem{
+			Description: description,
+			Quantity:    quantity,
+			Amount:      *line.amount,
+			Confidence:  0.6,
+			Line:        line.number,
+		})
+	}
+	return items
+}
+
+// crossCheck raises confidence when the amounts agree with each other, and
+// fills in a missing total from the subtotal and tax
+func crossCheck(receipt *Receipt) {
+	itemsTotal := 0.0
+	for _, item := range receipt.LineItems {
+		itemsTotal += item.Amount
+	}
+	itemsTotal = roundAmount(itemsTotal)
+
+	if receipt.Total == nil && receipt.Subtotal != nil {
+		value := receipt.Subtotal.Value
+		if receipt.Tax != nil {
+			value = roundAmount(value + receipt.Tax.Value)
+		}
+		receipt.Total = &AmountField{Value: value, Confidence: 0.6, Line: receipt.Subtotal.Line}
+	}
+	if receipt.Total == nil {
+		return
+	}
+
+	total := receipt.Total
+	if receipt.Subtotal != nil && receipt.Tax != nil && amountsAgree(receipt.Subtotal.Value+receipt.Tax.Value, total.Value) {
+		total.Confidence = math.Max(total.Confidence, 0.95)
+		receipt.Subtotal.Confidence = math.Max(receipt.Subtotal.Confidence, 0.95)
+		receipt.Tax.Confidence = math.Max(receipt.Tax.Confidence, 0.95)
+	}
+
+	if len(receipt.LineItems) == 0 {
+		return
+	}
+	itemsAgree := amountsAgree(itemsTotal, total.Value) ||
+		(receipt.Subtotal != nil && amountsAgree(itemsTotal, receipt.Subtotal.Value))
+	if itemsAgree {
+		total.Confidence = math.Max(total.Confidence, 0.95)
+		for i := range receipt.LineItems {
+			receipt.LineItems[i].Confidence = 0.85
+		}
+	}
+}
+
+func amountsAgree(a, b float64) bool {
+	return math.Abs(a-b) < amountTolerance
+}
+
+func amountConfidence(field *AmountField) float64 {
+	if field == nil {
+		return 0
+	}
+	return field.Confidence
+}
+
+func textConfidence(field *TextField) float64 {
+	if field == nil {
+		return 0
+	}
+	return field.Confidence
+}
+
+func roundAmount(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func roundConfidence(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// hasLetters reports whether text has at least n letters
+func hasLetters(text string, n int) bool {
+	count := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			count++
+			if count >= n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isMostlyDigits(text string) bool {
+	digits, letters := 0, 0
+	for _, r := range text {
+		if unicode.IsDigit(r) {
+			digits++
+		} else if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return digits > letters
+}
+
+// containsAny reports whether any keyword appears in text as whole words
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if containsWord(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether phrase appears in text without letters
+// directly either side, so "tax" doesn't match "taxi"
+func containsWord(text, phrase string) bool {
+	for start := 0; ; {
+		i := strings.Index(text[start:], phrase)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(phrase)
+		before := i == 0 || !isLetterByte(text[i-1])
+		after := end == len(text) || !isLetterByte(text[end])
+		if before && after {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isLetterByte(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{
+		"attachments/user/attachment",
+		"a",
+		"a/b.c/d..e",
+	}
+	for _, key := range valid {
+		if err := validateKey(key); err != nil {
+			t.Errorf("validateKey(%q) = %v, want nil", key, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"/etc/passwd",
+		"../secret",
+		"attachments/../../secret",
+		"attachments/./a",
+		"attachments//a",
+		"attachments/a/",
+		"..",
+		`attachments\..\..\secret`,
+	}
+	for _, key := range invalid {
+		if err := validateKey(key); err == nil {
+			t.Errorf("validateKey(%q) = nil, want an error", key)
+		}
+	}
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	parent := t.TempDir()
+	s, err := NewLocalStorage(filepath.Join(parent, "uploads"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"../escaped", "attachments/../../escaped", "/escaped"} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := s.Get(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+		if err := s.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "escaped")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a file was written outside the storage directory (%v)", err)
+	}
+}
+
+func TestLocalStoragePutGetDelete(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := "attachments/user/attachment"
+
+	if err := s.Put(ctx, key, strings.NewReader("receipt"), 7, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	file, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(data) != "receipt" {
+		t.Errorf("Get = %q (%v)", data, err)
+	}
+
+	// A short upload leaves the existing file alone
+	if err := s.Put(ctx, key, strings.NewReader("short"), 100, "image/png"); err == nil {
+		t.Error("Put with the wrong size succeeded")
+	}
+	file, err = s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ = io.ReadAll(file)
+	file.Close()
+	if string(data) != "receipt" {
+		t.Errorf("failed Put replaced the file with %q", data)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of missing key: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key has no stored object
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps uploaded files. Keys are slash-separated paths such as
+// "attachments/<user id>/<attachment id>".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error // Deleting a missing key isn't an error
+}
+
+// URLSigner is implemented by stores that can hand out time-limited URLs
+// for downloading straight from the store rather than through the API
+type URLSigner interface {
+	SignedURL(key, fileName, contentType string, expires time.Duration) (string, error)
+}
+
+// NewFromEnv builds the store selected by STORAGE_DRIVER: "local" (default)
+// keeps files under STORAGE_LOCAL_DIR (default ./data/uploads), and "s3" uses
+// an S3-compatible bucket configured by the S3_* variables (see NewS3StorageFromEnv).
+func NewFromEnv() (Storage, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/uploads"
+		}
+		return NewLocalStorage(dir)
+	case "s3":
+		return NewS3StorageFromEnv()
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER: %s", driver)
+	}
+}
+
+// validateKey rejects keys that could escape the store's root
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/jobs"
+	"github.com/google/uuid"
+)
+
+// JobTypeDeliverWebhook sends one logged delivery to its webhook
+const JobTypeDeliverWebhook = "deliver_webhook"
+
+// deliveryMaxAttempts and deliveryBackoff retry failed deliveries after 30s,
+// 1m, 2m, 4m, 8m and 16m before giving up
+const deliveryMaxAttempts = 7
+
+var deliveryBackoff = jobs.ExponentialBackoff(30 * time.Second)
+
+// deliveryTimeout bounds a single POST to a webhook
+const deliveryTimeout = 10 * time.Second
+
+// maxLoggedResponseBody is how much of a webhook's response the log keeps
+const maxLoggedResponseBody = 1024
+
+// Headers sent with every delivery
+const (
+	SignatureHeader = "X-Webhook-Signature" // t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// httpClient sends deliveries. Redirects aren't followed, so a webhook must
+// answer at its configured URL. Addresses are checked as each connection is
+// dialled, after DNS resolution, so a hostname that later resolves to a
+// private address or the cloud metadata address is still refused.
+var httpClient = &http.Client{
+	Timeout: deliveryTimeout,
+	Transport: &http.Transport{
+		Proxy: nil, // A proxy would make the dial check see the proxy's address instead
+		DialContext: (&net.Dialer{
+			Timeout: deliveryTimeout,
+			Control: refuseInternalAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: deliveryTimeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// reservedNetworks are never reachable by webhooks unless an operator allows
+// them: this host, private and shared (CGNAT) networks, link-local and cloud
+// metadata, multicast, documentation and benchmarking ranges, and IPv6
+// transition prefixes that can embed one of those IPv4 addresses
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.88.99.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001::/23",
+	"2001:db8::/32",
+	"2002::/16",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// allowedNetworks are reserved networks the operator has opened to webhooks
+// with WEBHOOK_ALLOWED_NETWORKS, e.g. a home LAN when self-hosting. It is
+// read on first use because .env is loaded after package initialisation.
+var (
+	allowedNetworks     []*net.IPNet
+	allowedNetworksOnce sync.Once
+)
+
+// mustParseCIDRs parses a fixed list of networks
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// loadAllowedNetworks reads WEBHOOK_ALLOWED_NETWORKS, a comma-separated list
+// of CIDRs. Invalid entries are logged and skipped.
+func loadAllowedNetworks() []*net.IPNet {
+	allowedNetworksOnce.Do(func() {
+		for _, cidr := range strings.Split(os.Getenv("WEBHOOK_ALLOWED_NETWORKS"), ",") {
+			cidr = strings.TrimSpace(cidr)
+			if cidr == "" {
+				continue
+			}
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				log.Printf("Ignoring invalid WEBHOOK_ALLOWED_NETWORKS entry %q: %v", cidr, err)
+				continue
+			}
+			allowedNetworks = append(allowedNetworks, network)
+		}
+	})
+	return allowedNetworks
+}
+
+// refuseInternalAddress stops deliveries reaching this server, its private
+// network (databases, admin panels, other services) or cloud metadata
+// (169.254.169.254)
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("webhook address %s is not an IP address", address)
+	}
+	if isRefusedIP(ip) {
+		return fmt.Errorf("webhook address %s is not allowed (private or reserved)", ip)
+	}
+	return nil
+}
+
+// isRefusedIP reports whether webhooks may not be delivered to ip
+func isRefusedIP(ip net.IP) bool {
+	for _, network := range loadAllowedNetworks() {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// attemptResult is the outcome of POSTing a delivery once
+type attemptResult struct {
+	responseStatus *int
+	responseBody   *string
+	durationMs     int
+	err            error
+}
+
+// RegisterJobHandlers registers the delivery job with exponential backoff
+func RegisterJobHandlers() {
+	jobs.RegisterWithBackoff(JobTypeDeliverWebhook, deliveryMaxAttempts, deliveryBackoff, runDeliverWebhookJob)
+}
+
+// Publish sends an event to each of the user's active webhooks subscribed to
+// it. Every delivery is logged and queued, so slow or failing endpoints never
+// hold up the caller; failures to queue are logged rather than returned.
+func Publish(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) {
+	webhooks, err := getSubscribedWebhooks(ctx, userID, eventType)
+	if err != nil {
+		log.Printf("Error loading webhooks for %s event (user %s): %v", eventType, userID, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", eventType, err)
+		return
+	}
+
+	for i := range webhooks {
+		delivery, err := createDelivery(ctx, &webhooks[i], eventType, payload)
+		if err != nil {
+			log.Printf("Error logging %s delivery to webhook %s: %v", eventType, webhooks[i].ID, err)
+			continue
+		}
+		if _, err := jobs.Enqueue(ctx, userID, JobTypeDeliverWebhook, map[string]uuid.UUID{"delivery_id": delivery.ID}); err != nil {
+			log.Printf("Error queueing delivery %s: %v", delivery.ID, err)
+			failDelivery(ctx, delivery.ID, "failed to queue delivery")
+		}
+	}
+}
+
+// SendTestEvent sends a ping to a webhook immediately, without retries, and
+// returns the logged delivery
+func SendTestEvent(ctx context.Context, webhook *Webhook) (*Delivery, error) {
+	payload, err := json.Marshal(Event{
+		ID:        uuid.New(),
+		Type:      EventPing,
+		CreatedAt: time.Now().UTC(),
+		Data: map[string]interface{}{
+			"webhook_id": webhook.ID,
+			"message":    "Test event from Help Me Budget",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := createDelivery(ctx, webhook, EventPing, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordDeliveryAttempt(ctx, delivery.ID, send(ctx, webhook, delivery), true)
+}
+
+// runDeliverWebhookJob makes one attempt at a queued delivery. A failed
+// attempt returns an error so the job queue retries it with backoff.
+func runDeliverWebhookJob(ctx context.Context, job *jobs.Job, report jobs.ProgressReporter) (interface{}, error) {
+	var payload struct {
+		DeliveryID uuid.UUID `json:"delivery_id"`
+	}
+	if err := job.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	delivery, err := getDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The webhook may have been deleted or paused since the event was queued
+	webhook, err := GetWebhookByID(ctx, delivery.WebhookID, delivery.UserID)
+	if err != nil && err.Error() != "webhook not found" {
+		return nil, err
+	}
+	if webhook == nil || !webhook.IsActive {
+		if err := failDelivery(ctx, delivery.ID, "webhook is disabled"); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"skipped": "webhook is disabled"}, nil
+	}
+
+	result := send(ctx, webhook, delivery)
+	final := job.Attempts >= job.MaxAttempts
+	if _, err := recordDeliveryAttempt(ctx, delivery.ID, result, final); err != nil {
+		return nil, err
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	return map[string]interface{}{"delivery_id": delivery.ID, "response_status": result.responseStatus}, nil
+}
+
+// send POSTs a delivery's payload to its webhook, signed with the webhook's
+// secret. Any 2xx response counts as delivered. Only a 2xx response's body is
+// logged, so a failing URL can't be used to read pages it wasn't meant for.
+func send(ctx context.Context, webhook *Webhook, delivery *Delivery) *attemptResult {
+	result := &attemptResult{}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		result.err = fmt.Errorf("invalid webhook request: %w", err)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HelpMeBudget-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, "t="+timestamp+",v1="+Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	result.durationMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		result.err = fmt.Errorf("request failed: %w", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.responseStatus = &resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.err = fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+		return result
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedResponseBody))
+	bodyText := string(body)
+	result.responseBody = &bodyText
+	return result
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// webhook's secret. Receivers should recompute it from the X-Webhook-Signature
+// timestamp and the raw body, compare in constant time, and reject old
+// timestamps to stop replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateSecret creates a random signing secret
+func generateSecret() (string, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+// validateWebhookURL requires an absolute http(s) URL, and https in production.
+// Hosts that are obviously internal are refused up front; hostnames are
+// checked again when each delivery connects.
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("URL must be an absolute http or https URL")
+	}
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		if os.Getenv("APP_ENV") == "production" {
+			return fmt.Errorf("URL must use https")
+		}
+	default:
+		return fmt.Errorf("URL must be an absolute http or https URL")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("URL must not point at a private or reserved address")
+	}
+	if ip := net.ParseIP(host); ip != nil && isRefusedIP(ip) {
+		return fmt.Errorf("URL must not point at a private or reserved address")
+	}
+	return nil
+}
+
+// validateEvents requires at least one supported, distinct event
+func validateEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("At least one event is required")
+	}
+
+	seen := make(map[string]bool)
+	for _, event := range events {
+		supported := false
+		for _, s := range SupportedEvents {
+			if event == s {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("Unsupported event %q (use transaction.created, transaction.matched, budget.exceeded or bill.overdue)", event)
+		}
+		if seen[event] {
+			return fmt.Errorf("Event %q is listed more than once", event)
+		}
+		seen[event] = true
+	}
+	return nil
+}
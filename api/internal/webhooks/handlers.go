@@ -0,0 +1,334 @@
+package webhooks
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// getUserIDFromContext extracts user ID from X-User-ID header
+func getUserIDFromContext(c *fiber.Ctx) uuid.UUID {
+	userIDStr := c.Get("X-User-ID")
+	if userIDStr == "" {
+		return uuid.Nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil
+	}
+
+	return userID
+}
+
+// withoutSecret hides a webhook's secret outside of create and rotate responses
+func withoutSecret(webhook *Webhook) *Webhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+// GetWebhooksHandler returns all of a user's webhooks
+func GetWebhooksHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhooks, err := GetWebhooksByUserID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve webhooks",
+		})
+	}
+
+	if webhooks == nil {
+		webhooks = []Webhook{}
+	}
+	for i := range webhooks {
+		withoutSecret(&webhooks[i])
+	}
+
+	return c.JSON(fiber.Map{
+		"webhooks":         webhooks,
+		"supported_events": SupportedEvents,
+	})
+}
+
+// GetWebhookHandler returns a specific webhook
+func GetWebhookHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	webhook, err := GetWebhookByID(c.Context(), webhookID, userID)
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Webhook not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve webhook",
+		})
+	}
+
+	return c.JSON(withoutSecret(webhook))
+}
+
+// CreateWebhookHandler creates a webhook. The response includes the signing
+// secret, which isn't shown again.
+func CreateWebhookHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	var req CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := validateEvents(req.Events); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	webhook, err := CreateWebhook(c.Context(), userID, req)
+	if err != nil {
+		log.Printf("Error creating webhook for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create webhook",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(webhook)
+}
+
+// UpdateWebhookHandler changes a webhook's URL, description, events or active state
+func UpdateWebhookHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	if req.Events != nil {
+		if err := validateEvents(req.Events); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	webhook, err := UpdateWebhook(c.Context(), webhookID, userID, req)
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Webhook not found",
+			})
+		}
+		log.Printf("Error updating webhook %s: %v", webhookID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update webhook",
+		})
+	}
+
+	return c.JSON(withoutSecret(webhook))
+}
+
+// RotateWebhookSecretHandler replaces a webhook's signing secret and returns the new one
+func RotateWebhookSecretHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	webhook, err := RotateWebhookSecret(c.Context(), webhookID, userID)
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Webhook not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to rotate webhook secret",
+		})
+	}
+
+	return c.JSON(webhook)
+}
+
+// DeleteWebhookHandler deletes a webhook and its delivery log
+func DeleteWebhookHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	if err := DeleteWebhook(c.Context(), webhookID, userID); err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Webhook not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete webhook",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// TestWebhookHandler sends a ping event to a webhook straight away and returns
+// the logged delivery, including the endpoint's response
+func TestWebhookHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	webhook, err := GetWebhookByID(c.Context(), webhookID, userID)
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Webhook not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send test event",
+		})
+	}
+
+	delivery, err := SendTestEvent(c.Context(), webhook)
+	if err != nil {
+		log.Printf("Error sending test event to webhook %s: %v", webhookID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send test event",
+		})
+	}
+
+	return c.JSON(delivery)
+}
+
+// GetWebhookDeliveriesHandler returns a webhook's delivery log, newest first
+// (supports ?limit=50)
+func GetWebhookDeliveriesHandler(c *fiber.Ctx) error {
+	userID := getUserIDFromContext(c)
+	if userID == uuid.Nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	webhookID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook ID",
+		})
+	}
+
+	limit := 50
+	if limitParam := c.QueryInt("limit", 50); limitParam > 0 && limitParam <= 200 {
+		limit = limitParam
+	}
+
+	if _, err := GetWebhookByID(c.Context(), webhookID, userID); err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Webhook not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve deliveries",
+		})
+	}
+
+	deliveries, err := GetDeliveriesByWebhookID(c.Context(), webhookID, userID, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve deliveries",
+		})
+	}
+
+	if deliveries == nil {
+		deliveries = []Delivery{}
+	}
+
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+	})
+}
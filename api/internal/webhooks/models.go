@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types a webhook can subscribe to
+const (
+	EventTransactionCreated = "transaction.created"
+	EventTransactionMatched = "transaction.matched"
+	EventBudgetExceeded     = "budget.exceeded"
+	EventBillOverdue        = "bill.overdue"
+)
+
+// EventPing is sent by the test endpoint; webhooks can't subscribe to it
+const EventPing = "ping"
+
+// SupportedEvents lists the events a webhook can subscribe to
+var SupportedEvents = []string{
+	EventTransactionCreated,
+	EventTransactionMatched,
+	EventBudgetExceeded,
+	EventBillOverdue,
+}
+
+// Webhook is an endpoint that receives signed event payloads
+type Webhook struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	URL         string    `json:"url"`
+	Description *string   `json:"description,omitempty"`
+	Events      []string  `json:"events"`
+	Secret      string    `json:"secret,omitempty"` // Only returned when created or rotated
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateWebhookRequest represents the request body for creating a webhook
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description *string  `json:"description,omitempty"`
+	Events      []string `json:"events"`
+}
+
+// UpdateWebhookRequest represents the request body for updating a webhook
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// Event is the JSON body POSTed to a webhook
+type Event struct {
+	ID        uuid.UUID   `json:"id"` // Shared by every webhook the event is sent to
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Delivery is one event sent to a webhook, with the outcome of its latest attempt
+type Delivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"` // 'pending', 'succeeded', 'failed'
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	ResponseBody   *string         `json:"response_body,omitempty"` // Only kept for 2xx responses
+	Error          *string         `json:"error,omitempty"`
+	DurationMs     *int            `json:"duration_ms,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
@@ -0,0 +1,330 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brendenbissett/help-me-budget/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookColumns = `id, user_id, url, description, events, secret, is_active, created_at, updated_at`
+
+// scanWebhook scans a single webhook row, including its secret
+func scanWebhook(row pgx.Row) (*Webhook, error) {
+	var webhook Webhook
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Description,
+		&webhook.Events,
+		&webhook.Secret,
+		&webhook.IsActive,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// queryWebhooks runs a query returning webhook rows
+func queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]Webhook, error) {
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhooksByUserID retrieves all of a user's webhooks
+func GetWebhooksByUserID(ctx context.Context, userID uuid.UUID) ([]Webhook, error) {
+	return queryWebhooks(ctx, `
+		SELECT `+webhookColumns+`
+		FROM budget.webhooks
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+}
+
+// getSubscribedWebhooks retrieves a user's active webhooks subscribed to an event
+func getSubscribedWebhooks(ctx context.Context, userID uuid.UUID, eventType string) ([]Webhook, error) {
+	return queryWebhooks(ctx, `
+		SELECT `+webhookColumns+`
+		FROM budget.webhooks
+		WHERE user_id = $1 AND is_active AND $2 = ANY(events)
+	`, userID, eventType)
+}
+
+// GetWebhookByID retrieves a specific webhook
+func GetWebhookByID(ctx context.Context, webhookID uuid.UUID, userID uuid.UUID) (*Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM budget.webhooks
+		WHERE id = $1 AND user_id = $2
+	`
+
+	webhook, err := scanWebhook(database.DB.QueryRow(ctx, query, webhookID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// CreateWebhook saves a new webhook with a freshly generated secret
+func CreateWebhook(ctx context.Context, userID uuid.UUID, req CreateWebhookRequest) (*Webhook, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO budget.webhooks (user_id, url, description, events, secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + webhookColumns
+
+	webhook, err := scanWebhook(database.DB.QueryRow(ctx, query, userID, req.URL, req.Description, req.Events, secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook applies changes to a webhook
+func UpdateWebhook(ctx context.Context, webhookID uuid.UUID, userID uuid.UUID, req UpdateWebhookRequest) (*Webhook, error) {
+	query := `
+		UPDATE budget.webhooks
+		SET url = COALESCE($3, url),
+		    description = COALESCE($4, description),
+		    events = COALESCE($5, events),
+		    is_active = COALESCE($6, is_active)
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + webhookColumns
+
+	var events interface{}
+	if req.Events != nil {
+		events = req.Events
+	}
+
+	webhook, err := scanWebhook(database.DB.QueryRow(ctx, query, webhookID, userID, req.URL, req.Description, events, req.IsActive))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// RotateWebhookSecret replaces a webhook's signing secret
+func RotateWebhookSecret(ctx context.Context, webhookID uuid.UUID, userID uuid.UUID) (*Webhook, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE budget.webhooks SET secret = $3
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + webhookColumns
+
+	webhook, err := scanWebhook(database.DB.QueryRow(ctx, query, webhookID, userID, secret))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+func DeleteWebhook(ctx context.Context, webhookID uuid.UUID, userID uuid.UUID) error {
+	result, err := database.DB.Exec(ctx, `
+		DELETE FROM budget.webhooks WHERE id = $1 AND user_id = $2
+	`, webhookID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+const deliveryColumns = `id, webhook_id, user_id, event_type, payload, status, attempts, response_status,
+	response_body, error, duration_ms, last_attempt_at, delivered_at, created_at`
+
+// scanDelivery scans a single delivery row
+func scanDelivery(row pgx.Row) (*Delivery, error) {
+	var delivery Delivery
+	var payload []byte
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.UserID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.ResponseBody,
+		&delivery.Error,
+		&delivery.DurationMs,
+		&delivery.LastAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	return &delivery, nil
+}
+
+// createDelivery logs an event that is about to be sent to a webhook
+func createDelivery(ctx context.Context, webhook *Webhook, eventType string, payload []byte) (*Delivery, error) {
+	query := `
+		INSERT INTO budget.webhook_deliveries (webhook_id, user_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + deliveryColumns
+
+	delivery, err := scanDelivery(database.DB.QueryRow(ctx, query, webhook.ID, webhook.UserID, eventType, payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// getDelivery retrieves a delivery by ID for the worker sending it
+func getDelivery(ctx context.Context, deliveryID uuid.UUID) (*Delivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM budget.webhook_deliveries
+		WHERE id = $1
+	`
+
+	delivery, err := scanDelivery(database.DB.QueryRow(ctx, query, deliveryID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// recordDeliveryAttempt stores the outcome of an attempt. The delivery stays
+// pending while retries remain; final marks the last attempt.
+func recordDeliveryAttempt(ctx context.Context, deliveryID uuid.UUID, result *attemptResult, final bool) (*Delivery, error) {
+	status := "pending"
+	var errorMessage *string
+	if result.err == nil {
+		status = "succeeded"
+	} else {
+		message := result.err.Error()
+		errorMessage = &message
+		if final {
+			status = "failed"
+		}
+	}
+
+	query := `
+		UPDATE budget.webhook_deliveries
+		SET status = $2,
+		    attempts = attempts + 1,
+		    response_status = $3,
+		    response_body = $4,
+		    error = $5,
+		    duration_ms = $6,
+		    last_attempt_at = CURRENT_TIMESTAMP,
+		    delivered_at = CASE WHEN $2 = 'succeeded' THEN CURRENT_TIMESTAMP ELSE delivered_at END
+		WHERE id = $1
+		RETURNING ` + deliveryColumns
+
+	delivery, err := scanDelivery(database.DB.QueryRow(ctx, query,
+		deliveryID,
+		status,
+		result.responseStatus,
+		result.responseBody,
+		errorMessage,
+		result.durationMs,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// failDelivery marks a delivery failed without attempting it
+func failDelivery(ctx context.Context, deliveryID uuid.UUID, reason string) error {
+	_, err := database.DB.Exec(ctx, `
+		UPDATE budget.webhook_deliveries SET status = 'failed', error = $2 WHERE id = $1
+	`, deliveryID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery failed: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveriesByWebhookID retrieves a webhook's most recent deliveries
+func GetDeliveriesByWebhookID(ctx context.Context, webhookID uuid.UUID, userID uuid.UUID, limit int) ([]Delivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM budget.webhook_deliveries
+		WHERE webhook_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := database.DB.Query(ctx, query, webhookID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
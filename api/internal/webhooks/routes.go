@@ -0,0 +1,18 @@
+package webhooks
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupWebhookRoutes configures outgoing webhook endpoints
+func SetupWebhookRoutes(app *fiber.App) {
+	webhooks := app.Group("/api/webhooks")
+	webhooks.Get("/", GetWebhooksHandler)                           // List webhooks and the events they can subscribe to
+	webhooks.Get("/:id", GetWebhookHandler)                         // Get specific webhook
+	webhooks.Post("/", CreateWebhookHandler)                        // Create webhook (response includes the signing secret)
+	webhooks.Put("/:id", UpdateWebhookHandler)                      // Update URL, description, events or is_active
+	webhooks.Delete("/:id", DeleteWebhookHandler)                   // Delete webhook and its delivery log
+	webhooks.Post("/:id/rotate-secret", RotateWebhookSecretHandler) // Replace the signing secret
+	webhooks.Post("/:id/test", TestWebhookHandler)                  // Send a ping event now and return the delivery
+	webhooks.Get("/:id/deliveries", GetWebhookDeliveriesHandler)    // Delivery log, newest first (supports ?limit=50)
+}
@@ -0,0 +1,8 @@
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_matching_batch_items_transaction_id;
+DROP INDEX IF EXISTS budget.idx_matching_batch_items_batch_id;
+DROP INDEX IF EXISTS budget.idx_matching_batches_user_id;
+
+-- Drop tables (in reverse order of dependencies)
+DROP TABLE IF EXISTS budget.matching_batch_items;
+DROP TABLE IF EXISTS budget.matching_batches;
@@ -0,0 +1,30 @@
+-- Create matching_batches table (one row per bulk match, commit or unlink operation)
+CREATE TABLE budget.matching_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL, -- 'bulk_auto_match', 'bulk_commit', 'unlink'
+    item_count INT NOT NULL DEFAULT 0,
+    undone_at TIMESTAMP WITH TIME ZONE, -- Set once the batch has been rolled back
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_batch_source CHECK (source IN ('bulk_auto_match', 'bulk_commit', 'unlink'))
+);
+
+-- Create matching_batch_items table (before/after state of each transaction touched by a batch)
+CREATE TABLE budget.matching_batch_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id UUID NOT NULL REFERENCES budget.matching_batches(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL REFERENCES budget.transactions(id) ON DELETE CASCADE,
+    previous_budget_entry_id UUID REFERENCES budget.budget_entries(id) ON DELETE SET NULL,
+    previous_match_confidence VARCHAR(20) NOT NULL,
+    new_budget_entry_id UUID REFERENCES budget.budget_entries(id) ON DELETE SET NULL,
+    new_match_confidence VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes for performance
+CREATE INDEX idx_matching_batches_user_id ON budget.matching_batches(user_id, created_at DESC);
+CREATE INDEX idx_matching_batch_items_batch_id ON budget.matching_batch_items(batch_id);
+CREATE INDEX idx_matching_batch_items_transaction_id ON budget.matching_batch_items(transaction_id);
+
+COMMENT ON TABLE budget.matching_batches IS 'Groups transaction match changes so they can be undone together';
+COMMENT ON TABLE budget.matching_batch_items IS 'Previous and new match state for each transaction in a matching batch';
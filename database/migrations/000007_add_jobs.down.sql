@@ -0,0 +1,9 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_jobs_updated_at ON budget.jobs;
+
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_jobs_user_id;
+DROP INDEX IF EXISTS budget.idx_jobs_queue;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.jobs;
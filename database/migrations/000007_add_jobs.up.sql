@@ -0,0 +1,34 @@
+-- Create jobs table (queue for long-running background operations)
+CREATE TABLE budget.jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    job_type VARCHAR(100) NOT NULL, -- e.g. 'bulk_auto_match'
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- 'queued', 'running', 'succeeded', 'failed'
+    payload JSONB, -- Job input
+    result JSONB, -- Job output (set on success)
+    error TEXT, -- Last error message
+    progress INT NOT NULL DEFAULT 0, -- 0-100
+    progress_message TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 3,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Earliest time the job may (re)run
+    locked_at TIMESTAMP WITH TIME ZONE, -- When a worker claimed the job
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_job_status CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    CONSTRAINT valid_job_progress CHECK (progress >= 0 AND progress <= 100)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_jobs_queue ON budget.jobs(status, run_at);
+CREATE INDEX idx_jobs_user_id ON budget.jobs(user_id, job_type, status);
+
+-- Create updated_at trigger
+CREATE TRIGGER update_jobs_updated_at
+    BEFORE UPDATE ON budget.jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.jobs IS 'Background job queue claimed by API worker pool with FOR UPDATE SKIP LOCKED';
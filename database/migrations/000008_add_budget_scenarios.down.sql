@@ -0,0 +1,8 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_budget_scenarios_updated_at ON budget.budget_scenarios;
+
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_budget_scenarios_budget_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.budget_scenarios;
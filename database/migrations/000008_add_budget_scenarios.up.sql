@@ -0,0 +1,22 @@
+-- Create budget scenarios table (saved what-if drafts layered over a budget)
+CREATE TABLE budget.budget_scenarios (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    budget_id UUID NOT NULL REFERENCES budget.budgets(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    changes JSONB NOT NULL, -- {"add": [...], "remove": [...], "change": [...]} applied to the budget's entries
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_budget_scenario_name UNIQUE (budget_id, name)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_budget_scenarios_budget_id ON budget.budget_scenarios(budget_id, user_id);
+
+-- Create updated_at trigger
+CREATE TRIGGER update_budget_scenarios_updated_at
+    BEFORE UPDATE ON budget.budget_scenarios
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.budget_scenarios IS 'Named what-if drafts: hypothetical entry additions, removals and amount changes for a budget';
@@ -0,0 +1,8 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_digest_settings_updated_at ON budget.digest_settings;
+
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_digest_settings_due;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.digest_settings;
@@ -0,0 +1,29 @@
+-- Create digest settings table (per-user schedule for budget summary emails)
+CREATE TABLE budget.digest_settings (
+    user_id UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE, -- Users can opt out
+    frequency VARCHAR(10) NOT NULL DEFAULT 'weekly', -- 'weekly' or 'monthly'
+    day_of_week INT NOT NULL DEFAULT 1, -- 0-6 (Sunday-Saturday), used by weekly digests
+    day_of_month INT NOT NULL DEFAULT 1, -- 1-28, used by monthly digests
+    send_hour INT NOT NULL DEFAULT 8, -- 0-23 in the user's timezone
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA name, e.g. 'Africa/Johannesburg'
+    next_send_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_digest_frequency CHECK (frequency IN ('weekly', 'monthly')),
+    CONSTRAINT valid_digest_day_of_week CHECK (day_of_week >= 0 AND day_of_week <= 6),
+    CONSTRAINT valid_digest_day_of_month CHECK (day_of_month >= 1 AND day_of_month <= 28),
+    CONSTRAINT valid_digest_send_hour CHECK (send_hour >= 0 AND send_hour <= 23)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_digest_settings_due ON budget.digest_settings(next_send_at) WHERE enabled;
+
+-- Create updated_at trigger
+CREATE TRIGGER update_digest_settings_updated_at
+    BEFORE UPDATE ON budget.digest_settings
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.digest_settings IS 'Per-user weekly/monthly email digest schedule; the scheduler queues a send_digest job when next_send_at passes';
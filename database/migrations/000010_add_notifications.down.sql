@@ -0,0 +1,11 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_alert_rules_updated_at ON budget.alert_rules;
+
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_notifications_unread;
+DROP INDEX IF EXISTS budget.idx_notifications_user_created;
+DROP INDEX IF EXISTS budget.idx_alert_rules_user_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.notifications;
+DROP TABLE IF EXISTS budget.alert_rules;
@@ -0,0 +1,48 @@
+-- Create alert rules table (user-configured conditions that raise notifications)
+CREATE TABLE budget.alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    rule_type VARCHAR(30) NOT NULL, -- 'category_spend', 'entry_spend', 'bill_due', 'bill_overdue', 'low_balance', 'large_transaction'
+    category_id UUID REFERENCES budget.categories(id) ON DELETE CASCADE, -- category_spend
+    budget_entry_id UUID REFERENCES budget.budget_entries(id) ON DELETE CASCADE, -- entry_spend; optional for bill_due/bill_overdue
+    account_id UUID REFERENCES budget.accounts(id) ON DELETE CASCADE, -- Optional for low_balance/large_transaction
+    threshold NUMERIC(20, 2), -- Warning percent for spend rules, amount for low_balance/large_transaction
+    days_ahead INT, -- bill_due: days before the due date; low_balance: projection horizon
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_alert_rule_type CHECK (rule_type IN ('category_spend', 'entry_spend', 'bill_due', 'bill_overdue', 'low_balance', 'large_transaction')),
+    CONSTRAINT valid_alert_days_ahead CHECK (days_ahead IS NULL OR (days_ahead >= 0 AND days_ahead <= 365))
+);
+
+-- Create notifications table
+CREATE TABLE budget.notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    alert_rule_id UUID REFERENCES budget.alert_rules(id) ON DELETE SET NULL,
+    notification_type VARCHAR(30) NOT NULL, -- 'budget_warning', 'budget_exceeded', 'bill_due', 'bill_overdue', 'low_balance', 'large_transaction'
+    severity VARCHAR(10) NOT NULL DEFAULT 'info', -- 'info', 'warning', 'critical'
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}', -- Amounts, dates and IDs behind the notification
+    dedupe_key VARCHAR(255) NOT NULL, -- Identifies the condition so re-evaluating doesn't notify twice
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_notification_severity CHECK (severity IN ('info', 'warning', 'critical')),
+    CONSTRAINT unique_notification_dedupe_key UNIQUE (user_id, dedupe_key)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_alert_rules_user_id ON budget.alert_rules(user_id) WHERE is_active;
+CREATE INDEX idx_notifications_user_created ON budget.notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON budget.notifications(user_id) WHERE read_at IS NULL;
+
+-- Create updated_at trigger
+CREATE TRIGGER update_alert_rules_updated_at
+    BEFORE UPDATE ON budget.alert_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.alert_rules IS 'User-configured alert conditions, evaluated after transaction writes and daily';
+COMMENT ON TABLE budget.notifications IS 'In-app notifications raised by alert rules, with read/unread state';
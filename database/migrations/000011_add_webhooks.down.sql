@@ -0,0 +1,10 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_webhooks_updated_at ON budget.webhooks;
+
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_webhook_deliveries_webhook_id;
+DROP INDEX IF EXISTS budget.idx_webhooks_user_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.webhook_deliveries;
+DROP TABLE IF EXISTS budget.webhooks;
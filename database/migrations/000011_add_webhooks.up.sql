@@ -0,0 +1,45 @@
+-- Create webhooks table (user endpoints that receive signed event payloads)
+CREATE TABLE budget.webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description VARCHAR(255),
+    events TEXT[] NOT NULL, -- 'transaction.created', 'transaction.matched', 'budget.exceeded', 'bill.overdue'
+    secret VARCHAR(100) NOT NULL, -- HMAC-SHA256 signing key
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT webhook_has_events CHECK (cardinality(events) > 0)
+);
+
+-- Create webhook deliveries table (one row per event sent to a webhook, updated on each attempt)
+CREATE TABLE budget.webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES budget.webhooks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL, -- Exact body sent, so retries are signed over the same bytes
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'succeeded', 'failed'
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT, -- HTTP status of the last attempt
+    response_body TEXT, -- First 1KB of the last response
+    error TEXT, -- Why the last attempt failed
+    duration_ms INT, -- How long the last attempt took
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_webhook_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+-- Indexes for performance
+CREATE INDEX idx_webhooks_user_id ON budget.webhooks(user_id) WHERE is_active;
+CREATE INDEX idx_webhook_deliveries_webhook_id ON budget.webhook_deliveries(webhook_id, created_at DESC);
+
+-- Create updated_at trigger
+CREATE TRIGGER update_webhooks_updated_at
+    BEFORE UPDATE ON budget.webhooks
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.webhooks IS 'Outgoing webhook endpoints with the events they subscribe to and their signing secret';
+COMMENT ON TABLE budget.webhook_deliveries IS 'Delivery log: each event sent to a webhook with the outcome of its latest attempt';
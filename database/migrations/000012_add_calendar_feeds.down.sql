@@ -0,0 +1,5 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_calendar_feeds_updated_at ON budget.calendar_feeds;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.calendar_feeds;
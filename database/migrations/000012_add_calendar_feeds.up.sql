@@ -0,0 +1,18 @@
+-- Create calendar feeds table (per-user secret token for subscribing to bills and paydays)
+CREATE TABLE budget.calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE, -- Secret in the feed URL; rotating it revokes old subscriptions
+    months_ahead INT NOT NULL DEFAULT 3, -- How far ahead occurrences are expanded
+    last_accessed_at TIMESTAMP WITH TIME ZONE, -- Last time a calendar app fetched the feed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_calendar_months_ahead CHECK (months_ahead >= 1 AND months_ahead <= 12)
+);
+
+-- Create updated_at trigger
+CREATE TRIGGER update_calendar_feeds_updated_at
+    BEFORE UPDATE ON budget.calendar_feeds
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.calendar_feeds IS 'Per-user iCalendar feed of upcoming budget entry occurrences, fetched by token without a session';
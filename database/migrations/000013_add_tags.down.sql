@@ -0,0 +1,9 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_tags_updated_at ON budget.tags;
+
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_transaction_tags_tag_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.transaction_tags;
+DROP TABLE IF EXISTS budget.tags;
@@ -0,0 +1,30 @@
+-- Create tags table (cross-cutting labels like 'holiday-2026' or 'tax-deductible')
+CREATE TABLE budget.tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL, -- Lowercase, with spaces replaced by hyphens
+    color VARCHAR(7), -- Hex color code (e.g., #FF5733)
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_tag_name UNIQUE (user_id, name)
+);
+
+-- Create transaction tags table (many-to-many between transactions and tags)
+CREATE TABLE budget.transaction_tags (
+    transaction_id UUID NOT NULL REFERENCES budget.transactions(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES budget.tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_transaction_tags_tag_id ON budget.transaction_tags(tag_id);
+
+-- Create updated_at trigger
+CREATE TRIGGER update_tags_updated_at
+    BEFORE UPDATE ON budget.tags
+    FOR EACH ROW
+    EXECUTE FUNCTION auth.update_updated_at_column();
+
+COMMENT ON TABLE budget.tags IS 'User-defined labels that cut across the category hierarchy';
+COMMENT ON TABLE budget.transaction_tags IS 'Tags applied to transactions; a transaction can have many tags';
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS budget.idx_transaction_attachments_user_id;
+DROP INDEX IF EXISTS budget.idx_transaction_attachments_transaction_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS budget.transaction_attachments;
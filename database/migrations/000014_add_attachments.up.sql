@@ -0,0 +1,18 @@
+-- Create transaction attachments table (receipts and documents; file contents live in object storage)
+CREATE TABLE budget.transaction_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES budget.transactions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL, -- Original name, used when downloading
+    content_type VARCHAR(100) NOT NULL, -- Detected from the file's contents, not the upload's headers
+    size_bytes BIGINT NOT NULL,
+    storage_key VARCHAR(500) NOT NULL UNIQUE, -- Key in the configured storage (local disk or S3 bucket)
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_attachment_size CHECK (size_bytes > 0)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_transaction_attachments_transaction_id ON budget.transaction_attachments(transaction_id);
+CREATE INDEX idx_transaction_attachments_user_id ON budget.transaction_attachments(user_id);
+
+COMMENT ON TABLE budget.transaction_attachments IS 'Files such as receipts attached to transactions; rows point at objects in storage';
@@ -0,0 +1,2 @@
+-- Cleared response bodies can't be restored
+SELECT 1;
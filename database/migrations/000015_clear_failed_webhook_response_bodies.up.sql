@@ -0,0 +1,6 @@
+-- Webhook deliveries now only keep the body of a 2xx response. Drop bodies
+-- already logged for failed attempts, which may hold internal pages.
+UPDATE budget.webhook_deliveries
+SET response_body = NULL
+WHERE response_body IS NOT NULL
+  AND (response_status IS NULL OR response_status < 200 OR response_status > 299);
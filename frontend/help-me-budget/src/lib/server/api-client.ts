@@ -32,7 +32,10 @@ export async function authenticatedFetch(
 ): Promise<Response> {
 	const headers = new Headers(options.headers);
 	headers.set('X-API-Key', API_SECRET_KEY);
-	headers.set('Content-Type', 'application/json');
+	// FormData bodies need fetch to set the multipart boundary itself
+	if (!(options.body instanceof FormData)) {
+		headers.set('Content-Type', 'application/json');
+	}
 
 	return globalThis.fetch(`${API_URL}${endpoint}`, {
 		...options,
@@ -57,7 +60,9 @@ export async function authenticatedFetchWithUser(
 	const headers = new Headers(options.headers);
 	headers.set('X-API-Key', API_SECRET_KEY);
 	headers.set('X-User-ID', userId);
-	headers.set('Content-Type', 'application/json');
+	if (!(options.body instanceof FormData)) {
+		headers.set('Content-Type', 'application/json');
+	}
 
 	return globalThis.fetch(`${API_URL}${endpoint}`, {
 		...options,
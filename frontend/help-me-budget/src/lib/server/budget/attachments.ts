@@ -0,0 +1,106 @@
+import { authenticatedFetch, authenticatedFetchWithUser } from '../api-client';
+
+// Attachment types matching backend models
+export interface Attachment {
+	id: string;
+	transaction_id: string;
+	user_id: string;
+	file_name: string;
+	content_type: 'image/jpeg' | 'image/png' | 'image/gif' | 'image/webp' | 'application/pdf';
+	size_bytes: number;
+	download_url?: string; // Signed; either a site-relative /api/attachments/... path or a storage URL
+	download_url_expires_at?: string;
+	created_at: string;
+}
+
+export const MAX_ATTACHMENT_SIZE = 10 * 1024 * 1024;
+
+/**
+ * Get a transaction's attachments, each with a download URL valid for 15 minutes
+ */
+export async function getAttachments(userId: string, transactionId: string): Promise<Attachment[]> {
+	const response = await authenticatedFetchWithUser(
+		`/api/transactions/${transactionId}/attachments`,
+		userId
+	);
+
+	if (!response.ok) {
+		if (response.status === 404) {
+			throw new Error('Transaction not found');
+		}
+		throw new Error(`Failed to fetch attachments: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.attachments || [];
+}
+
+/**
+ * Attach a receipt or document to a transaction
+ * @param file - JPEG, PNG, GIF, WebP or PDF up to 10 MB; the type is checked from the contents
+ */
+export async function uploadAttachment(
+	userId: string,
+	transactionId: string,
+	file: Blob,
+	fileName: string
+): Promise<Attachment> {
+	const form = new FormData();
+	form.append('file', file, fileName);
+
+	const response = await authenticatedFetchWithUser(
+		`/api/transactions/${transactionId}/attachments`,
+		userId,
+		{
+			method: 'POST',
+			body: form
+		}
+	);
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to upload attachment';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Delete an attachment and its stored file
+ */
+export async function deleteAttachment(
+	userId: string,
+	transactionId: string,
+	attachmentId: string
+): Promise<void> {
+	const response = await authenticatedFetchWithUser(
+		`/api/transactions/${transactionId}/attachments/${attachmentId}`,
+		userId,
+		{ method: 'DELETE' }
+	);
+
+	if (!response.ok) {
+		if (response.status === 404) {
+			throw new Error('Attachment not found');
+		}
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to delete attachment');
+	}
+}
+
+/**
+ * Fetch an attachment's file through a signed download URL. The signature is
+ * the only credential, so no user is needed.
+ * @param search - The download URL's query string (?expires=...&signature=...)
+ */
+export async function fetchAttachmentDownload(attachmentId: string, search: string): Promise<Response> {
+	return authenticatedFetch(`/api/attachments/${encodeURIComponent(attachmentId)}/download${search}`, {
+		method: 'GET'
+	});
+}
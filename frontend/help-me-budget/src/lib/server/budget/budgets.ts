@@ -58,6 +58,16 @@ export interface DailyProjection {
 	daily_income: number;
 	daily_expenses: number;
 	daily_net: number;
+	occurrences?: ProjectedOccurrence[];
+}
+
+export interface ProjectedOccurrence {
+	entry_id: string;
+	entry_name: string;
+	entry_type: 'income' | 'expense';
+	amount: number;
+	due_date: string; // YYYY-MM-DD
+	status: 'overdue' | 'upcoming';
 }
 
 export interface MonthlyBreakdown {
@@ -72,10 +82,15 @@ export interface CashFlowProjection {
 	start_date: string;
 	end_date: string;
 	starting_balance: number;
+	balance_source: 'accounts' | 'account' | 'manual';
+	account_id?: string;
 	ending_balance: number;
+	lowest_balance: number;
+	lowest_balance_date: string;
 	total_income: number;
 	total_expenses: number;
 	net_cash_flow: number;
+	overdue: ProjectedOccurrence[]; // Unpaid occurrences from earlier this month, applied on the first day
 	daily_projections: DailyProjection[];
 	monthly_breakdown: MonthlyBreakdown[];
 }
@@ -120,6 +135,51 @@ export interface UpdateBudgetEntryRequest {
 	is_active?: boolean;
 }
 
+export interface ScenarioChanges {
+	add?: CreateBudgetEntryRequest[];
+	remove?: string[]; // Entry IDs to leave out
+	change?: { entry_id: string; amount: number }[];
+}
+
+export interface ScenarioRequest extends ScenarioChanges {
+	days?: number; // Projection length, default 90
+	account_id?: string;
+	starting_balance?: number;
+	save_as?: string; // Save the scenario as a named draft
+}
+
+export interface BudgetScenario {
+	id: string;
+	user_id: string;
+	budget_id: string;
+	name: string;
+	changes: ScenarioChanges;
+	created_at: string;
+	updated_at: string;
+}
+
+export interface ScenarioOutcome {
+	summary: BudgetSummary;
+	health: BudgetHealthStatus;
+	projection: CashFlowProjection;
+}
+
+export interface ScenarioComparison {
+	budget_id: string;
+	baseline: ScenarioOutcome;
+	scenario: ScenarioOutcome;
+	difference: {
+		monthly_income: number;
+		monthly_expenses: number;
+		monthly_surplus_deficit: number;
+		annual_surplus_deficit: number;
+		health_score: number;
+		ending_balance: number;
+		lowest_balance: number;
+	};
+	draft?: BudgetScenario;
+}
+
 /**
  * Get all budgets for a user
  */
@@ -247,16 +307,23 @@ export async function getBudgetSummary(
 }
 
 /**
- * Project cash flow for a budget
+ * Project cash flow for a budget. Starts from the user's account balances
+ * (or a single account) unless startingBalance is given.
  */
 export async function projectCashFlow(
 	userId: string,
 	budgetId: string,
-	startingBalance: number = 0,
-	days: number = 90
+	days: number = 90,
+	options: { accountId?: string; startingBalance?: number } = {}
 ): Promise<CashFlowProjection> {
+	const params = new URLSearchParams({ days: days.toString() });
+	if (options.accountId) params.append('account_id', options.accountId);
+	if (options.startingBalance !== undefined) {
+		params.append('starting_balance', options.startingBalance.toString());
+	}
+
 	const response = await authenticatedFetchWithUser(
-		`/api/budgets/${budgetId}/projection?starting_balance=${startingBalance}&days=${days}`,
+		`/api/budgets/${budgetId}/projection?${params.toString()}`,
 		userId
 	);
 
@@ -355,3 +422,85 @@ export async function deleteBudgetEntry(
 		throw new Error(error.error || 'Failed to delete budget entry');
 	}
 }
+
+/**
+ * Compare a budget with a what-if version of it (nothing is changed unless save_as is given,
+ * which only saves a draft)
+ */
+export async function evaluateScenario(
+	userId: string,
+	budgetId: string,
+	scenario: ScenarioRequest
+): Promise<ScenarioComparison> {
+	const response = await authenticatedFetchWithUser(`/api/budgets/${budgetId}/what-if`, userId, {
+		method: 'POST',
+		body: JSON.stringify(scenario)
+	});
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to evaluate scenario');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Get saved scenario drafts for a budget
+ */
+export async function getScenarios(userId: string, budgetId: string): Promise<BudgetScenario[]> {
+	const response = await authenticatedFetchWithUser(`/api/budgets/${budgetId}/scenarios`, userId);
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch scenarios: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Re-evaluate a saved scenario draft against the budget as it is now
+ */
+export async function getScenario(
+	userId: string,
+	budgetId: string,
+	scenarioId: string,
+	days: number = 90
+): Promise<ScenarioComparison> {
+	const response = await authenticatedFetchWithUser(
+		`/api/budgets/${budgetId}/scenarios/${scenarioId}?days=${days}`,
+		userId
+	);
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to fetch scenario');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Delete a saved scenario draft
+ */
+export async function deleteScenario(
+	userId: string,
+	budgetId: string,
+	scenarioId: string
+): Promise<void> {
+	const response = await authenticatedFetchWithUser(
+		`/api/budgets/${budgetId}/scenarios/${scenarioId}`,
+		userId,
+		{
+			method: 'DELETE'
+		}
+	);
+
+	if (!response.ok) {
+		if (response.status === 404) {
+			throw new Error('Scenario not found');
+		}
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to delete scenario');
+	}
+}
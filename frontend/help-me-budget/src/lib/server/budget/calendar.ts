@@ -0,0 +1,89 @@
+import { authenticatedFetch, authenticatedFetchWithUser } from '../api-client';
+
+// Calendar feed types matching backend models
+export interface CalendarFeed {
+	user_id: string;
+	token: string;
+	path: string; // Feed path relative to the site, e.g. /api/calendar/<token>.ics
+	months_ahead: number; // 1-12
+	last_accessed_at?: string; // Last time a calendar app fetched the feed
+	created_at: string;
+	updated_at: string;
+}
+
+/**
+ * Get the user's calendar feed
+ * @returns The feed, or null when it hasn't been turned on
+ */
+export async function getCalendarFeed(userId: string): Promise<CalendarFeed | null> {
+	const response = await authenticatedFetchWithUser('/api/calendar/feed', userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch calendar feed: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.enabled ? data.feed : null;
+}
+
+/**
+ * Turn on the user's calendar feed, or replace its token so old URLs stop working
+ */
+export async function createCalendarFeed(userId: string): Promise<CalendarFeed> {
+	const response = await authenticatedFetchWithUser('/api/calendar/feed', userId, {
+		method: 'POST'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to create calendar feed: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Change how many months ahead the calendar feed covers
+ */
+export async function updateCalendarFeed(userId: string, monthsAhead: number): Promise<CalendarFeed> {
+	const response = await authenticatedFetchWithUser('/api/calendar/feed', userId, {
+		method: 'PUT',
+		body: JSON.stringify({ months_ahead: monthsAhead })
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to update calendar feed';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Turn off the user's calendar feed
+ */
+export async function deleteCalendarFeed(userId: string): Promise<void> {
+	const response = await authenticatedFetchWithUser('/api/calendar/feed', userId, {
+		method: 'DELETE'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to delete calendar feed: ${response.statusText}`);
+	}
+}
+
+/**
+ * Fetch a calendar feed by its token, for calendar apps that have no session
+ */
+export async function fetchCalendarICS(token: string): Promise<Response> {
+	return authenticatedFetch(`/api/calendar/${encodeURIComponent(token)}.ics`, {
+		method: 'GET'
+	});
+}
@@ -0,0 +1,87 @@
+import { authenticatedFetchWithUser } from '../api-client';
+
+export type DataExportFormat = 'json' | 'zip';
+
+export interface DataExport {
+	filename: string;
+	contentType: string;
+	body: ArrayBuffer;
+}
+
+export interface ImportResult {
+	accounts: number;
+	categories: number;
+	budgets: number;
+	budget_entries: number;
+	tags: number;
+	transactions: number;
+	replaced_categories: number; // Unused existing categories replaced by the archive's
+	replaced_category_names: string[];
+	kept_categories: number; // Existing categories kept because alert rules use them
+}
+
+export interface ImportError {
+	error: string;
+	message?: string;
+	problems?: string[]; // Every validation problem found in the archive
+}
+
+/**
+ * Download all of a user's budget data as a portable archive
+ * @param userId - User ID
+ * @param format - 'json' for one document, 'zip' for a ZIP of CSVs
+ */
+export async function exportData(userId: string, format: DataExportFormat = 'json'): Promise<DataExport> {
+	const response = await authenticatedFetchWithUser(`/api/export?format=${format}`, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to export data';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	const disposition = response.headers.get('Content-Disposition') ?? '';
+	const filename = /filename="([^"]+)"/.exec(disposition)?.[1] ?? `help-me-budget-export.${format}`;
+
+	return {
+		filename,
+		contentType: response.headers.get('Content-Type') ?? 'application/octet-stream',
+		body: await response.arrayBuffer()
+	};
+}
+
+/**
+ * Restore an archive into an account that has no accounts, budgets or transactions
+ * @param userId - User ID
+ * @param archive - Contents of a JSON or ZIP export
+ * @returns Counts of the imported records, or the problems that stopped the import
+ */
+export async function importData(
+	userId: string,
+	archive: ArrayBuffer | string
+): Promise<{ ok: true; imported: ImportResult } | { ok: false; error: ImportError }> {
+	const response = await authenticatedFetchWithUser('/api/import', userId, {
+		method: 'POST',
+		body: archive
+	});
+
+	if (!response.ok) {
+		let error: ImportError = { error: 'Failed to import data' };
+		try {
+			error = await response.json();
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		return { ok: false, error };
+	}
+
+	const result = await response.json();
+	return { ok: true, imported: result.imported };
+}
@@ -0,0 +1,138 @@
+import { authenticatedFetchWithUser } from '../api-client';
+import type { CategorySpending, UpcomingBill } from './dashboard';
+
+export type DigestFrequency = 'weekly' | 'monthly';
+
+// Digest types matching backend models
+export interface DigestSettings {
+	user_id: string;
+	enabled: boolean;
+	frequency: DigestFrequency;
+	day_of_week: number; // 0-6 (Sunday-Saturday), weekly digests
+	day_of_month: number; // 1-28, monthly digests
+	send_hour: number; // 0-23 in timezone
+	timezone: string; // IANA name, e.g. 'Africa/Johannesburg'
+	next_send_at: string;
+	last_sent_at?: string;
+	created_at: string;
+	updated_at: string;
+}
+
+export interface UpdateDigestSettingsRequest {
+	enabled?: boolean;
+	frequency?: DigestFrequency;
+	day_of_week?: number;
+	day_of_month?: number;
+	send_hour?: number;
+	timezone?: string;
+}
+
+export interface Digest {
+	name: string;
+	email: string;
+	frequency: DigestFrequency;
+	period: string;
+	currency: string;
+	month_to_date_net: number;
+	month_to_date_income: number;
+	month_to_date_expenses: number;
+	total_balance: number;
+	health_status: string;
+	health_message: string;
+	health_score: number;
+	upcoming_bills: UpcomingBill[];
+	top_categories: CategorySpending[];
+	unmatched_count: number;
+}
+
+export interface RenderedDigest {
+	subject: string;
+	text: string;
+	html: string;
+}
+
+/**
+ * Get the user's digest schedule and whether the server can send email
+ */
+export async function getDigestSettings(
+	userId: string
+): Promise<{ settings: DigestSettings; email_configured: boolean }> {
+	const response = await authenticatedFetchWithUser('/api/digests/settings', userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch digest settings: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Change the digest schedule, or opt out with { enabled: false }
+ */
+export async function updateDigestSettings(
+	userId: string,
+	updates: UpdateDigestSettingsRequest
+): Promise<DigestSettings> {
+	const response = await authenticatedFetchWithUser('/api/digests/settings', userId, {
+		method: 'PUT',
+		body: JSON.stringify(updates)
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to update digest settings';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Render the digest the user would receive now without sending it
+ * @param frequency - Defaults to the user's scheduled frequency
+ */
+export async function previewDigest(
+	userId: string,
+	frequency?: DigestFrequency
+): Promise<{ digest: Digest; rendered: RenderedDigest }> {
+	const url = frequency ? `/api/digests/preview?frequency=${frequency}` : '/api/digests/preview';
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to preview digest: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Email the digest to the user now
+ */
+export async function sendTestDigest(userId: string): Promise<string> {
+	const response = await authenticatedFetchWithUser('/api/digests/test', userId, {
+		method: 'POST'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to send digest';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	const result = await response.json();
+	return result.message;
+}
@@ -0,0 +1,42 @@
+import { authenticatedFetchWithUser } from '../api-client';
+
+export type BudgetEventType =
+	| 'connected' // Sent first on every (re)connect; re-fetch, since missed events aren't replayed
+	| 'transaction.created'
+	| 'transaction.updated'
+	| 'transaction.deleted'
+	| 'transaction.matched'
+	| 'transaction.unmatched'
+	| 'transactions.bulk_updated' // Bulk tagging; data is { transaction_ids }
+	| 'account.created'
+	| 'account.updated'
+	| 'account.deleted'
+	| 'matching.batch_completed'
+	| 'matching.batch_undone'
+	| 'data.imported';
+
+// Event sent on the stream, matching the backend model
+export interface BudgetEvent {
+	type: BudgetEventType;
+	data: unknown; // The changed record, or { id } for deletions
+	created_at: string;
+}
+
+/**
+ * Open the user's server-sent event stream
+ * @param signal - Aborting closes the stream on the API
+ * @returns The streaming response, to be piped to the browser
+ */
+export async function openEventStream(userId: string, signal?: AbortSignal): Promise<Response> {
+	const response = await authenticatedFetchWithUser('/api/events/stream', userId, {
+		method: 'GET',
+		headers: { Accept: 'text/event-stream' },
+		signal
+	});
+
+	if (!response.ok || !response.body) {
+		throw new Error(`Failed to open event stream: ${response.statusText}`);
+	}
+
+	return response;
+}
@@ -54,9 +54,23 @@ export interface AutoMatchResponse {
 
 export interface BulkAutoMatchResponse {
 	matched_count: number;
+	failed_count: number;
+	batch_id: string | null;
 	message: string;
 }
 
+export interface Job<T = unknown> {
+	id: string;
+	job_type: string;
+	status: 'queued' | 'running' | 'succeeded' | 'failed';
+	result?: T;
+	error?: string;
+	progress: number;
+	progress_message?: string;
+	attempts: number;
+	max_attempts: number;
+}
+
 export interface TeachMatchRequest {
 	budget_entry_id: string;
 	create_rules: boolean;
@@ -85,6 +99,67 @@ export interface MatchingRules {
 	amount_tolerance?: number;
 }
 
+export interface RecurringCandidate {
+	id: string;
+	merchant: string;
+	entry_type: 'income' | 'expense';
+	frequency: 'weekly' | 'fortnightly' | 'monthly' | 'annually';
+	typical_amount: number;
+	min_amount: number;
+	max_amount: number;
+	occurrences: number;
+	first_date: string;
+	last_date: string;
+	next_expected_date: string;
+	confidence: number; // 0-100
+	category_id?: string;
+	existing_entry_id?: string; // Set when a budget entry already covers this series
+	transaction_ids: string[];
+	suggested_entry: {
+		category_id?: string;
+		name: string;
+		amount: number;
+		entry_type: 'income' | 'expense';
+		frequency: string;
+		day_of_month?: number;
+		day_of_week?: number;
+		start_date: string;
+		matching_rules: Record<string, unknown>;
+	};
+}
+
+export interface CreateEntryFromCandidateRequest {
+	budget_id?: string; // Defaults to the active budget
+	name?: string;
+	amount?: number;
+	category_id?: string;
+	start_date?: string;
+}
+
+export interface AmountHistoryPoint {
+	transaction_id: string;
+	transaction_date: string;
+	amount: number;
+	difference: number; // Amount minus the entry's budgeted amount
+}
+
+export interface PriceChange {
+	entry_id: string;
+	budget_id: string;
+	entry_name: string;
+	entry_type: 'income' | 'expense';
+	frequency: string;
+	current_amount: number;
+	suggested_amount: number;
+	change: number;
+	change_pct: number;
+	direction: 'increase' | 'decrease';
+	since: string; // Date of the first charge at the new amount
+	consecutive_charges: number;
+	tolerance: number;
+	history: AmountHistoryPoint[];
+}
+
 // ============================================================================
 // API Functions
 // ============================================================================
@@ -136,9 +211,27 @@ export async function autoMatchTransaction(
 }
 
 /**
- * Auto-match all unmatched transactions
+ * Get the status of a background job
+ */
+export async function getJob<T = unknown>(userId: string, jobId: string): Promise<Job<T>> {
+	const response = await authenticatedFetchWithUser(`/api/jobs/${jobId}`, userId);
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to get job status');
+	}
+
+	return response.json();
+}
+
+/**
+ * Auto-match all unmatched transactions. The API runs the match as a
+ * background job, so this polls until the job finishes or times out.
  */
-export async function bulkAutoMatch(userId: string): Promise<BulkAutoMatchResponse> {
+export async function bulkAutoMatch(
+	userId: string,
+	timeoutMs = 60000
+): Promise<BulkAutoMatchResponse> {
 	const response = await authenticatedFetchWithUser(`/api/matching/bulk-auto-match`, userId, {
 		method: 'POST'
 	});
@@ -148,7 +241,23 @@ export async function bulkAutoMatch(userId: string): Promise<BulkAutoMatchRespon
 		throw new Error(error.error || 'Failed to bulk auto-match transactions');
 	}
 
-	return response.json();
+	const { job_id } = await response.json();
+	const deadline = Date.now() + timeoutMs;
+
+	while (Date.now() < deadline) {
+		const job = await getJob<Omit<BulkAutoMatchResponse, 'message'>>(userId, job_id);
+
+		if (job.status === 'succeeded' && job.result) {
+			return { ...job.result, message: 'Auto-match completed' };
+		}
+		if (job.status === 'failed') {
+			throw new Error(job.error || 'Failed to bulk auto-match transactions');
+		}
+
+		await new Promise((resolve) => setTimeout(resolve, 1000));
+	}
+
+	throw new Error('Auto-match is still running; check back shortly');
 }
 
 /**
@@ -199,3 +308,86 @@ export async function updateBudgetEntryMatchingRules(
 		throw new Error(error.error || 'Failed to update matching rules');
 	}
 }
+
+/**
+ * Detect repeating charges that don't have a budget entry yet
+ */
+export async function getRecurringCandidates(
+	userId: string,
+	includeCovered = false
+): Promise<RecurringCandidate[]> {
+	const params = includeCovered ? '?include_covered=true' : '';
+	const response = await authenticatedFetchWithUser(
+		`/api/matching/recurring-candidates${params}`,
+		userId
+	);
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to detect recurring charges');
+	}
+
+	const data = await response.json();
+	return data.candidates;
+}
+
+/**
+ * Create a budget entry with pre-filled matching rules from a recurring candidate
+ */
+export async function createEntryFromCandidate(
+	userId: string,
+	candidateId: string,
+	overrides: CreateEntryFromCandidateRequest = {}
+) {
+	const response = await authenticatedFetchWithUser(
+		`/api/matching/recurring-candidates/${candidateId}/create-entry`,
+		userId,
+		{
+			method: 'POST',
+			body: JSON.stringify(overrides)
+		}
+	);
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to create budget entry');
+	}
+
+	return response.json();
+}
+
+/**
+ * Get budget entries whose linked charges have settled at a new amount
+ */
+export async function getPriceChanges(userId: string): Promise<PriceChange[]> {
+	const response = await authenticatedFetchWithUser(`/api/matching/price-changes`, userId);
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to get price changes');
+	}
+
+	const data = await response.json();
+	return data.price_changes;
+}
+
+/**
+ * Update a budget entry's amount to its new price (defaults to the detected amount)
+ */
+export async function applyPriceChange(userId: string, entryId: string, amount?: number) {
+	const response = await authenticatedFetchWithUser(
+		`/api/matching/price-changes/${entryId}/apply`,
+		userId,
+		{
+			method: 'POST',
+			body: JSON.stringify(amount !== undefined ? { amount } : {})
+		}
+	);
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to apply price change');
+	}
+
+	return response.json();
+}
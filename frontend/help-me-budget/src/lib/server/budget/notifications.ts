@@ -0,0 +1,244 @@
+import { authenticatedFetchWithUser } from '../api-client';
+
+export type AlertRuleType =
+	| 'category_spend'
+	| 'entry_spend'
+	| 'bill_due'
+	| 'bill_overdue'
+	| 'low_balance'
+	| 'large_transaction';
+
+// Alert and notification types matching backend models
+export interface AlertRule {
+	id: string;
+	user_id: string;
+	name: string;
+	rule_type: AlertRuleType;
+	category_id?: string; // category_spend
+	budget_entry_id?: string; // entry_spend; limits bill_due/bill_overdue to one entry
+	account_id?: string; // Limits low_balance/large_transaction to one account
+	threshold?: number; // Warning percent for spend rules (100% always alerts too), amount for low_balance/large_transaction
+	days_ahead?: number; // bill_due: days before the due date; low_balance: projection horizon
+	is_active: boolean;
+	created_at: string;
+	updated_at: string;
+}
+
+export interface CreateAlertRuleRequest {
+	name: string;
+	rule_type: AlertRuleType;
+	category_id?: string;
+	budget_entry_id?: string;
+	account_id?: string;
+	threshold?: number;
+	days_ahead?: number;
+}
+
+export interface UpdateAlertRuleRequest {
+	name?: string;
+	category_id?: string;
+	budget_entry_id?: string;
+	account_id?: string;
+	threshold?: number;
+	days_ahead?: number;
+	is_active?: boolean;
+}
+
+export interface Notification {
+	id: string;
+	user_id: string;
+	alert_rule_id?: string;
+	notification_type:
+		| 'budget_warning'
+		| 'budget_exceeded'
+		| 'bill_due'
+		| 'bill_overdue'
+		| 'low_balance'
+		| 'large_transaction';
+	severity: 'info' | 'warning' | 'critical';
+	title: string;
+	message: string;
+	data: Record<string, unknown>;
+	is_read: boolean;
+	read_at?: string;
+	created_at: string;
+}
+
+/**
+ * Get the user's notifications, newest first, with the unread count
+ */
+export async function getNotifications(
+	userId: string,
+	options: { unread?: boolean; limit?: number } = {}
+): Promise<{ notifications: Notification[]; unread_count: number }> {
+	const params = new URLSearchParams();
+	if (options.unread) params.append('unread', 'true');
+	if (options.limit) params.append('limit', options.limit.toString());
+
+	const url = `/api/notifications${params.toString() ? `?${params.toString()}` : ''}`;
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch notifications: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Count the user's unread notifications
+ */
+export async function getUnreadNotificationCount(userId: string): Promise<number> {
+	const response = await authenticatedFetchWithUser('/api/notifications/unread-count', userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch unread notifications: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.unread_count;
+}
+
+/**
+ * Mark a notification read or unread
+ */
+export async function setNotificationRead(
+	userId: string,
+	notificationId: string,
+	read: boolean = true
+): Promise<Notification> {
+	const action = read ? 'read' : 'unread';
+	const response = await authenticatedFetchWithUser(
+		`/api/notifications/${notificationId}/${action}`,
+		userId,
+		{
+			method: 'POST'
+		}
+	);
+
+	if (!response.ok) {
+		throw new Error(`Failed to update notification: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Mark all of the user's notifications read
+ * @returns How many notifications changed
+ */
+export async function markAllNotificationsRead(userId: string): Promise<number> {
+	const response = await authenticatedFetchWithUser('/api/notifications/read-all', userId, {
+		method: 'POST'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to update notifications: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.updated;
+}
+
+/**
+ * Get all of the user's alert rules
+ */
+export async function getAlertRules(userId: string): Promise<AlertRule[]> {
+	const response = await authenticatedFetchWithUser('/api/alert-rules', userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch alert rules: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.rules || [];
+}
+
+/**
+ * Create an alert rule
+ */
+export async function createAlertRule(
+	userId: string,
+	rule: CreateAlertRuleRequest
+): Promise<AlertRule> {
+	const response = await authenticatedFetchWithUser('/api/alert-rules', userId, {
+		method: 'POST',
+		body: JSON.stringify(rule)
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to create alert rule';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Update an alert rule (set is_active to false to pause it)
+ */
+export async function updateAlertRule(
+	userId: string,
+	ruleId: string,
+	updates: UpdateAlertRuleRequest
+): Promise<AlertRule> {
+	const response = await authenticatedFetchWithUser(`/api/alert-rules/${ruleId}`, userId, {
+		method: 'PUT',
+		body: JSON.stringify(updates)
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to update alert rule';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Delete an alert rule. Notifications it raised are kept.
+ */
+export async function deleteAlertRule(userId: string, ruleId: string): Promise<void> {
+	const response = await authenticatedFetchWithUser(`/api/alert-rules/${ruleId}`, userId, {
+		method: 'DELETE'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to delete alert rule: ${response.statusText}`);
+	}
+}
+
+/**
+ * Evaluate the user's alert rules now
+ * @returns Notifications raised by this evaluation
+ */
+export async function evaluateAlerts(userId: string): Promise<Notification[]> {
+	const response = await authenticatedFetchWithUser('/api/alert-rules/evaluate', userId, {
+		method: 'POST'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to evaluate alerts: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.notifications || [];
+}
@@ -0,0 +1,115 @@
+import { authenticatedFetchWithUser } from '../api-client';
+import type { CreateTransactionRequest } from './transactions';
+
+// Receipt extraction types matching backend models. Every parsed field has a
+// confidence from 0 to 1 and the (1-based) line of text it was read from.
+export interface ReceiptTextField {
+	value: string;
+	confidence: number;
+	line: number;
+}
+
+export interface ReceiptAmountField {
+	value: number;
+	confidence: number;
+	line: number;
+}
+
+export interface ReceiptLineItem {
+	description: string;
+	quantity?: number;
+	amount: number; // Discounts printed under the item are already taken off
+	confidence: number;
+	line: number;
+}
+
+export interface Receipt {
+	merchant?: ReceiptTextField;
+	date?: ReceiptTextField; // YYYY-MM-DD
+	total?: ReceiptAmountField;
+	subtotal?: ReceiptAmountField;
+	tax?: ReceiptAmountField;
+	line_items: ReceiptLineItem[];
+	confidence: number;
+	text: string; // Raw OCR output
+}
+
+export interface ProposedSplit {
+	transaction: CreateTransactionRequest; // Amount includes the item's share of tax
+	confidence: number;
+}
+
+export interface ReceiptProposal {
+	// account_id is the nil UUID when no account was given and the user has several
+	transaction: CreateTransactionRequest;
+	splits: ProposedSplit[]; // Empty unless the line items add up to the total
+	receipt: Receipt;
+	confidence: number;
+	warnings: string[];
+}
+
+const NIL_UUID = '00000000-0000-0000-0000-000000000000';
+
+/**
+ * Whether a proposal still needs an account chosen before it can be saved
+ */
+export function needsAccount(proposal: ReceiptProposal): boolean {
+	return !proposal.transaction.account_id || proposal.transaction.account_id === NIL_UUID;
+}
+
+async function readProposal(response: Response): Promise<ReceiptProposal> {
+	if (!response.ok) {
+		let errorMessage = 'Failed to extract receipt';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Read a receipt and propose a transaction from it. Nothing is saved; create
+ * the transaction (or its splits) once the user has confirmed the fields.
+ * @param file - JPEG, PNG, GIF, WebP or PDF up to 10 MB
+ * @param accountId - Account to propose; defaults to the user's only active account
+ */
+export async function extractReceipt(
+	userId: string,
+	file: Blob,
+	fileName: string,
+	accountId?: string
+): Promise<ReceiptProposal> {
+	const form = new FormData();
+	form.append('file', file, fileName);
+	if (accountId) form.append('account_id', accountId);
+
+	const response = await authenticatedFetchWithUser('/api/receipts/extract', userId, {
+		method: 'POST',
+		body: form
+	});
+
+	return readProposal(response);
+}
+
+/**
+ * Propose a transaction from a receipt already attached to a transaction,
+ * using that transaction's account
+ */
+export async function extractAttachmentReceipt(
+	userId: string,
+	transactionId: string,
+	attachmentId: string
+): Promise<ReceiptProposal> {
+	const response = await authenticatedFetchWithUser(
+		`/api/transactions/${transactionId}/attachments/${attachmentId}/extract`,
+		userId,
+		{ method: 'POST' }
+	);
+
+	return readProposal(response);
+}
@@ -8,17 +8,54 @@ export interface SpendingTrend {
 	month: string; // YYYY-MM format
 	category_id: string;
 	category: string;
+	parent_category_id?: string;
+	depth: number; // 0 = top-level category
 	amount: number;
 }
 
 export interface BudgetVariance {
 	entry_id: string;
 	entry_name: string;
+	entry_type: 'income' | 'expense';
+	frequency: string;
 	category: string;
+	occurrences: number; // Times the entry falls due in the period
 	budgeted: number;
 	actual: number;
 	variance: number; // positive = under budget, negative = over budget
 	variance_pct: number;
+	ytd_budgeted: number;
+	ytd_actual: number;
+	ytd_variance: number;
+}
+
+export type ReportPeriodType = 'week' | 'month' | 'quarter' | 'year' | 'custom';
+
+export interface ReportPeriod {
+	type: ReportPeriodType;
+	start_date: string; // YYYY-MM-DD
+	end_date: string; // YYYY-MM-DD
+}
+
+export interface BudgetVarianceReport {
+	period: ReportPeriod;
+	ytd_start_date: string;
+	ytd_end_date: string;
+	entries: BudgetVariance[];
+	budgeted: number;
+	actual: number;
+	variance: number;
+	ytd_budgeted: number;
+	ytd_actual: number;
+	ytd_variance: number;
+}
+
+export interface BudgetVarianceOptions {
+	period?: ReportPeriodType;
+	date?: string; // YYYY-MM-DD, any day inside the period
+	month?: string; // YYYY-MM, shorthand for period=month
+	startDate?: string; // YYYY-MM-DD, custom periods only
+	endDate?: string; // YYYY-MM-DD, custom periods only
 }
 
 export interface DailyCashFlowProjection {
@@ -28,14 +65,278 @@ export interface DailyCashFlowProjection {
 	projected_balance: number;
 }
 
+export interface ProjectedOccurrence {
+	entry_id: string;
+	entry_name: string;
+	entry_type: 'income' | 'expense';
+	amount: number;
+	due_date: string; // YYYY-MM-DD
+	status: 'overdue' | 'upcoming';
+}
+
+export interface CashFlowProjectionReport {
+	starting_balance: number;
+	balance_source: 'accounts' | 'account' | 'manual';
+	account_id?: string;
+	lowest_balance: number;
+	lowest_balance_date: string; // YYYY-MM-DD
+	overdue: ProjectedOccurrence[]; // Unpaid occurrences from earlier this month, applied on the first day
+	projections: DailyCashFlowProjection[];
+}
+
+export interface CashFlowProjectionOptions {
+	days?: number; // Default 90, max 365
+	accountId?: string; // Project a single account instead of all active accounts
+	startingBalance?: number; // Overrides the account balance
+}
+
 export interface TopExpense {
 	category_id: string;
 	category_name: string;
+	parent_category_id?: string;
+	depth: number; // 0 = top-level category
 	total_amount: number;
 	percentage: number; // Percentage of total expenses
 	count: number; // Number of transactions
 }
 
+export interface IncomeExpenseAverage {
+	months: number;
+	income: number;
+	expenses: number;
+	net: number;
+	savings_rate: number;
+	complete: boolean; // False when fewer than `months` months of history exist
+}
+
+export interface IncomeExpenseMonth {
+	month: string; // YYYY-MM format
+	income: number;
+	expenses: number;
+	net: number;
+	savings_rate: number; // Percentage of income kept
+	rolling: IncomeExpenseAverage[]; // 3, 6 and 12 month averages
+}
+
+export interface IncomeCategoryTotal {
+	category_id: string;
+	category: string;
+	amount: number;
+	percentage: number; // Percentage of total income
+	count: number;
+}
+
+export interface IncomeExpenseReport {
+	start_month: string;
+	end_month: string;
+	months: IncomeExpenseMonth[];
+	total_income: number;
+	total_expenses: number;
+	total_net: number;
+	savings_rate: number;
+	savings_trend: 'improving' | 'declining' | 'stable' | 'insufficient_data';
+	income_by_category: IncomeCategoryTotal[];
+}
+
+export interface ContributingTransaction {
+	transaction_id: string;
+	range: 'base' | 'compare';
+	transaction_date: string;
+	description?: string;
+	amount: number;
+	merchant: string;
+	merchant_delta: number;
+}
+
+export interface CategoryComparison {
+	category_id: string;
+	category: string;
+	parent_category_id?: string;
+	base_total: number;
+	compare_total: number;
+	delta: number; // compare - base
+	delta_pct: number | null; // null when the base total is zero
+	contributors: ContributingTransaction[];
+}
+
+export interface ComparisonRange {
+	start_date: string;
+	end_date: string;
+	total: number;
+}
+
+export interface ComparisonReport {
+	transaction_type: 'expense' | 'income';
+	base: ComparisonRange;
+	compare: ComparisonRange;
+	delta: number;
+	delta_pct: number | null;
+	categories: CategoryComparison[];
+	parent_categories: CategoryComparison[];
+}
+
+export type ComparisonOptions = {
+	type?: 'expense' | 'income';
+	contributors?: number;
+} & (
+	| { baseStartDate: string; baseEndDate: string; compareStartDate: string; compareEndDate: string }
+	| {
+			period: ReportPeriodType;
+			date?: string;
+			compareTo?: 'previous_period' | 'previous_year';
+			startDate?: string;
+			endDate?: string;
+	  }
+);
+
+export interface CategoryRollup {
+	category_id: string | null; // null for uncategorized
+	category_name: string;
+	parent_category_id?: string;
+	depth: number;
+	color?: string;
+	total_amount: number; // Including all subcategories
+	direct_amount: number; // Categorized directly on this category
+	count: number;
+	percentage: number;
+	children: CategoryRollup[];
+}
+
+export interface CategoryRollupOptions {
+	depth?: number; // Roll up to ancestors at this depth (0 = top-level)
+	parentId?: string; // Only include this category's subtree
+}
+
+export interface CategoryDrillDown {
+	category: { id: string; name: string; category_type: string; parent_category_id?: string };
+	path: { category_id: string; category_name: string }[];
+	start_date: string;
+	end_date: string;
+	total_amount: number;
+	direct_amount: number;
+	children: CategoryRollup[];
+	transactions: {
+		id: string;
+		category_id: string | null;
+		amount: number;
+		transaction_type: 'income' | 'expense';
+		description: string | null;
+		transaction_date: string;
+	}[];
+}
+
+export interface TagSpending {
+	tag_id: string;
+	tag_name: string;
+	color?: string;
+	total_amount: number;
+	percentage: number; // Of all transactions of the type; tags overlap, so these can add up past 100
+	count: number;
+	categories: {
+		category_id?: string; // Absent for uncategorized
+		category_name: string;
+		total_amount: number;
+		count: number;
+	}[];
+}
+
+export interface TagSpendingReport {
+	start_date: string;
+	end_date: string;
+	transaction_type: 'expense' | 'income';
+	total_amount: number; // All transactions of the type, tagged or not
+	untagged_amount: number;
+	tags: TagSpending[];
+}
+
+export interface Anomaly {
+	type: 'transaction' | 'category_month';
+	scope: 'merchant' | 'category';
+	transaction_id?: string;
+	date?: string; // YYYY-MM-DD for transactions
+	month?: string; // YYYY-MM for category months
+	category_id: string;
+	category: string;
+	merchant?: string;
+	description?: string;
+	amount: number;
+	expected: number;
+	expected_low: number;
+	expected_high: number;
+	score: number; // Robust z-score; positive = above usual
+	severity: 'medium' | 'high';
+	reason: string;
+}
+
+export interface AnomalyReport {
+	start_date: string;
+	end_date: string;
+	baseline_start_date: string;
+	threshold: number;
+	anomalies: Anomaly[];
+}
+
+export interface ForecastBand {
+	p10: number;
+	p50: number;
+	p90: number;
+}
+
+export interface ForecastDay extends ForecastBand {
+	date: string; // YYYY-MM-DD
+	probability_below: number; // Share of simulations below the threshold on this day (0-1)
+}
+
+export interface ForecastCategory {
+	category_id: string;
+	category_name: string;
+	average_daily: number;
+	expected_total: number;
+}
+
+export interface ForecastEntryTiming {
+	entry_id: string;
+	entry_name: string;
+	samples: number;
+	earliest_days: number; // Negative = paid before the due date
+	latest_days: number;
+	uses_default: boolean;
+}
+
+export interface CashFlowForecast {
+	start_date: string;
+	end_date: string;
+	starting_balance: number;
+	balance_source: 'accounts' | 'account' | 'manual';
+	account_id?: string;
+	history_start_date: string;
+	history_end_date: string;
+	simulations: number;
+	seed: number;
+	threshold: number;
+	probability_below_threshold: number; // Share of simulations that dip below the threshold (0-1)
+	lowest_balance: ForecastBand;
+	ending_balance: ForecastBand;
+	days: ForecastDay[];
+	categories: ForecastCategory[];
+	entries: ForecastEntryTiming[];
+}
+
+export interface CashFlowForecastOptions extends CashFlowProjectionOptions {
+	threshold?: number; // Default 0
+	simulations?: number; // Default 1000, max 5000
+	lookbackMonths?: number; // Default 6
+	seed?: number; // Pass the returned seed to reproduce a forecast
+}
+
+export type ExportFormat = 'csv' | 'xlsx' | 'pdf';
+
+export interface ReportExport {
+	filename: string;
+	contentType: string;
+	body: ArrayBuffer;
+}
+
 // ============================================================================
 // API Functions
 // ============================================================================
@@ -77,16 +378,20 @@ export async function getSpendingTrends(
 }
 
 /**
- * Get budget vs actual comparison for a month
+ * Get budget vs actual comparison for a period, with year-to-date totals
  * @param userId - User ID
- * @param month - Month in YYYY-MM format, defaults to current month
+ * @param options - Period to report on, defaults to the current month
  */
 export async function getBudgetVariance(
 	userId: string,
-	month?: string
-): Promise<BudgetVariance[]> {
+	options: BudgetVarianceOptions = {}
+): Promise<BudgetVarianceReport> {
 	const params = new URLSearchParams();
-	if (month) params.append('month', month);
+	if (options.period) params.append('period', options.period);
+	if (options.date) params.append('date', options.date);
+	if (options.month) params.append('month', options.month);
+	if (options.startDate) params.append('start_date', options.startDate);
+	if (options.endDate) params.append('end_date', options.endDate);
 
 	const queryString = params.toString();
 	const url = `/api/reports/budget-variance${queryString ? '?' + queryString : ''}`;
@@ -110,19 +415,20 @@ export async function getBudgetVariance(
 }
 
 /**
- * Get projected cash flow based on budget entries
+ * Get projected cash flow based on budget entries, starting from actual account balances
  * @param userId - User ID
- * @param days - Number of days to project (default 90)
- * @param startingBalance - Starting balance (default 0)
+ * @param options - Days to project, optional account and starting balance override
  */
 export async function getCashFlowProjection(
 	userId: string,
-	days: number = 90,
-	startingBalance: number = 0
-): Promise<DailyCashFlowProjection[]> {
+	options: CashFlowProjectionOptions = {}
+): Promise<CashFlowProjectionReport> {
 	const params = new URLSearchParams();
-	params.append('days', days.toString());
-	params.append('starting_balance', startingBalance.toString());
+	params.append('days', (options.days ?? 90).toString());
+	if (options.accountId) params.append('account_id', options.accountId);
+	if (options.startingBalance !== undefined) {
+		params.append('starting_balance', options.startingBalance.toString());
+	}
 
 	const url = `/api/reports/cash-flow-projection?${params.toString()}`;
 
@@ -181,3 +487,278 @@ export async function getTopExpenses(
 
 	return response.json();
 }
+
+/**
+ * Get monthly income vs expenses with savings rate and rolling averages
+ * @param userId - User ID
+ * @param startMonth - Start month (YYYY-MM), defaults to 11 months before endMonth
+ * @param endMonth - End month (YYYY-MM), defaults to current month
+ */
+export async function getIncomeExpenseReport(
+	userId: string,
+	startMonth?: string,
+	endMonth?: string
+): Promise<IncomeExpenseReport> {
+	const params = new URLSearchParams();
+	if (startMonth) params.append('start_month', startMonth);
+	if (endMonth) params.append('end_month', endMonth);
+
+	const queryString = params.toString();
+	const url = `/api/reports/income-expense${queryString ? '?' + queryString : ''}`;
+
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to get income vs expense report';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return response.json();
+}
+
+/**
+ * Compare category totals between two date ranges
+ * @param userId - User ID
+ * @param options - Explicit base/compare ranges, or a period compared with the previous period or year
+ */
+export async function getComparisonReport(
+	userId: string,
+	options: ComparisonOptions
+): Promise<ComparisonReport> {
+	const params = new URLSearchParams();
+	if (options.type) params.append('type', options.type);
+	if (options.contributors) params.append('contributors', options.contributors.toString());
+	if ('period' in options) {
+		params.append('period', options.period);
+		if (options.date) params.append('date', options.date);
+		if (options.compareTo) params.append('compare_to', options.compareTo);
+		if (options.startDate) params.append('start_date', options.startDate);
+		if (options.endDate) params.append('end_date', options.endDate);
+	} else {
+		params.append('base_start_date', options.baseStartDate);
+		params.append('base_end_date', options.baseEndDate);
+		params.append('compare_start_date', options.compareStartDate);
+		params.append('compare_end_date', options.compareEndDate);
+	}
+
+	const url = `/api/reports/comparison?${params.toString()}`;
+
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to get comparison report';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return response.json();
+}
+
+/**
+ * Drill into a category: its subcategory subtotals and transactions
+ * @param userId - User ID
+ * @param categoryId - Category to drill into
+ * @param startDate - Start date (YYYY-MM-DD), defaults to start of current month
+ * @param endDate - End date (YYYY-MM-DD), defaults to today
+ */
+export async function getCategoryDrillDown(
+	userId: string,
+	categoryId: string,
+	startDate?: string,
+	endDate?: string
+): Promise<CategoryDrillDown> {
+	const params = new URLSearchParams();
+	if (startDate) params.append('start_date', startDate);
+	if (endDate) params.append('end_date', endDate);
+
+	const queryString = params.toString();
+	const url = `/api/reports/categories/${categoryId}/drill-down${queryString ? '?' + queryString : ''}`;
+
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to get category drill-down';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return response.json();
+}
+
+/**
+ * Get spending per tag, broken down by category
+ * @param userId - User ID
+ * @param startDate - Start date (YYYY-MM-DD), defaults to start of current month
+ * @param endDate - End date (YYYY-MM-DD), defaults to today
+ * @param type - Report expenses (default) or income
+ */
+export async function getTagSpending(
+	userId: string,
+	startDate?: string,
+	endDate?: string,
+	type: 'expense' | 'income' = 'expense'
+): Promise<TagSpendingReport> {
+	const params = new URLSearchParams();
+	if (startDate) params.append('start_date', startDate);
+	if (endDate) params.append('end_date', endDate);
+	params.append('type', type);
+
+	const url = `/api/reports/tags?${params.toString()}`;
+
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to get tag spending';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return response.json();
+}
+
+/**
+ * Get unusual transactions and category months
+ * @param userId - User ID
+ * @param startDate - Start of the range to check (YYYY-MM-DD), defaults to 90 days ago
+ * @param endDate - End of the range to check (YYYY-MM-DD), defaults to today
+ */
+export async function getAnomalies(
+	userId: string,
+	startDate?: string,
+	endDate?: string
+): Promise<AnomalyReport> {
+	const params = new URLSearchParams();
+	if (startDate) params.append('start_date', startDate);
+	if (endDate) params.append('end_date', endDate);
+
+	const queryString = params.toString();
+	const url = `/api/reports/anomalies${queryString ? '?' + queryString : ''}`;
+
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to get anomalies';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return response.json();
+}
+
+/**
+ * Simulate the coming days to get a range of likely balances
+ * @param userId - User ID
+ * @param options - Projection options plus threshold, simulation count and history length
+ */
+export async function getCashFlowForecast(
+	userId: string,
+	options: CashFlowForecastOptions = {}
+): Promise<CashFlowForecast> {
+	const params = new URLSearchParams();
+	if (options.days) params.append('days', options.days.toString());
+	if (options.accountId) params.append('account_id', options.accountId);
+	if (options.startingBalance !== undefined) {
+		params.append('starting_balance', options.startingBalance.toString());
+	}
+	if (options.threshold !== undefined) params.append('threshold', options.threshold.toString());
+	if (options.simulations) params.append('simulations', options.simulations.toString());
+	if (options.lookbackMonths) params.append('lookback_months', options.lookbackMonths.toString());
+	if (options.seed) params.append('seed', options.seed.toString());
+
+	const queryString = params.toString();
+	const url = `/api/reports/cash-flow-forecast${queryString ? '?' + queryString : ''}`;
+
+	const response = await authenticatedFetchWithUser(url, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to get cash flow forecast';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	return response.json();
+}
+
+/**
+ * Download a report or the transaction list as CSV, XLSX or PDF
+ * @param userId - User ID
+ * @param path - Report endpoint, e.g. '/api/reports/budget-variance' or '/api/transactions'
+ * @param format - File format
+ * @param params - The endpoint's usual query parameters
+ */
+export async function exportReport(
+	userId: string,
+	path: string,
+	format: ExportFormat,
+	params: Record<string, string> = {}
+): Promise<ReportExport> {
+	const query = new URLSearchParams(params);
+	query.set('format', format);
+
+	const response = await authenticatedFetchWithUser(`${path}?${query.toString()}`, userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		let errorMessage = 'Failed to export report';
+		try {
+			const error = await response.json();
+			errorMessage = error.error || errorMessage;
+		} catch {
+			// Response wasn't JSON, use default message
+		}
+		throw new Error(errorMessage);
+	}
+
+	const disposition = response.headers.get('Content-Disposition') ?? '';
+	const filename = /filename="([^"]+)"/.exec(disposition)?.[1] ?? `report.${format}`;
+
+	return {
+		filename,
+		contentType: response.headers.get('Content-Type') ?? 'application/octet-stream',
+		body: await response.arrayBuffer()
+	};
+}
@@ -0,0 +1,172 @@
+import { authenticatedFetchWithUser } from '../api-client';
+
+// Tag names are lowercased with spaces turned into hyphens by the API
+export interface Tag {
+	id: string;
+	user_id: string;
+	name: string;
+	color?: string | null;
+	transaction_count?: number; // Only included by getTags
+	created_at: string;
+	updated_at: string;
+}
+
+export interface CreateTagRequest {
+	name: string;
+	color?: string; // #RRGGBB
+}
+
+export interface UpdateTagRequest {
+	name?: string;
+	color?: string;
+}
+
+export interface BulkTagResult {
+	updated_count: number; // Transactions found
+	changed_count: number; // Tags actually added or removed
+	not_found: string[]; // Transaction IDs that don't exist or aren't the user's
+	tags: string[]; // Normalized tag names
+}
+
+/**
+ * Get all tags for a user, with how many transactions use each
+ */
+export async function getTags(userId: string): Promise<Tag[]> {
+	const response = await authenticatedFetchWithUser('/api/tags', userId);
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch tags: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.tags || [];
+}
+
+/**
+ * Create a new tag
+ */
+export async function createTag(userId: string, tag: CreateTagRequest): Promise<Tag> {
+	const response = await authenticatedFetchWithUser('/api/tags', userId, {
+		method: 'POST',
+		body: JSON.stringify(tag)
+	});
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to create tag');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Rename or recolor a tag
+ */
+export async function updateTag(
+	userId: string,
+	tagId: string,
+	updates: UpdateTagRequest
+): Promise<Tag> {
+	const response = await authenticatedFetchWithUser(`/api/tags/${tagId}`, userId, {
+		method: 'PUT',
+		body: JSON.stringify(updates)
+	});
+
+	if (!response.ok) {
+		if (response.status === 404) {
+			throw new Error('Tag not found');
+		}
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to update tag');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Delete a tag, removing it from all transactions
+ */
+export async function deleteTag(userId: string, tagId: string): Promise<void> {
+	const response = await authenticatedFetchWithUser(`/api/tags/${tagId}`, userId, {
+		method: 'DELETE'
+	});
+
+	if (!response.ok) {
+		if (response.status === 404) {
+			throw new Error('Tag not found');
+		}
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to delete tag');
+	}
+}
+
+/**
+ * Replace a transaction's tags
+ * @param tags - Tag names; missing tags are created, an empty list removes all tags
+ */
+export async function setTransactionTags(
+	userId: string,
+	transactionId: string,
+	tags: string[]
+): Promise<Tag[]> {
+	const response = await authenticatedFetchWithUser(
+		`/api/transactions/${transactionId}/tags`,
+		userId,
+		{
+			method: 'PUT',
+			body: JSON.stringify({ tags })
+		}
+	);
+
+	if (!response.ok) {
+		if (response.status === 404) {
+			throw new Error('Transaction not found');
+		}
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to update transaction tags');
+	}
+
+	const data = await response.json();
+	return data.tags || [];
+}
+
+/**
+ * Add tags to many transactions at once (up to 1000), creating missing tags
+ */
+export async function bulkTagTransactions(
+	userId: string,
+	transactionIds: string[],
+	tags: string[]
+): Promise<BulkTagResult> {
+	return bulkTagRequest(userId, '/api/transactions/bulk-tag', transactionIds, tags);
+}
+
+/**
+ * Remove tags from many transactions at once (up to 1000)
+ */
+export async function bulkUntagTransactions(
+	userId: string,
+	transactionIds: string[],
+	tags: string[]
+): Promise<BulkTagResult> {
+	return bulkTagRequest(userId, '/api/transactions/bulk-untag', transactionIds, tags);
+}
+
+async function bulkTagRequest(
+	userId: string,
+	path: string,
+	transactionIds: string[],
+	tags: string[]
+): Promise<BulkTagResult> {
+	const response = await authenticatedFetchWithUser(path, userId, {
+		method: 'POST',
+		body: JSON.stringify({ transaction_ids: transactionIds, tags })
+	});
+
+	if (!response.ok) {
+		const error = await response.json();
+		throw new Error(error.error || 'Failed to update transaction tags');
+	}
+
+	return await response.json();
+}
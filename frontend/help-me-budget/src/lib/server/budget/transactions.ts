@@ -1,4 +1,5 @@
 import { authenticatedFetchWithUser } from '../api-client';
+import type { Tag } from './tags';
 
 export interface Transaction {
 	id: string;
@@ -12,6 +13,7 @@ export interface Transaction {
 	transaction_date: string;
 	notes?: string | null;
 	match_confidence: 'manual' | 'auto_high' | 'auto_low' | 'unmatched';
+	tags?: Tag[]; // Only included by getTransactions and getTransaction
 	created_at: string;
 	updated_at: string;
 }
@@ -43,6 +45,8 @@ export interface TransactionFilters {
 	category_id?: string;
 	start_date?: string;
 	end_date?: string;
+	tags?: string[]; // Tag names
+	tag_match?: 'any' | 'all'; // Default 'any'
 }
 
 /**
@@ -59,6 +63,8 @@ export async function getTransactions(
 	if (filters?.category_id) params.append('category_id', filters.category_id);
 	if (filters?.start_date) params.append('start_date', filters.start_date);
 	if (filters?.end_date) params.append('end_date', filters.end_date);
+	if (filters?.tags?.length) params.append('tags', filters.tags.join(','));
+	if (filters?.tag_match) params.append('tag_match', filters.tag_match);
 
 	if (params.toString()) {
 		url += `?${params.toString()}`;
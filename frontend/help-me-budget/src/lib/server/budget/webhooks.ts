@@ -0,0 +1,190 @@
+import { authenticatedFetchWithUser } from '../api-client';
+
+export type WebhookEvent =
+	| 'transaction.created'
+	| 'transaction.matched'
+	| 'budget.exceeded'
+	| 'bill.overdue';
+
+// Webhook types matching backend models
+export interface Webhook {
+	id: string;
+	user_id: string;
+	url: string;
+	description?: string;
+	events: WebhookEvent[];
+	secret?: string; // Only returned when created or rotated
+	is_active: boolean;
+	created_at: string;
+	updated_at: string;
+}
+
+export interface CreateWebhookRequest {
+	url: string;
+	description?: string;
+	events: WebhookEvent[];
+}
+
+export interface UpdateWebhookRequest {
+	url?: string;
+	description?: string;
+	events?: WebhookEvent[];
+	is_active?: boolean;
+}
+
+export interface WebhookDelivery {
+	id: string;
+	webhook_id: string;
+	event_type: WebhookEvent | 'ping';
+	payload: unknown;
+	status: 'pending' | 'succeeded' | 'failed';
+	attempts: number;
+	response_status?: number;
+	response_body?: string; // First 1KB of the last response, when it was 2xx
+	error?: string;
+	duration_ms?: number;
+	last_attempt_at?: string;
+	delivered_at?: string;
+	created_at: string;
+}
+
+/**
+ * Throw the API's error message, or a default if the response wasn't JSON
+ */
+async function throwResponseError(response: Response, defaultMessage: string): Promise<never> {
+	let errorMessage = defaultMessage;
+	try {
+		const error = await response.json();
+		errorMessage = error.error || errorMessage;
+	} catch {
+		// Response wasn't JSON, use default message
+	}
+	throw new Error(errorMessage);
+}
+
+/**
+ * Get all of the user's webhooks and the events they can subscribe to
+ */
+export async function getWebhooks(
+	userId: string
+): Promise<{ webhooks: Webhook[]; supported_events: WebhookEvent[] }> {
+	const response = await authenticatedFetchWithUser('/api/webhooks', userId, {
+		method: 'GET'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch webhooks: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Create a webhook
+ * @returns The webhook including its signing secret, which isn't shown again
+ */
+export async function createWebhook(userId: string, webhook: CreateWebhookRequest): Promise<Webhook> {
+	const response = await authenticatedFetchWithUser('/api/webhooks', userId, {
+		method: 'POST',
+		body: JSON.stringify(webhook)
+	});
+
+	if (!response.ok) {
+		await throwResponseError(response, 'Failed to create webhook');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Update a webhook (set is_active to false to pause deliveries)
+ */
+export async function updateWebhook(
+	userId: string,
+	webhookId: string,
+	updates: UpdateWebhookRequest
+): Promise<Webhook> {
+	const response = await authenticatedFetchWithUser(`/api/webhooks/${webhookId}`, userId, {
+		method: 'PUT',
+		body: JSON.stringify(updates)
+	});
+
+	if (!response.ok) {
+		await throwResponseError(response, 'Failed to update webhook');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Delete a webhook and its delivery log
+ */
+export async function deleteWebhook(userId: string, webhookId: string): Promise<void> {
+	const response = await authenticatedFetchWithUser(`/api/webhooks/${webhookId}`, userId, {
+		method: 'DELETE'
+	});
+
+	if (!response.ok) {
+		throw new Error(`Failed to delete webhook: ${response.statusText}`);
+	}
+}
+
+/**
+ * Replace a webhook's signing secret
+ * @returns The webhook with its new secret
+ */
+export async function rotateWebhookSecret(userId: string, webhookId: string): Promise<Webhook> {
+	const response = await authenticatedFetchWithUser(
+		`/api/webhooks/${webhookId}/rotate-secret`,
+		userId,
+		{
+			method: 'POST'
+		}
+	);
+
+	if (!response.ok) {
+		throw new Error(`Failed to rotate webhook secret: ${response.statusText}`);
+	}
+
+	return await response.json();
+}
+
+/**
+ * Send a ping event to a webhook now
+ * @returns The delivery, including the endpoint's response
+ */
+export async function testWebhook(userId: string, webhookId: string): Promise<WebhookDelivery> {
+	const response = await authenticatedFetchWithUser(`/api/webhooks/${webhookId}/test`, userId, {
+		method: 'POST'
+	});
+
+	if (!response.ok) {
+		await throwResponseError(response, 'Failed to send test event');
+	}
+
+	return await response.json();
+}
+
+/**
+ * Get a webhook's delivery log, newest first
+ */
+export async function getWebhookDeliveries(
+	userId: string,
+	webhookId: string,
+	limit: number = 50
+): Promise<WebhookDelivery[]> {
+	const response = await authenticatedFetchWithUser(
+		`/api/webhooks/${webhookId}/deliveries?limit=${limit}`,
+		userId,
+		{
+			method: 'GET'
+		}
+	);
+
+	if (!response.ok) {
+		throw new Error(`Failed to fetch webhook deliveries: ${response.statusText}`);
+	}
+
+	const data = await response.json();
+	return data.deliveries || [];
+}
@@ -0,0 +1,32 @@
+import { error } from '@sveltejs/kit';
+import type { RequestHandler } from './$types';
+import { fetchAttachmentDownload } from '$lib/server/budget/attachments';
+
+// Serves attachment files from the signed download URLs the API hands out when
+// files are stored locally. The signature in the URL is the only credential,
+// so links work in <img> tags and new tabs; the API key is added here.
+export const GET: RequestHandler = async ({ params, url }) => {
+	const response = await fetchAttachmentDownload(params.id, url.search);
+
+	if (response.status === 403) {
+		throw error(403, 'Download link is invalid or has expired');
+	}
+	if (response.status === 404) {
+		throw error(404, 'Attachment not found');
+	}
+	if (!response.ok) {
+		throw error(502, 'Failed to fetch attachment');
+	}
+
+	const headers = new Headers({
+		'Content-Type': response.headers.get('Content-Type') ?? 'application/octet-stream',
+		'X-Content-Type-Options': 'nosniff',
+		'Cache-Control': 'private, max-age=300'
+	});
+	const disposition = response.headers.get('Content-Disposition');
+	if (disposition) headers.set('Content-Disposition', disposition);
+	const length = response.headers.get('Content-Length');
+	if (length) headers.set('Content-Length', length);
+
+	return new Response(response.body, { headers });
+};
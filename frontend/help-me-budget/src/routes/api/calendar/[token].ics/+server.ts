@@ -0,0 +1,24 @@
+import { error } from '@sveltejs/kit';
+import type { RequestHandler } from './$types';
+import { fetchCalendarICS } from '$lib/server/budget/calendar';
+
+// Serves the iCalendar feed to calendar apps. They can't sign in, so the token
+// in the URL is the only credential; the API key is added here.
+export const GET: RequestHandler = async ({ params }) => {
+	const response = await fetchCalendarICS(params.token);
+
+	if (response.status === 404) {
+		throw error(404, 'Calendar feed not found');
+	}
+	if (!response.ok) {
+		throw error(502, 'Failed to fetch calendar feed');
+	}
+
+	return new Response(await response.text(), {
+		headers: {
+			'Content-Type': 'text/calendar; charset=utf-8',
+			'Content-Disposition': 'inline; filename="help-me-budget.ics"',
+			'Cache-Control': 'private, max-age=900'
+		}
+	});
+};
@@ -0,0 +1,33 @@
+import { error } from '@sveltejs/kit';
+import type { RequestHandler } from './$types';
+import { getLocalUserId } from '$lib/server/auth-helpers';
+import { openEventStream } from '$lib/server/budget/events';
+
+// Proxies the API's event stream so the browser can use EventSource without
+// seeing the API key. Closing the EventSource aborts the upstream stream.
+export const GET: RequestHandler = async ({ request, locals }) => {
+	const session = await locals.safeGetSession();
+
+	if (!session?.user) {
+		throw error(401, 'Unauthorized');
+	}
+
+	const userId = await getLocalUserId(locals.supabase);
+
+	let upstream: Response;
+	try {
+		upstream = await openEventStream(userId, request.signal);
+	} catch (err: any) {
+		console.error('Error opening event stream:', err);
+		throw error(502, 'Failed to open event stream');
+	}
+
+	return new Response(upstream.body, {
+		headers: {
+			'Content-Type': 'text/event-stream',
+			'Cache-Control': 'no-cache',
+			Connection: 'keep-alive',
+			'X-Accel-Buffering': 'no'
+		}
+	});
+};
@@ -33,8 +33,8 @@ export const load: PageServerLoad = async ({ locals, url }) => {
 		const [spendingTrends, budgetVariance, cashFlowProjection, topExpenses, accounts] =
 			await Promise.allSettled([
 				getSpendingTrends(userId, trendsStartDate, endOfMonth),
-				getBudgetVariance(userId, month || currentMonth),
-				getCashFlowProjection(userId, days, 0), // Starting balance of 0
+				getBudgetVariance(userId, { month: month || currentMonth }).then((report) => report.entries),
+				getCashFlowProjection(userId, { days }).then((report) => report.projections),
 				getTopExpenses(userId, startOfMonth, endOfMonth, 10),
 				getAccounts(userId)
 			]).then((results) => [
@@ -45,16 +45,13 @@ export const load: PageServerLoad = async ({ locals, url }) => {
 				results[4].status === 'fulfilled' ? results[4].value : []
 			]);
 
-		// Calculate total balance from accounts for cash flow projection starting point
+		// The projection already starts from the account balances
 		const totalBalance = accounts.reduce((sum, account) => sum + account.balance, 0);
 
-		// Re-fetch cash flow with actual starting balance
-		const cashFlowWithBalance = await getCashFlowProjection(userId, days, totalBalance);
-
 		return {
 			spendingTrends,
 			budgetVariance,
-			cashFlowProjection: cashFlowWithBalance,
+			cashFlowProjection,
 			topExpenses,
 			totalBalance,
 			currentMonth